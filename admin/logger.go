@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"sync/atomic"
+
+	"github.com/apus-run/sea-kit/log"
+)
+
+var _ log.Logger = (*Logger)(nil)
+
+// Logger is a log.Logger whose level can be changed at runtime.
+type Logger struct {
+	logger log.Logger
+	level  atomic.Int32
+}
+
+// NewLogger wraps logger, dropping records below level.
+func NewLogger(logger log.Logger, level log.Level) *Logger {
+	l := &Logger{logger: logger}
+	l.SetLevel(level)
+	return l
+}
+
+// Log implements log.Logger.
+func (l *Logger) Log(level log.Level, keyvals ...interface{}) error {
+	if level < l.Level() {
+		return nil
+	}
+	return l.logger.Log(level, keyvals...)
+}
+
+// Level returns the current level.
+func (l *Logger) Level() log.Level {
+	return log.Level(l.level.Load())
+}
+
+// SetLevel changes the current level.
+func (l *Logger) SetLevel(level log.Level) {
+	l.level.Store(int32(level))
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/apus-run/gaia/health"
+	"github.com/apus-run/gaia/registry"
+)
+
+// Option is an admin server option.
+type Option func(*Server)
+
+// Network with server network.
+func Network(network string) Option {
+	return func(s *Server) {
+		s.network = network
+	}
+}
+
+// Address with server address.
+func Address(addr string) Option {
+	return func(s *Server) {
+		s.address = addr
+	}
+}
+
+// Listener with server lis
+func Listener(lis net.Listener) Option {
+	return func(s *Server) {
+		s.lis = lis
+	}
+}
+
+// Timeout with server read and write timeout, profiles that take longer
+// than the timeout are cut off.
+func Timeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// Instance with a func returning the registered service instance.
+func Instance(fn func() *registry.ServiceInstance) Option {
+	return func(s *Server) {
+		s.instance = fn
+	}
+}
+
+// Readiness with a func reporting whether the application is ready.
+func Readiness(fn func() bool) Option {
+	return func(s *Server) {
+		s.readiness = fn
+	}
+}
+
+// Health with the health aggregator reported by /healthz and /readyz.
+func Health(h *health.Health) Option {
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
+// WithLogger with the logger whose level is changed through /loglevel.
+func WithLogger(l *Logger) Option {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+// Framework with the framework name and version reported by /buildinfo.
+func Framework(name, version string) Option {
+	return func(s *Server) {
+		s.framework = map[string]string{"name": name, "version": version}
+	}
+}
+
+// Metrics with the handler of /metrics, e.g. prometheus.Handler(nil) of
+// pkg/metrics/prometheus.
+func Metrics(h http.Handler) Option {
+	return func(s *Server) {
+		s.metrics = h
+	}
+}
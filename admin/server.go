@@ -0,0 +1,202 @@
+// Package admin provides a side HTTP server exposing pprof, probes, build
+// information and runtime log level control. It is never registered in the
+// service registry.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apus-run/sea-kit/log"
+
+	"github.com/apus-run/gaia/health"
+	"github.com/apus-run/gaia/internal/inherit"
+	"github.com/apus-run/gaia/registry"
+	"github.com/apus-run/gaia/transport"
+)
+
+var (
+	_ transport.Server  = (*Server)(nil)
+	_ transport.Readier = (*Server)(nil)
+)
+
+// Server is the admin HTTP server.
+type Server struct {
+	*http.Server
+	mux       *http.ServeMux
+	lis       net.Listener
+	network   string
+	address   string
+	timeout   time.Duration
+	instance  func() *registry.ServiceInstance
+	readiness func() bool
+	health    *health.Health
+	logger    *Logger
+	framework map[string]string
+	metrics   http.Handler
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// NewServer creates an admin server by options.
+func NewServer(opts ...Option) *Server {
+	srv := &Server{
+		mux:     http.NewServeMux(),
+		network: "tcp",
+		address: ":0",
+		timeout: time.Minute,
+		ready:   make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+
+	srv.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	srv.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	srv.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	srv.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	srv.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	srv.mux.HandleFunc("/healthz", srv.healthz)
+	srv.mux.HandleFunc("/readyz", srv.readyz)
+	srv.mux.HandleFunc("/buildinfo", srv.buildInfo)
+	srv.mux.HandleFunc("/instance", srv.serveInstance)
+	srv.mux.HandleFunc("/loglevel", srv.logLevel)
+	if srv.metrics != nil {
+		srv.mux.Handle("/metrics", srv.metrics)
+	}
+
+	srv.Server = &http.Server{
+		Handler:      srv.mux,
+		ReadTimeout:  srv.timeout,
+		WriteTimeout: srv.timeout,
+	}
+	return srv
+}
+
+// Handle registers an extra handler for the given pattern.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start start the admin server.
+func (s *Server) Start(ctx context.Context) error {
+	if s.lis == nil {
+		lis, err := inherit.Listen(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.lis = lis
+	}
+	s.BaseContext = func(net.Listener) context.Context {
+		s.readyOnce.Do(func() { close(s.ready) })
+		return ctx
+	}
+	log.Infof("[admin] server is listening on: %s", s.lis.Addr().String())
+	if err := s.Serve(s.lis); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop stop the admin server.
+func (s *Server) Stop(ctx context.Context) error {
+	log.Info("[admin] server is stopping")
+	return s.Shutdown(ctx)
+}
+
+// Ready returns a channel that is closed once the server is serving.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+func (s *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	if s.health != nil {
+		health.WriteReport(w, s.health.Liveness())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) readyz(w http.ResponseWriter, _ *http.Request) {
+	if s.readiness != nil && !s.readiness() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if s.health != nil {
+		health.WriteReport(w, s.health.Readiness())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) buildInfo(w http.ResponseWriter, _ *http.Request) {
+	info := map[string]interface{}{}
+	if s.framework != nil {
+		info["framework"] = s.framework
+	}
+	if s.instance != nil {
+		if ins := s.instance(); ins != nil {
+			info["app"] = map[string]string{"id": ins.ID, "name": ins.Name, "version": ins.Version}
+		}
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info["go"] = bi.GoVersion
+		info["path"] = bi.Path
+		info["main"] = bi.Main
+		info["deps"] = bi.Deps
+		info["settings"] = bi.Settings
+	}
+	writeJSON(w, info)
+}
+
+func (s *Server) serveInstance(w http.ResponseWriter, _ *http.Request) {
+	var ins *registry.ServiceInstance
+	if s.instance != nil {
+		ins = s.instance()
+	}
+	if ins == nil {
+		http.NotFound(w, nil)
+		return
+	}
+	writeJSON(w, ins)
+}
+
+// logLevel returns the current log level on GET and changes it on PUT or
+// POST with the level form value, e.g. level=debug.
+func (s *Server) logLevel(w http.ResponseWriter, r *http.Request) {
+	if s.logger == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level := r.FormValue("level")
+		if level == "" || log.ParseLevel(level).String() != strings.ToUpper(level) {
+			http.Error(w, "invalid level: "+level, http.StatusBadRequest)
+			return
+		}
+		s.logger.SetLevel(log.ParseLevel(level))
+		log.Infof("[admin] log level changed to %s", s.logger.Level())
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, map[string]string{"level": s.logger.Level().String()})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("[admin] failed to encode response: %v", err)
+	}
+}
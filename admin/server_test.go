@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apus-run/sea-kit/log"
+
+	"github.com/apus-run/gaia/health"
+	"github.com/apus-run/gaia/registry"
+)
+
+func serve(srv *Server, method, target string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, nil)
+	srv.Handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestServer_Probes(t *testing.T) {
+	ready := false
+	srv := NewServer(Readiness(func() bool { return ready }))
+	if code := serve(srv, http.MethodGet, "/healthz").Code; code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if code := serve(srv, http.MethodGet, "/readyz").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d got %d", http.StatusServiceUnavailable, code)
+	}
+	ready = true
+	if code := serve(srv, http.MethodGet, "/readyz").Code; code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+}
+
+func TestServer_Instance(t *testing.T) {
+	var ins *registry.ServiceInstance
+	srv := NewServer(
+		Instance(func() *registry.ServiceInstance { return ins }),
+		Framework("Gaia", "v1"),
+	)
+	if code := serve(srv, http.MethodGet, "/instance").Code; code != http.StatusNotFound {
+		t.Errorf("expected %d got %d", http.StatusNotFound, code)
+	}
+	ins = &registry.ServiceInstance{ID: "1", Name: "gaia", Version: "v1.0.0"}
+	res := serve(srv, http.MethodGet, "/instance")
+	var got registry.ServiceInstance
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != ins.ID || got.Name != ins.Name {
+		t.Errorf("expected %v got %v", ins, got)
+	}
+
+	res = serve(srv, http.MethodGet, "/buildinfo")
+	info := map[string]interface{}{}
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := info["framework"]; !ok {
+		t.Errorf("expected framework in %v", info)
+	}
+	if _, ok := info["app"]; !ok {
+		t.Errorf("expected app in %v", info)
+	}
+}
+
+type countLogger struct {
+	n int
+}
+
+func (l *countLogger) Log(level log.Level, keyvals ...interface{}) error {
+	l.n++
+	return nil
+}
+
+func TestServer_LogLevel(t *testing.T) {
+	cl := &countLogger{}
+	logger := NewLogger(cl, log.LevelInfo)
+	srv := NewServer(WithLogger(logger))
+
+	_ = logger.Log(log.LevelDebug, "msg", "dropped")
+	if cl.n != 0 {
+		t.Errorf("expected debug record to be dropped")
+	}
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(url.Values{"level": {"debug"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.Handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected %d got %d", http.StatusOK, res.Code)
+	}
+	if logger.Level() != log.LevelDebug {
+		t.Errorf("expected %v got %v", log.LevelDebug, logger.Level())
+	}
+	_ = logger.Log(log.LevelDebug, "msg", "kept")
+	if cl.n != 1 {
+		t.Errorf("expected debug record to be logged")
+	}
+
+	if code := serve(srv, http.MethodPut, "/loglevel?level=verbose").Code; code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, code)
+	}
+	if code := serve(srv, http.MethodGet, "/loglevel").Code; code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(Address("127.0.0.1:0"))
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	select {
+	case <-srv.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("server is not ready")
+	}
+	res, err := http.Get("http://" + srv.lis.Addr().String() + "/debug/pprof/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, res.StatusCode)
+	}
+	if err := srv.Stop(ctx); err != nil {
+		t.Errorf("expected nil got %v", err)
+	}
+}
+
+func TestServer_Health(t *testing.T) {
+	ctx := context.Background()
+	h := health.New()
+	h.AddReadiness("db", health.CheckerFunc(func(context.Context) error { return errors.New("down") }))
+	go func() {
+		_ = h.Start(ctx)
+	}()
+	defer h.Stop(ctx)
+	<-h.Ready()
+
+	srv := NewServer(Health(h))
+	if code := serve(srv, http.MethodGet, "/healthz").Code; code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if code := serve(srv, http.MethodGet, "/readyz").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d got %d", http.StatusServiceUnavailable, code)
+	}
+}
+
+func TestServer_Metrics(t *testing.T) {
+	if code := serve(NewServer(), http.MethodGet, "/metrics").Code; code != http.StatusNotFound {
+		t.Errorf("expected %d got %d", http.StatusNotFound, code)
+	}
+	srv := NewServer(Metrics(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("gaia_server_requests_total 1\n"))
+	})))
+	res := serve(srv, http.MethodGet, "/metrics")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "gaia_server_requests_total") {
+		t.Errorf("expected the metrics got %d %q", res.Code, res.Body.String())
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apus-run/gaia/pkg/errcode"
+)
+
+const (
+	errorsPackage  = protogen.GoImportPath("errors")
+	errCodePackage = protogen.GoImportPath("github.com/apus-run/gaia/pkg/errcode")
+)
+
+// defaultStatus is the HTTP status of enums without default_status.
+const defaultStatus = 500
+
+// generateFile generates a _errors.pb.go file with the errcode definitions
+// of the enums with a default_status or a domain option.
+func generateFile(gen *protogen.Plugin, file *protogen.File) (*protogen.GeneratedFile, error) {
+	var enums []*protogen.Enum
+	for _, enum := range file.Enums {
+		if hasErrorOptions(enum) {
+			enums = append(enums, enum)
+		}
+	}
+	if len(enums) == 0 {
+		return nil, nil
+	}
+	filename := file.GeneratedFilenamePrefix + "_errors.pb.go"
+	g := gen.NewGeneratedFile(filename, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-errors. DO NOT EDIT.")
+	g.P("// protoc-gen-go-errors ", version)
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	for _, enum := range enums {
+		if err := genErrors(g, enum); err != nil {
+			g.Skip()
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func hasErrorOptions(enum *protogen.Enum) bool {
+	opts := enum.Desc.Options()
+	return proto.HasExtension(opts, errcode.E_DefaultStatus) || proto.HasExtension(opts, errcode.E_Domain)
+}
+
+// genErrors generates the definitions of enum. The code of a value is its
+// number unless set by the code option, and must not be 0, which is the
+// code of errcode.Success: clients would not decode such errors.
+func genErrors(g *protogen.GeneratedFile, enum *protogen.Enum) error {
+	opts := enum.Desc.Options()
+	status := int(proto.GetExtension(opts, errcode.E_DefaultStatus).(int32))
+	if status == 0 {
+		status = defaultStatus
+	}
+	ed := &errorsDesc{
+		Enum:      enum.GoIdent.GoName,
+		Domain:    proto.GetExtension(opts, errcode.E_Domain).(string),
+		DomainVar: lowerFirst(enum.GoIdent.GoName) + "Domain",
+
+		NewDomain: g.QualifiedGoIdent(errCodePackage.Ident("NewDomain")),
+		Newf:      g.QualifiedGoIdent(errCodePackage.Ident("Newf")),
+		FromError: g.QualifiedGoIdent(errCodePackage.Ident("FromError")),
+		Error:     g.QualifiedGoIdent(errCodePackage.Ident("Error")),
+		Is:        g.QualifiedGoIdent(errorsPackage.Ident("Is")),
+	}
+	if ed.Domain == "" {
+		ed.Domain = string(enum.Desc.FullName())
+	}
+	for _, v := range enum.Values {
+		vopts := v.Desc.Options()
+		e := &errorDesc{
+			Name:   camelCase(string(v.Desc.Name())),
+			Value:  v.GoIdent.GoName,
+			Reason: string(v.Desc.Name()),
+			Code:   int(v.Desc.Number()),
+			Status: status,
+			Msg:    proto.GetExtension(vopts, errcode.E_Msg).(string),
+		}
+		if proto.HasExtension(vopts, errcode.E_Code) {
+			e.Code = int(proto.GetExtension(vopts, errcode.E_Code).(int32))
+		}
+		if e.Code == 0 {
+			return fmt.Errorf("%s: code 0 is reserved for success, set the errcode.code option", v.Desc.FullName())
+		}
+		if s := int(proto.GetExtension(vopts, errcode.E_Status).(int32)); s != 0 {
+			e.Status = s
+		}
+		if e.Msg == "" {
+			e.Msg = e.Reason
+		}
+		ed.Errors = append(ed.Errors, e)
+	}
+	g.P(ed.execute())
+	return nil
+}
+
+// camelCase converts a SCREAMING_SNAKE_CASE name, e.g. USER_NOT_FOUND into
+// UserNotFound.
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.ToLower(s), "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
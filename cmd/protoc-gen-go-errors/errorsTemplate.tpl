@@ -0,0 +1,24 @@
+// {{ .DomainVar }} is the error code domain of {{ .Enum }}.
+var {{ .DomainVar }} = {{ .NewDomain }}({{ printf "%q" .Domain }}, 0, 0)
+
+var (
+{{- range .Errors }}
+	// Err{{ .Name }} is the {{ .Reason }} error, see {{ .Value }}.
+	Err{{ .Name }} = {{ $.DomainVar }}.New({{ .Code }}, {{ .Status }}, {{ printf "%q" .Reason }}, {{ printf "%q" .Msg }})
+{{- end }}
+)
+{{ range .Errors }}
+// Is{{ .Name }} reports whether err is a {{ .Reason }} error, including errors
+// received from gRPC and HTTP clients.
+func Is{{ .Name }}(err error) bool {
+	if err == nil {
+		return false
+	}
+	return {{ $.Is }}({{ $.FromError }}(err), Err{{ .Name }})
+}
+
+// Error{{ .Name }} returns a {{ .Reason }} error with the formatted message.
+func Error{{ .Name }}(format string, args ...interface{}) *{{ $.Error }} {
+	return {{ $.Newf }}({{ .Status }}, {{ printf "%q" .Reason }}, format, args...).WithCode({{ .Code }}).WithDomain({{ printf "%q" $.Domain }})
+}
+{{ end }}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/apus-run/gaia/pkg/errcode"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func newPlugin(t *testing.T, values ...*descriptorpb.EnumValueDescriptorProto) *protogen.Plugin {
+	t.Helper()
+	opts := &descriptorpb.EnumOptions{}
+	proto.SetExtension(opts, errcode.E_DefaultStatus, int32(500))
+	proto.SetExtension(opts, errcode.E_Domain, "user")
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("user/v1/user.proto"),
+		Package:    proto.String("user.v1"),
+		Dependency: []string{errcode.File_errcode_errors_proto.Path()},
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/user/v1;v1")},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name:    proto.String("ErrorReason"),
+			Value:   values,
+			Options: opts,
+		}},
+	}
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(errcode.File_errcode_errors_proto),
+			file,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen
+}
+
+func enumValue(name string, number int32, opts ...interface{}) *descriptorpb.EnumValueDescriptorProto {
+	v := &descriptorpb.EnumValueDescriptorProto{Name: proto.String(name), Number: proto.Int32(number)}
+	if len(opts) > 0 {
+		v.Options = &descriptorpb.EnumValueOptions{}
+	}
+	for i := 0; i+1 < len(opts); i += 2 {
+		proto.SetExtension(v.Options, opts[i].(protoreflect.ExtensionType), opts[i+1])
+	}
+	return v
+}
+
+func TestGenErrors(t *testing.T) {
+	gen := newPlugin(t,
+		enumValue("USER_NOT_FOUND", 0, errcode.E_Code, int32(20001), errcode.E_Status, int32(404), errcode.E_Msg, "user not found"),
+		enumValue("USER_DISABLED", 20002, errcode.E_Status, int32(403)),
+		enumValue("USER_INTERNAL", 20003),
+	)
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if _, err := generateFile(gen, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res := gen.Response()
+	if res.Error != nil {
+		t.Fatal(res.GetError())
+	}
+	if len(res.File) != 1 || res.File[0].GetName() != "example.com/user/v1/user_errors.pb.go" {
+		t.Fatalf("unexpected files %v", res.File)
+	}
+	got := strings.ReplaceAll(res.File[0].GetContent(), version, "(devel)")
+
+	golden := filepath.Join("testdata", "user_errors.pb.go.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(expected) {
+		t.Errorf("generated file differs from %s, run go test -update:\n%s", golden, got)
+	}
+}
+
+func TestGenErrors_ZeroCode(t *testing.T) {
+	gen := newPlugin(t,
+		enumValue("USER_NOT_FOUND", 0, errcode.E_Status, int32(404)),
+	)
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if _, err := generateFile(gen, f); err == nil || !strings.Contains(err.Error(), "user.v1.USER_NOT_FOUND") {
+			t.Errorf("expected a code 0 error, got %v", err)
+		}
+	}
+}
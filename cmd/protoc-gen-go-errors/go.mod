@@ -0,0 +1,16 @@
+module github.com/apus-run/gaia/cmd/protoc-gen-go-errors
+
+go 1.20
+
+require (
+	github.com/apus-run/gaia v0.0.0-00010101000000-000000000000
+	google.golang.org/protobuf v1.30.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	google.golang.org/genproto v0.0.0-20220519153652-3a47de7e79bd // indirect
+	google.golang.org/grpc v1.48.0 // indirect
+)
+
+replace github.com/apus-run/gaia => ../..
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"strings"
+	"text/template"
+)
+
+//go:embed errorsTemplate.tpl
+var errorsTemplate string
+
+type errorsDesc struct {
+	Enum      string // ErrorReason
+	Domain    string // helloworld.ErrorReason
+	DomainVar string // errorReasonDomain
+	Errors    []*errorDesc
+
+	// qualified identifiers of the imported packages
+	NewDomain string
+	Newf      string
+	FromError string
+	Error     string
+	Is        string
+}
+
+type errorDesc struct {
+	Name   string // UserNotFound
+	Value  string // ErrorReason_USER_NOT_FOUND
+	Reason string // USER_NOT_FOUND
+	Code   int
+	Status int
+	Msg    string
+}
+
+func (e *errorsDesc) execute() string {
+	buf := new(bytes.Buffer)
+	tmpl, err := template.New("errors").Parse(strings.TrimSpace(errorsTemplate))
+	if err != nil {
+		panic(err)
+	}
+	if err := tmpl.Execute(buf, e); err != nil {
+		panic(err)
+	}
+	return strings.Trim(buf.String(), "\r\n")
+}
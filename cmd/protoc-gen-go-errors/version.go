@@ -0,0 +1,4 @@
+package main
+
+// version is the current protoc-gen-go-errors version.
+const version = "v1.0.0"
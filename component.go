@@ -0,0 +1,170 @@
+package gaia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/apus-run/gaia/transport"
+)
+
+// Component is a named part of the application with a lifecycle, e.g. a
+// database pool, a message consumer or a server other components call.
+//
+// Start may block until the component is stopped. A component implementing
+// transport.Readier, like the servers, is ready once its ready channel is
+// closed, any other component as soon as Start is called, so components that
+// their dependents must wait for implement transport.Readier. Every
+// component whose Start was called is stopped, on failures too.
+type Component interface {
+	Start(context.Context) error
+	Stop(context.Context) error
+}
+
+type component struct {
+	name string
+	c    Component
+	deps []string
+}
+
+// sortComponents orders components into levels, every component depends
+// only on components of earlier levels. It fails on duplicate names, unknown
+// dependencies and dependency cycles.
+func sortComponents(components []*component) ([][]*component, error) {
+	byName := make(map[string]*component, len(components))
+	for _, c := range components {
+		if _, ok := byName[c.name]; ok {
+			return nil, fmt.Errorf("gaia: duplicate component %q", c.name)
+		}
+		byName[c.name] = c
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	var (
+		state = make(map[string]int, len(components))
+		level = make(map[string]int, len(components))
+		path  []string
+		visit func(c *component) error
+	)
+	visit = func(c *component) error {
+		switch state[c.name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, name := range path {
+				if name == c.name {
+					cycle := append(path[i:], c.name)
+					return fmt.Errorf("gaia: component dependency cycle: %s", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		state[c.name] = visiting
+		path = append(path, c.name)
+		for _, name := range c.deps {
+			dep, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("gaia: component %q depends on unknown component %q", c.name, name)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+			if level[dep.name]+1 > level[c.name] {
+				level[c.name] = level[dep.name] + 1
+			}
+		}
+		path = path[:len(path)-1]
+		state[c.name] = visited
+		return nil
+	}
+
+	var levels [][]*component
+	for _, c := range components {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range components {
+		l := level[c.name]
+		for len(levels) <= l {
+			levels = append(levels, nil)
+		}
+		levels[l] = append(levels[l], c)
+	}
+	return levels, nil
+}
+
+// startComponents starts the components level by level, a level starts once
+// every component of the previous one is ready. Components implementing
+// transport.Readier are ready once their ready channel is closed, the others
+// as soon as their Start is called. It returns every component whose Start
+// was called, in levels, so that they can be stopped on failure.
+func (a *Gaia) startComponents(ctx context.Context, eg *errgroup.Group) ([][]*component, error) {
+	var timeout <-chan time.Time
+	if a.opts.readyTimeout > 0 {
+		timer := time.NewTimer(a.opts.readyTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var started [][]*component
+	for _, level := range a.components {
+		readies := make([]<-chan struct{}, 0, len(level))
+		for _, c := range level {
+			c := c
+			eg.Go(func() error {
+				if err := c.c.Start(ctx); err != nil {
+					return fmt.Errorf("component %s: %w", c.name, err)
+				}
+				return nil
+			})
+			if r, ok := c.c.(transport.Readier); ok {
+				readies = append(readies, r.Ready())
+			}
+		}
+		started = append(started, level)
+		for _, ready := range readies {
+			select {
+			case <-ready:
+			case <-ctx.Done():
+				return started, ctx.Err()
+			case <-timeout:
+				return started, ErrReadyTimeout
+			}
+		}
+	}
+	return started, nil
+}
+
+// stopComponents stops the components in the reverse order they started,
+// the components of a level concurrently, each within the stop timeout.
+func (a *Gaia) stopComponents(ctx context.Context, started [][]*component) []error {
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		var (
+			mu sync.Mutex
+			wg sync.WaitGroup
+		)
+		for _, c := range started[i] {
+			c := c
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(ctx, a.opts.stopTimeout)
+				defer cancel()
+				if err := c.c.Stop(ctx); err != nil {
+					mu.Lock()
+					errs = append(errs, phaseError(phaseStop, fmt.Errorf("component %s: %w", c.name, err)))
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	return errs
+}
@@ -0,0 +1,212 @@
+package gaia
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockComponent is ready once Start returns nil.
+type mockComponent struct {
+	name     string
+	rec      *recorder
+	startErr error
+
+	once  sync.Once
+	ready chan struct{}
+}
+
+func (c *mockComponent) Start(ctx context.Context) error {
+	c.rec.add("start " + c.name)
+	if c.startErr == nil {
+		c.Ready()
+		close(c.ready)
+	}
+	return c.startErr
+}
+
+func (c *mockComponent) Ready() <-chan struct{} {
+	c.once.Do(func() { c.ready = make(chan struct{}) })
+	return c.ready
+}
+
+func (c *mockComponent) Stop(ctx context.Context) error {
+	c.rec.add("stop " + c.name)
+	return nil
+}
+
+// readyComponent blocks in Start like a server.
+type readyComponent struct {
+	*mockServer
+	name string
+}
+
+func (c *readyComponent) Start(ctx context.Context) error {
+	c.rec.add("start " + c.name)
+	close(c.started)
+	<-c.stop
+	return nil
+}
+
+func (c *readyComponent) Stop(ctx context.Context) error {
+	c.rec.add("stop " + c.name)
+	close(c.stop)
+	return nil
+}
+
+func TestComponent_Order(t *testing.T) {
+	rec := &recorder{}
+	var app *Gaia
+	app = New(
+		WithServer(newMockServer(rec)),
+		WithComponent("consumer", &mockComponent{name: "consumer", rec: rec}, "db", "grpc"),
+		WithComponent("grpc", &readyComponent{mockServer: newMockServer(rec), name: "grpc"}, "db"),
+		WithComponent("db", &mockComponent{name: "db", rec: rec}),
+		AfterStart(func(ctx context.Context) error {
+			return app.Stop()
+		}),
+	)
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "start grpc", "start consumer", "start", "stop", "stop consumer", "stop grpc", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestComponent_StartErrorRollsBack(t *testing.T) {
+	rec := &recorder{}
+	errStart := errors.New("connect")
+	app := New(
+		WithServer(newMockServer(rec)),
+		WithComponent("db", &mockComponent{name: "db", rec: rec}),
+		WithComponent("cache", &mockComponent{name: "cache", rec: rec, startErr: errStart}, "db"),
+		WithComponent("consumer", &mockComponent{name: "consumer", rec: rec}, "cache"),
+	)
+	if err := app.Run(); !errors.Is(err, errStart) {
+		t.Fatalf("expected %v got %v", errStart, err)
+	}
+	want := []string{"start db", "start cache", "stop cache", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestComponent_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "cycle",
+			opts: []Option{
+				WithComponent("a", &mockComponent{}, "b"),
+				WithComponent("b", &mockComponent{}, "c"),
+				WithComponent("c", &mockComponent{}, "a"),
+			},
+			want: "cycle: a -> b -> c -> a",
+		},
+		{
+			name: "unknown",
+			opts: []Option{WithComponent("a", &mockComponent{}, "b")},
+			want: `unknown component "b"`,
+		},
+		{
+			name: "duplicate",
+			opts: []Option{
+				WithComponent("a", &mockComponent{}),
+				WithComponent("a", &mockComponent{}),
+			},
+			want: `duplicate component "a"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("expected panic with %q got %v", tt.want, err)
+				}
+			}()
+			New(tt.opts...)
+		})
+	}
+}
+
+// blockingComponent blocks in Start until it is stopped, without being a
+// transport.Readier.
+type blockingComponent struct {
+	name string
+	rec  *recorder
+	stop chan struct{}
+}
+
+func (c *blockingComponent) Start(ctx context.Context) error {
+	c.rec.add("start " + c.name)
+	<-c.stop
+	return nil
+}
+
+func (c *blockingComponent) Stop(ctx context.Context) error {
+	c.rec.add("stop " + c.name)
+	close(c.stop)
+	return nil
+}
+
+// slowComponent never gets ready.
+type slowComponent struct {
+	blockingComponent
+}
+
+func (c *slowComponent) Ready() <-chan struct{} {
+	return nil
+}
+
+func TestComponent_BlockingStart(t *testing.T) {
+	rec := &recorder{}
+	var app *Gaia
+	app = New(
+		WithServer(newMockServer(rec)),
+		WithComponent("consumer", &blockingComponent{name: "consumer", rec: rec, stop: make(chan struct{})}),
+		WithReadyTimeout(time.Second),
+		AfterStart(func(ctx context.Context) error {
+			return app.Stop()
+		}),
+	)
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	// Start runs concurrently, only the stop order is deterministic
+	var stops []string
+	for _, e := range rec.get() {
+		if strings.HasPrefix(e, "stop") {
+			stops = append(stops, e)
+		}
+	}
+	if want := []string{"stop", "stop consumer"}; !reflect.DeepEqual(stops, want) {
+		t.Errorf("expected %v got %v", want, stops)
+	}
+}
+
+func TestComponent_ReadyTimeoutStopsStarted(t *testing.T) {
+	rec := &recorder{}
+	app := New(
+		WithServer(newMockServer(rec)),
+		WithComponent("db", &mockComponent{name: "db", rec: rec}),
+		WithComponent("grpc", &slowComponent{blockingComponent{name: "grpc", rec: rec, stop: make(chan struct{})}}, "db"),
+		WithReadyTimeout(20*time.Millisecond),
+	)
+	if err := app.Run(); !errors.Is(err, ErrReadyTimeout) {
+		t.Fatalf("expected %v got %v", ErrReadyTimeout, err)
+	}
+	want := []string{"start db", "start grpc", "stop grpc", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
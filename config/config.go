@@ -0,0 +1,200 @@
+// Package config loads configuration from pluggable sources, merges them in
+// priority order and lets code scan values into structs or watch keys for
+// changes.
+//
+//	c := config.New(config.WithSource(
+//		file.NewSource("configs/app.yaml"),
+//		env.NewSource("APP_"),
+//		flag.NewSource(flag.CommandLine),
+//	))
+//	if err := c.Load(); err != nil {
+//		panic(err)
+//	}
+//	var tls tls.TLS
+//	err := c.Value("server.http.tls").Scan(&tls)
+package config
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/apus-run/sea-kit/log"
+)
+
+var (
+	// ErrNotFound is returned for a key that does not exist.
+	ErrNotFound = errors.New("config: key not found")
+	// ErrClosed is returned when the config is used after Close.
+	ErrClosed = errors.New("config: closed")
+)
+
+// Observer is called with the new value when a watched key changes. The value
+// returns ErrNotFound when the key was removed.
+type Observer func(key string, value Value)
+
+// Config is a config interface.
+type Config interface {
+	// Load loads every source and starts watching them.
+	Load() error
+	// Scan decodes the whole config tree into v.
+	Scan(v interface{}) error
+	// Value returns the value at the dotted key path, e.g. "server.http.addr".
+	Value(key string) Value
+	// Watch calls o whenever the value at key changes.
+	Watch(key string, o Observer) error
+	Close() error
+}
+
+type observer struct {
+	key string
+	fn  Observer
+}
+
+type config struct {
+	opts options
+
+	mu        sync.RWMutex
+	closed    bool
+	loaded    [][]*KeyValue
+	tree      map[string]interface{}
+	observers []*observer
+	watchers  []Watcher
+}
+
+// New creates a config by options.
+func New(opts ...Option) Config {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &config{
+		opts: o,
+		tree: make(map[string]interface{}),
+	}
+}
+
+func (c *config) Load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	loaded := make([][]*KeyValue, len(c.opts.sources))
+	for i, src := range c.opts.sources {
+		kvs, err := src.Load()
+		if err != nil {
+			return err
+		}
+		loaded[i] = kvs
+	}
+	tree, err := merge(loaded)
+	if err != nil {
+		return err
+	}
+	c.loaded, c.tree = loaded, tree
+
+	for i, src := range c.opts.sources {
+		w, err := src.Watch()
+		if err != nil {
+			return err
+		}
+		if w == nil {
+			continue
+		}
+		c.watchers = append(c.watchers, w)
+		go c.watch(i, w)
+	}
+	return nil
+}
+
+// watch replaces the key values of the i-th source on every change and
+// notifies the observers of the keys that changed.
+func (c *config) watch(i int, w Watcher) {
+	for {
+		kvs, err := w.Next()
+		if err != nil {
+			c.mu.RLock()
+			closed := c.closed
+			c.mu.RUnlock()
+			if closed {
+				return
+			}
+			log.Errorf("[config] failed to watch next config: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		c.mu.Lock()
+		loaded := append([][]*KeyValue(nil), c.loaded...)
+		loaded[i] = kvs
+		tree, err := merge(loaded)
+		if err != nil {
+			c.mu.Unlock()
+			log.Errorf("[config] failed to merge next config: %v", err)
+			continue
+		}
+		old := c.tree
+		c.loaded, c.tree = loaded, tree
+		observers := append([]*observer(nil), c.observers...)
+		c.mu.Unlock()
+
+		for _, o := range observers {
+			ov, _ := lookup(old, o.key)
+			nv, ok := lookup(tree, o.key)
+			if reflect.DeepEqual(ov, nv) {
+				continue
+			}
+			if ok {
+				o.fn(o.key, &value{key: o.key, raw: nv})
+			} else {
+				o.fn(o.key, &errValue{err: ErrNotFound})
+			}
+		}
+	}
+}
+
+func (c *config) Scan(v interface{}) error {
+	return c.Value("").Scan(v)
+}
+
+func (c *config) Value(key string) Value {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	raw, ok := lookup(c.tree, key)
+	if !ok {
+		return &errValue{err: ErrNotFound}
+	}
+	return &value{key: key, raw: raw}
+}
+
+func (c *config) Watch(key string, o Observer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	c.observers = append(c.observers, &observer{key: key, fn: o})
+	return nil
+}
+
+func (c *config) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	watchers := c.watchers
+	c.watchers = nil
+	c.mu.Unlock()
+
+	var errs []error
+	for _, w := range watchers {
+		if err := w.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
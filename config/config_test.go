@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/pkg/retry"
+	"github.com/apus-run/gaia/pkg/tls"
+)
+
+type testSource struct {
+	kvs []*KeyValue
+	ch  chan []*KeyValue
+}
+
+func (s *testSource) Load() ([]*KeyValue, error) {
+	return s.kvs, nil
+}
+
+func (s *testSource) Watch() (Watcher, error) {
+	if s.ch == nil {
+		return nil, nil
+	}
+	return &testWatcher{ch: s.ch, stop: make(chan struct{})}, nil
+}
+
+type testWatcher struct {
+	ch   chan []*KeyValue
+	stop chan struct{}
+}
+
+func (w *testWatcher) Next() ([]*KeyValue, error) {
+	select {
+	case kvs := <-w.ch:
+		return kvs, nil
+	case <-w.stop:
+		return nil, errors.New("stopped")
+	}
+}
+
+func (w *testWatcher) Stop() error {
+	close(w.stop)
+	return nil
+}
+
+const testYAML = `
+server:
+  http:
+    addr: ":8000"
+    timeout: 1s
+    tls:
+      ca: ca.pem
+      insecure: true
+  retry:
+    times: 3
+    interval: 100ms
+`
+
+func TestConfig_Merge(t *testing.T) {
+	c := New(WithSource(
+		&testSource{kvs: []*KeyValue{{Key: "app.yaml", Value: []byte(testYAML), Format: "yaml"}}},
+		&testSource{kvs: []*KeyValue{{Key: "app.json", Value: []byte(`{"server":{"http":{"addr":":9000"}}}`), Format: "json"}}},
+		&testSource{kvs: []*KeyValue{{Key: "server.retry.times", Value: []byte("5")}}},
+	))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if v, err := c.Value("server.http.addr").String(); err != nil || v != ":9000" {
+		t.Errorf("expected :9000 got %v %v", v, err)
+	}
+	if v, err := c.Value("server.http.timeout").Duration(); err != nil || v != time.Second {
+		t.Errorf("expected 1s got %v %v", v, err)
+	}
+	if v, err := c.Value("server.retry.times").Int(); err != nil || v != 5 {
+		t.Errorf("expected 5 got %v %v", v, err)
+	}
+	if _, err := c.Value("server.grpc").String(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+	m, err := c.Value("server").Map()
+	if err != nil || len(m) != 2 {
+		t.Errorf("expected 2 keys got %v %v", m, err)
+	}
+}
+
+func TestConfig_Scan(t *testing.T) {
+	c := New(WithSource(
+		&testSource{kvs: []*KeyValue{{Key: "app.yaml", Value: []byte(testYAML), Format: "yaml"}}},
+	))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var conf struct {
+		Server struct {
+			HTTP struct {
+				Addr    string        `yaml:"addr"`
+				Timeout time.Duration `yaml:"timeout"`
+				TLS     tls.TLS       `yaml:"tls"`
+			} `yaml:"http"`
+			Retry retry.Retry `yaml:"retry"`
+		} `yaml:"server"`
+	}
+	if err := c.Scan(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Server.HTTP.Addr != ":8000" || conf.Server.HTTP.Timeout != time.Second {
+		t.Errorf("unexpected http config %+v", conf.Server.HTTP)
+	}
+	if want := (tls.TLS{CA: "ca.pem", Insecure: true}); !reflect.DeepEqual(conf.Server.HTTP.TLS, want) {
+		t.Errorf("expected %+v got %+v", want, conf.Server.HTTP.TLS)
+	}
+
+	var r retry.Retry
+	if err := c.Value("server.retry").Scan(&r); err != nil {
+		t.Fatal(err)
+	}
+	if want := (retry.Retry{Times: 3, Interval: 100 * time.Millisecond}); r != want {
+		t.Errorf("expected %+v got %+v", want, r)
+	}
+}
+
+func TestConfig_Watch(t *testing.T) {
+	src := &testSource{
+		kvs: []*KeyValue{{Key: "app.json", Value: []byte(`{"a":{"b":1,"c":1}}`), Format: "json"}},
+		ch:  make(chan []*KeyValue),
+	}
+	c := New(WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	changed := make(chan Value, 2)
+	if err := c.Watch("a.b", func(key string, v Value) {
+		changed <- v
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a.b is unchanged
+	src.ch <- []*KeyValue{{Key: "app.json", Value: []byte(`{"a":{"b":1,"c":2}}`), Format: "json"}}
+	src.ch <- []*KeyValue{{Key: "app.json", Value: []byte(`{"a":{"b":2,"c":2}}`), Format: "json"}}
+	select {
+	case v := <-changed:
+		if n, err := v.Int(); err != nil || n != 2 {
+			t.Errorf("expected 2 got %v %v", n, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a.b to change")
+	}
+	if v, _ := c.Value("a.c").Int(); v != 2 {
+		t.Errorf("expected 2 got %v", v)
+	}
+
+	src.ch <- []*KeyValue{{Key: "app.json", Value: []byte(`{"a":{"c":2}}`), Format: "json"}}
+	select {
+	case v := <-changed:
+		if _, err := v.Int(); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected %v got %v", ErrNotFound, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a.b to be removed")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Watch("a", func(string, Value) {}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected %v got %v", ErrClosed, err)
+	}
+}
+
+func TestConfig_UnsupportedFormat(t *testing.T) {
+	c := New(WithSource(
+		&testSource{kvs: []*KeyValue{{Key: "app.ini", Value: []byte("a=1"), Format: "ini"}}},
+	))
+	if err := c.Load(); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,63 @@
+// Package env is a config source that reads environment variables.
+//
+// A variable is kept when it starts with one of the prefixes. The prefix is
+// trimmed, the rest is lower cased and a double underscore separates nested
+// keys, so with the prefix "APP_" the variable APP_HTTP__READ_TIMEOUT sets the
+// key "http.read_timeout".
+package env
+
+import (
+	"os"
+	"strings"
+
+	"github.com/apus-run/gaia/config"
+)
+
+var _ config.Source = (*env)(nil)
+
+type env struct {
+	prefixes []string
+}
+
+// NewSource creates an environment variable source of prefixes, no prefix
+// keeps every variable.
+func NewSource(prefixes ...string) config.Source {
+	return &env{prefixes: prefixes}
+}
+
+func (e *env) Load() ([]*config.KeyValue, error) {
+	var kvs []*config.KeyValue
+	for _, kv := range os.Environ() {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		k, ok = e.trim(k)
+		if !ok || k == "" {
+			continue
+		}
+		kvs = append(kvs, &config.KeyValue{
+			Key:   strings.ReplaceAll(strings.ToLower(k), "__", "."),
+			Value: []byte(v),
+		})
+	}
+	return kvs, nil
+}
+
+// trim trims the first matching prefix.
+func (e *env) trim(k string) (string, bool) {
+	if len(e.prefixes) == 0 {
+		return k, true
+	}
+	for _, p := range e.prefixes {
+		if strings.HasPrefix(k, p) {
+			return strings.TrimPrefix(k, p), true
+		}
+	}
+	return "", false
+}
+
+// Watch returns no watcher, the environment does not change.
+func (e *env) Watch() (config.Watcher, error) {
+	return nil, nil
+}
@@ -0,0 +1,31 @@
+package env
+
+import (
+	"testing"
+
+	"github.com/apus-run/gaia/config"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("GAIA_TEST_HTTP__READ_TIMEOUT", "3s")
+	t.Setenv("GAIA_TEST_DEBUG", "true")
+	t.Setenv("OTHER_DEBUG", "false")
+
+	c := config.New(config.WithSource(NewSource("GAIA_TEST_")))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Value("http.read_timeout").String(); err != nil || v != "3s" {
+		t.Errorf("expected 3s got %v %v", v, err)
+	}
+	if v, err := c.Value("debug").Bool(); err != nil || !v {
+		t.Errorf("expected true got %v %v", v, err)
+	}
+	var m map[string]interface{}
+	if err := c.Scan(&m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 {
+		t.Errorf("expected only prefixed variables, got %v", m)
+	}
+}
@@ -0,0 +1,78 @@
+// Package file is a config source that reads a file, or every file of a
+// directory, and watches it for changes. The format is taken from the file
+// extension, e.g. yaml, json or toml.
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apus-run/gaia/config"
+)
+
+var _ config.Source = (*file)(nil)
+
+type file struct {
+	path string
+}
+
+// NewSource creates a file source of path, a file or a directory.
+func NewSource(path string) config.Source {
+	return &file{path: path}
+}
+
+func (f *file) loadFile(path string) (*config.KeyValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &config.KeyValue{
+		Key:    path,
+		Value:  data,
+		Format: format(path),
+	}, nil
+}
+
+func (f *file) loadDir(path string) ([]*config.KeyValue, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var kvs []*config.KeyValue
+	for _, e := range entries {
+		// ignore directories and hidden files
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		kv, err := f.loadFile(filepath.Join(path, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs, nil
+}
+
+func (f *file) Load() ([]*config.KeyValue, error) {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return f.loadDir(f.path)
+	}
+	kv, err := f.loadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return []*config.KeyValue{kv}, nil
+}
+
+func (f *file) Watch() (config.Watcher, error) {
+	return newWatcher(f)
+}
+
+func format(path string) string {
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
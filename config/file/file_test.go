@@ -0,0 +1,118 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/config"
+)
+
+func TestFile_Load(t *testing.T) {
+	c := config.New(config.WithSource(NewSource("../../internal/testdata/config")))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if v, err := c.Value("app.port").Int(); err != nil || v != 4000 {
+		t.Errorf("expected 4000 got %v %v", v, err)
+	}
+	if v, err := c.Value("http.read_timeout").Duration(); err != nil || v != 3*time.Second {
+		t.Errorf("expected 3s got %v %v", v, err)
+	}
+	hosts, err := c.Value("app.auto_tls.hosts").Slice()
+	if err != nil || len(hosts) != 2 {
+		t.Errorf("expected 2 hosts got %v %v", hosts, err)
+	}
+}
+
+func TestFile_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.toml")
+	if err := os.WriteFile(path, []byte("[http]\naddr = \":8000\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New(config.WithSource(NewSource(path)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if v, _ := c.Value("http.addr").String(); v != ":8000" {
+		t.Fatalf("expected :8000 got %v", v)
+	}
+	changed := make(chan string, 10)
+	if err := c.Watch("http.addr", func(key string, v config.Value) {
+		s, _ := v.String()
+		changed <- s
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("[http]\naddr = \":9000\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case v := <-changed:
+			if v == ":9000" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected http.addr to change")
+		}
+	}
+}
+
+func TestFile_WatchSymlink(t *testing.T) {
+	// the layout of a mounted config map
+	dir := t.TempDir()
+	for i, addr := range []string{":8000", ":9000"} {
+		data := filepath.Join(dir, fmt.Sprintf("..data_%d", i))
+		if err := os.Mkdir(data, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(data, "app.toml"), []byte("[http]\naddr = \""+addr+"\"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("..data_0", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "app.toml")
+	if err := os.Symlink(filepath.Join("..data", "app.toml"), path); err != nil {
+		t.Fatal(err)
+	}
+	c := config.New(config.WithSource(NewSource(path)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	changed := make(chan string, 10)
+	if err := c.Watch("http.addr", func(key string, v config.Value) {
+		s, _ := v.String()
+		changed <- s
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..data_1", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case v := <-changed:
+			if v == ":9000" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected http.addr to change")
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/apus-run/gaia/config"
+)
+
+var _ config.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	f  *file
+	fw *fsnotify.Watcher
+	// target is the file the path resolves to, config maps update files by
+	// swapping the symlink of their ..data directory
+	target string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWatcher(f *file) (config.Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// watch the directory, editors and config maps replace files by renaming
+	dir := f.path
+	if fi, err := os.Stat(f.path); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(f.path)
+	}
+	if err := fw.Add(dir); err != nil {
+		_ = fw.Close()
+		return nil, err
+	}
+	target, _ := filepath.EvalSymlinks(f.path)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{f: f, fw: fw, target: target, ctx: ctx, cancel: cancel}, nil
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case event, ok := <-w.fw.Events:
+			if !ok {
+				return nil, context.Canceled
+			}
+			if !w.match(event) {
+				continue
+			}
+			return w.f.Load()
+		case err, ok := <-w.fw.Errors:
+			if !ok {
+				return nil, context.Canceled
+			}
+			return nil, err
+		}
+	}
+}
+
+// match reports whether the event changes the content of the source.
+func (w *watcher) match(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	if filepath.Clean(event.Name) == filepath.Clean(w.f.path) {
+		return true
+	}
+	// any file of a watched directory
+	fi, err := os.Stat(w.f.path)
+	if err == nil && fi.IsDir() {
+		return true
+	}
+	// a symlink of the directory swapped, the path resolves to a new file
+	if event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+		target, err := filepath.EvalSymlinks(w.f.path)
+		if err == nil && target != w.target {
+			w.target = target
+			return true
+		}
+	}
+	return false
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return w.fw.Close()
+}
@@ -0,0 +1,38 @@
+// Package flag is a config source that reads command line flags. A flag name
+// is used as the dotted key, e.g. -http.addr=:8000 sets "http.addr". Only
+// flags that were set are read, so the defaults never override other sources.
+package flag
+
+import (
+	stdflag "flag"
+
+	"github.com/apus-run/gaia/config"
+)
+
+var _ config.Source = (*flag)(nil)
+
+type flag struct {
+	fs *stdflag.FlagSet
+}
+
+// NewSource creates a flag source of a parsed flag set, e.g.
+// flag.CommandLine.
+func NewSource(fs *stdflag.FlagSet) config.Source {
+	return &flag{fs: fs}
+}
+
+func (f *flag) Load() ([]*config.KeyValue, error) {
+	var kvs []*config.KeyValue
+	f.fs.Visit(func(fl *stdflag.Flag) {
+		kvs = append(kvs, &config.KeyValue{
+			Key:   fl.Name,
+			Value: []byte(fl.Value.String()),
+		})
+	})
+	return kvs, nil
+}
+
+// Watch returns no watcher, flags do not change once parsed.
+func (f *flag) Watch() (config.Watcher, error) {
+	return nil, nil
+}
@@ -0,0 +1,28 @@
+package flag
+
+import (
+	stdflag "flag"
+	"testing"
+
+	"github.com/apus-run/gaia/config"
+)
+
+func TestFlag(t *testing.T) {
+	fs := stdflag.NewFlagSet("test", stdflag.ContinueOnError)
+	fs.String("http.addr", ":8000", "")
+	fs.Int("http.port", 8000, "")
+	if err := fs.Parse([]string{"-http.addr=:9000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.New(config.WithSource(NewSource(fs)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Value("http.addr").String(); err != nil || v != ":9000" {
+		t.Errorf("expected :9000 got %v %v", v, err)
+	}
+	if _, err := c.Value("http.port").Int(); err == nil {
+		t.Error("expected unset flag to be skipped")
+	}
+}
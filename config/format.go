@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Unmarshaler decodes a document into v.
+type Unmarshaler func(data []byte, v interface{}) error
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Unmarshaler{
+		"json": json.Unmarshal,
+		"yaml": yaml.Unmarshal,
+		"yml":  yaml.Unmarshal,
+		"toml": toml.Unmarshal,
+	}
+)
+
+// RegisterFormat registers the unmarshaler of a document format, json, yaml,
+// yml and toml are registered by default.
+func RegisterFormat(format string, fn Unmarshaler) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[format] = fn
+}
+
+// decode decodes a document into a map.
+func decode(kv *KeyValue) (map[string]interface{}, error) {
+	formatsMu.RLock()
+	fn, ok := formats[kv.Format]
+	formatsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("config: unsupported format %q of %s", kv.Format, kv.Key)
+	}
+	m := make(map[string]interface{})
+	if err := fn(kv.Value, &m); err != nil {
+		return nil, fmt.Errorf("config: failed to decode %s: %w", kv.Key, err)
+	}
+	return m, nil
+}
+
+// parseScalar parses a plain value, e.g. from an environment variable, as a
+// YAML scalar so that numbers and booleans keep their type. Anything else is
+// kept as a string.
+func parseScalar(data []byte) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return string(data)
+	}
+	switch v.(type) {
+	case bool, int, int64, uint64, float64:
+		return v
+	}
+	return string(data)
+}
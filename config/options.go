@@ -0,0 +1,16 @@
+package config
+
+// Option is a config option.
+type Option func(o *options)
+
+type options struct {
+	sources []Source
+}
+
+// WithSource with config sources, later sources take precedence over earlier
+// ones, e.g. a file first, then environment variables, then flags.
+func WithSource(s ...Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, s...)
+	}
+}
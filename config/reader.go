@@ -0,0 +1,83 @@
+package config
+
+import (
+	"strings"
+)
+
+// merge merges the key values of every source in order into one tree, later
+// sources take precedence.
+func merge(sources [][]*KeyValue) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	for _, kvs := range sources {
+		for _, kv := range kvs {
+			if kv.Format == "" {
+				set(root, kv.Key, parseScalar(kv.Value))
+				continue
+			}
+			m, err := decode(kv)
+			if err != nil {
+				return nil, err
+			}
+			mergeMap(root, m)
+		}
+	}
+	return root, nil
+}
+
+// mergeMap merges src into dst, nested maps are merged and anything else is
+// replaced.
+func mergeMap(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sm, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dm, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dm = make(map[string]interface{}, len(sm))
+			dst[k] = dm
+		}
+		mergeMap(dm, sm)
+	}
+}
+
+// set sets v at the dotted path, creating or replacing intermediate maps.
+func set(root map[string]interface{}, path string, v interface{}) {
+	keys := strings.Split(path, ".")
+	m := root
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[k] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = v
+}
+
+// lookup returns the value at the dotted path, an empty path is the root.
+func lookup(root map[string]interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return root, true
+	}
+	var cur interface{} = root
+	for _, k := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
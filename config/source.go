@@ -0,0 +1,27 @@
+package config
+
+// KeyValue is a config key value.
+//
+// A KeyValue with a Format holds a whole document that is decoded and merged
+// at the root of the config tree, e.g. a YAML file. A KeyValue without a
+// Format holds a single value at the dotted Key path, e.g. an environment
+// variable.
+type KeyValue struct {
+	Key    string
+	Value  []byte
+	Format string
+}
+
+// Source is a config source.
+type Source interface {
+	Load() ([]*KeyValue, error)
+	// Watch returns a nil Watcher when the source never changes.
+	Watch() (Watcher, error)
+}
+
+// Watcher watches a source for changes.
+type Watcher interface {
+	// Next blocks until the source changes and returns all of its key values.
+	Next() ([]*KeyValue, error)
+	Stop() error
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+// Value is a config value.
+type Value interface {
+	Bool() (bool, error)
+	Int() (int64, error)
+	Float() (float64, error)
+	String() (string, error)
+	Duration() (time.Duration, error)
+	Slice() ([]Value, error)
+	Map() (map[string]Value, error)
+	Scan(v interface{}) error
+	Load() interface{}
+}
+
+type value struct {
+	key string
+	raw interface{}
+}
+
+type errValue struct {
+	err error
+}
+
+var (
+	_ Value = (*value)(nil)
+	_ Value = (*errValue)(nil)
+)
+
+func (v *value) Bool() (bool, error)     { return cast.ToBoolE(v.raw) }
+func (v *value) Int() (int64, error)     { return cast.ToInt64E(v.raw) }
+func (v *value) Float() (float64, error) { return cast.ToFloat64E(v.raw) }
+func (v *value) String() (string, error) { return cast.ToStringE(v.raw) }
+func (v *value) Load() interface{}       { return v.raw }
+
+func (v *value) Duration() (time.Duration, error) {
+	return cast.ToDurationE(v.raw)
+}
+
+func (v *value) Slice() ([]Value, error) {
+	s, ok := v.raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config: %s is %T, not a slice", v.key, v.raw)
+	}
+	vs := make([]Value, 0, len(s))
+	for i, raw := range s {
+		vs = append(vs, &value{key: fmt.Sprintf("%s[%d]", v.key, i), raw: raw})
+	}
+	return vs, nil
+}
+
+func (v *value) Map() (map[string]Value, error) {
+	m, ok := v.raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config: %s is %T, not a map", v.key, v.raw)
+	}
+	vs := make(map[string]Value, len(m))
+	for k, raw := range m {
+		vs[k] = &value{key: join(v.key, k), raw: raw}
+	}
+	return vs, nil
+}
+
+// Scan decodes the value into v through YAML, so fields are matched by their
+// yaml tag or lower cased name, and durations are written like "1s".
+func (v *value) Scan(out interface{}) error {
+	data, err := yaml.Marshal(v.raw)
+	if err != nil {
+		return err
+	}
+	if err = yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("config: failed to scan %s: %w", v.key, err)
+	}
+	return nil
+}
+
+func (v *errValue) Bool() (bool, error)              { return false, v.err }
+func (v *errValue) Int() (int64, error)              { return 0, v.err }
+func (v *errValue) Float() (float64, error)          { return 0, v.err }
+func (v *errValue) String() (string, error)          { return "", v.err }
+func (v *errValue) Duration() (time.Duration, error) { return 0, v.err }
+func (v *errValue) Slice() ([]Value, error)          { return nil, v.err }
+func (v *errValue) Map() (map[string]Value, error)   { return nil, v.err }
+func (v *errValue) Scan(interface{}) error           { return v.err }
+func (v *errValue) Load() interface{}                { return nil }
@@ -0,0 +1,67 @@
+// Package encoding is the codec registry of the transports, codecs are
+// registered by name, which is the subtype of their content type, e.g. json
+// for application/json.
+//
+// The codecs of the sub-packages register themselves when imported, the
+// HTTP transport imports all of them.
+package encoding
+
+import (
+	"strings"
+	"sync"
+)
+
+// Codec defines the interface Transport uses to encode and decode messages. Note
+// that implementations of this interface must be thread safe; a Codec's
+// methods can be called from concurrent goroutines.
+type Codec interface {
+	// Marshal returns the wire format of v.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal parses the wire format into v.
+	Unmarshal(data []byte, v interface{}) error
+	// Name returns the name of the Codec implementation. The returned string
+	// will be used as part of content type in transmission. The result must be
+	// static; the result cannot change between calls.
+	Name() string
+}
+
+var (
+	mu     sync.RWMutex
+	codecs = make(map[string]Codec)
+)
+
+// RegisterCodec registers the provided Codec for use with all Transport clients and
+// servers, replacing the codec registered with the same name. It panics
+// when the codec is nil or has no name.
+func RegisterCodec(codec Codec) {
+	if codec == nil {
+		panic("cannot register a nil Codec")
+	}
+	if codec.Name() == "" {
+		panic("cannot register Codec with empty string result for Name()")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	codecs[strings.ToLower(codec.Name())] = codec
+}
+
+// GetCodec gets a registered Codec by content-subtype, or nil if no Codec is
+// registered for the content-subtype.
+//
+// The content-subtype is expected to be lowercase.
+func GetCodec(contentSubtype string) Codec {
+	mu.RLock()
+	defer mu.RUnlock()
+	return codecs[contentSubtype]
+}
+
+// Names returns the names of the registered codecs.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,30 @@
+package encoding
+
+import "testing"
+
+type codec struct{ name string }
+
+func (codec) Marshal(interface{}) ([]byte, error) { return nil, nil }
+func (codec) Unmarshal([]byte, interface{}) error { return nil }
+func (c codec) Name() string                      { return c.name }
+
+func TestRegisterCodec(t *testing.T) {
+	c := codec{name: "Test"}
+	RegisterCodec(c)
+	if got := GetCodec("test"); got != c {
+		t.Errorf("expected %v got %v", c, got)
+	}
+	if GetCodec("unknown") != nil {
+		t.Error("expected no codec")
+	}
+	for _, c := range []Codec{nil, codec{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering %v to panic", c)
+				}
+			}()
+			RegisterCodec(c)
+		}()
+	}
+}
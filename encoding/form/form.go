@@ -0,0 +1,383 @@
+// Package form is the codec of URL-encoded forms, also used to decode query
+// parameters and path variables.
+//
+// Struct fields are named by their form tag, then their json tag, then
+// their name, proto message fields by their JSON or proto name. Nested
+// fields and map entries use dotted keys, e.g. user.name or attrs.key, and
+// repeated fields repeat the key.
+package form
+
+import (
+	stdencoding "encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apus-run/gaia/encoding"
+)
+
+// Name is the name registered for the form codec.
+const Name = "x-www-form-urlencoded"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with url.Values.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	vs, err := EncodeValues(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(vs.Encode()), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	vs, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	return DecodeValues(v, vs)
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+// EncodeValues encodes a struct, a proto message or url.Values into
+// url.Values, zero fields are omitted.
+func EncodeValues(v interface{}) (url.Values, error) {
+	switch m := v.(type) {
+	case url.Values:
+		return m, nil
+	case proto.Message:
+		vs := make(url.Values)
+		if err := encodeProto(vs, "", m.ProtoReflect()); err != nil {
+			return nil, err
+		}
+		return vs, nil
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form: cannot encode %T", v)
+	}
+	vs := make(url.Values)
+	if err := encodeStruct(vs, "", rv); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// DecodeValues decodes url.Values into a pointer to a struct or a proto
+// message, keys that match no field are ignored.
+func DecodeValues(v interface{}, vs url.Values) error {
+	if m, ok := v.(proto.Message); ok {
+		return decodeProto(m.ProtoReflect(), vs)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("form: cannot decode into non-pointer %T", v)
+	}
+	for key, values := range vs {
+		if err := decodeField(rv.Elem(), strings.Split(key, "."), values); err != nil {
+			return fmt.Errorf("form: %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// fieldName returns the key of a struct field, or "-" to skip it.
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"form", "json"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func encodeStruct(vs url.Values, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := fieldName(f)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && name == f.Name && reflect.Indirect(fv).Kind() == reflect.Struct {
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				continue
+			}
+			if err := encodeStruct(vs, prefix, reflect.Indirect(fv)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := encodeValue(vs, join(prefix, name), fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeValue(vs url.Values, key string, rv reflect.Value) error {
+	if rv.IsZero() {
+		return nil
+	}
+	if m, ok := rv.Interface().(proto.Message); ok {
+		return encodeProto(vs, key, m.ProtoReflect())
+	}
+	if s, ok, err := marshalText(rv); ok {
+		if err == nil {
+			vs.Add(key, s)
+		}
+		return err
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return encodeValue(vs, key, rv.Elem())
+	case reflect.Struct:
+		return encodeStruct(vs, key, rv)
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := encodeValue(vs, join(key, fmt.Sprint(iter.Key().Interface())), iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// arrays of unaddressable values have no Bytes
+			b := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), rv.Len(), rv.Len())
+			reflect.Copy(b, rv)
+			vs.Add(key, string(b.Bytes()))
+			return nil
+		}
+		for i := 0; i < rv.Len(); i++ {
+			s, err := formatScalar(rv.Index(i))
+			if err != nil {
+				return err
+			}
+			vs.Add(key, s)
+		}
+		return nil
+	}
+	s, err := formatScalar(rv)
+	if err != nil {
+		return err
+	}
+	vs.Add(key, s)
+	return nil
+}
+
+// marshalText formats values implementing encoding.TextMarshaler, such as
+// time.Time.
+func marshalText(rv reflect.Value) (string, bool, error) {
+	if m, ok := rv.Interface().(stdencoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), true, err
+	}
+	return "", false, nil
+}
+
+func formatScalar(rv reflect.Value) (string, error) {
+	if s, ok, err := marshalText(rv); ok {
+		return s, err
+	}
+	if d, ok := rv.Interface().(time.Duration); ok {
+		return d.String(), nil
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return "", nil
+		}
+		return formatScalar(rv.Elem())
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("form: cannot encode %s", rv.Type())
+}
+
+// decodeField decodes the values into the field of rv at path.
+func decodeField(rv reflect.Value, path []string, values []string) error {
+	if len(path) == 0 {
+		return setValue(rv, values)
+	}
+	if m, ok := addr(rv).Interface().(proto.Message); ok {
+		return decodeProto(m.ProtoReflect(), url.Values{strings.Join(path, "."): values})
+	}
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decodeField(rv.Elem(), path, values)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if err := decodeField(elem, path[1:], values); err != nil {
+			return err
+		}
+		rv.SetMapIndex(reflect.ValueOf(path[0]).Convert(rv.Type().Key()), elem)
+		return nil
+	case reflect.Struct:
+		if fv, ok := lookupField(rv, path[0]); ok {
+			return decodeField(fv, path[1:], values)
+		}
+	}
+	return nil
+}
+
+// lookupField returns the field of a struct by key, searching embedded
+// structs.
+func lookupField(rv reflect.Value, key string) (reflect.Value, bool) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := fieldName(f)
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == f.Name {
+			fv := rv.Field(i)
+			if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if v, ok := lookupField(fv, key); ok {
+					return v, true
+				}
+				continue
+			}
+		}
+		if name == key || strings.EqualFold(f.Name, key) {
+			return rv.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func addr(rv reflect.Value) reflect.Value {
+	if rv.CanAddr() {
+		return rv.Addr()
+	}
+	return rv
+}
+
+func setValue(rv reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return setValue(rv.Elem(), values)
+	}
+	if m, ok := addr(rv).Interface().(proto.Message); ok {
+		return unmarshalWellKnown(m.ProtoReflect(), values[0])
+	}
+	if u, ok := addr(rv).Interface().(stdencoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(values[0]))
+	}
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(rv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setValue(s.Index(i), []string{v}); err != nil {
+				return err
+			}
+		}
+		rv.Set(s)
+		return nil
+	}
+	return parseScalar(rv, values[0])
+}
+
+func parseScalar(rv reflect.Value, s string) error {
+	if _, ok := rv.Interface().(time.Duration); ok {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(int64(d))
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	case reflect.Slice:
+		// []byte
+		rv.SetBytes([]byte(s))
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return fmt.Errorf("cannot decode into %s", rv.Type())
+		}
+		rv.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("cannot decode into %s", rv.Type())
+	}
+	return nil
+}
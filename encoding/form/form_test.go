@@ -0,0 +1,119 @@
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/encoding"
+	pb "github.com/apus-run/gaia/internal/testdata/encoding"
+	"google.golang.org/protobuf/proto"
+)
+
+type Base struct {
+	ID int64 `json:"id"`
+}
+
+type user struct {
+	Base
+	Name    string            `json:"name"`
+	Tags    []string          `form:"tag"`
+	Age     *int              `json:"age,omitempty"`
+	Timeout time.Duration     `json:"timeout"`
+	Created time.Time         `json:"created"`
+	Labels  map[string]string `json:"labels"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+	Ignored string `json:"-"`
+}
+
+func TestCodec_Struct(t *testing.T) {
+	c := encoding.GetCodec(Name)
+	if c == nil {
+		t.Fatal("expected the form codec to be registered")
+	}
+	age := 3
+	in := user{
+		Base:    Base{ID: 1},
+		Name:    "gaia",
+		Tags:    []string{"a", "b"},
+		Age:     &age,
+		Timeout: time.Second,
+		Created: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Labels:  map[string]string{"zone": "a"},
+		Ignored: "x",
+	}
+	in.Address.City = "paris"
+	data, err := c.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, _ := url.ParseQuery(string(data))
+	expected := url.Values{
+		"id":           {"1"},
+		"name":         {"gaia"},
+		"tag":          {"a", "b"},
+		"age":          {"3"},
+		"timeout":      {"1s"},
+		"created":      {"2023-01-02T03:04:05Z"},
+		"labels.zone":  {"a"},
+		"address.city": {"paris"},
+	}
+	if !reflect.DeepEqual(vs, expected) {
+		t.Errorf("expected %v got %v", expected, vs)
+	}
+
+	var out user
+	if err = c.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Ignored = ""
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("expected %+v got %+v", in, out)
+	}
+
+	if err = DecodeValues(&out, url.Values{"age": {"x"}}); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestCodec_ByteArray(t *testing.T) {
+	in := struct {
+		Code [4]byte `json:"code"`
+		Data []byte  `json:"data"`
+	}{Code: [4]byte{'g', 'a', 'i', 'a'}, Data: []byte("data")}
+	// the struct is passed by value, its array is not addressable
+	data, err := encoding.GetCodec(Name).Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, _ := url.ParseQuery(string(data))
+	expected := url.Values{"code": {"gaia"}, "data": {"data"}}
+	if !reflect.DeepEqual(vs, expected) {
+		t.Errorf("expected %v got %v", expected, vs)
+	}
+}
+
+func TestCodec_Proto(t *testing.T) {
+	in := &pb.TestModel{Id: 1, Name: "gaia", Hobby: []string{"a", "b"}, Attrs: map[string]string{"k": "v"}}
+	vs, err := EncodeValues(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := url.Values{"id": {"1"}, "name": {"gaia"}, "hobby": {"a", "b"}, "attrs.k": {"v"}}
+	if !reflect.DeepEqual(vs, expected) {
+		t.Errorf("expected %v got %v", expected, vs)
+	}
+	out := &pb.TestModel{}
+	if err = DecodeValues(out, vs); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(in, out) {
+		t.Errorf("expected %v got %v", in, out)
+	}
+	if err = DecodeValues(out, url.Values{"id": {"x"}}); err == nil {
+		t.Error("expected a parse error")
+	}
+}
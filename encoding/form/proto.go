@@ -0,0 +1,217 @@
+package form
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// wellKnown reports whether the message is a well-known type, encoded as a
+// single value in its protojson form, e.g. a timestamp or a wrapper.
+func wellKnown(md protoreflect.MessageDescriptor) bool {
+	return md.FullName().Parent() == "google.protobuf"
+}
+
+func encodeProto(vs url.Values, prefix string, m protoreflect.Message) error {
+	if wellKnown(m.Descriptor()) {
+		s, err := marshalWellKnown(m)
+		if err != nil {
+			return err
+		}
+		vs.Add(prefix, s)
+		return nil
+	}
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		key := join(prefix, fd.JSONName())
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				err = encodeProtoValue(vs, key, fd, list.Get(i))
+			}
+		case fd.IsMap():
+			v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				err = encodeProtoValue(vs, join(key, k.String()), fd.MapValue(), v)
+				return err == nil
+			})
+		default:
+			err = encodeProtoValue(vs, key, fd, v)
+		}
+		return err == nil
+	})
+	return err
+}
+
+func encodeProtoValue(vs url.Values, key string, fd protoreflect.FieldDescriptor, v protoreflect.Value) error {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return encodeProto(vs, key, v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			vs.Add(key, string(ev.Name()))
+		} else {
+			vs.Add(key, strconv.Itoa(int(v.Enum())))
+		}
+	case protoreflect.BytesKind:
+		vs.Add(key, base64.URLEncoding.EncodeToString(v.Bytes()))
+	default:
+		vs.Add(key, v.String())
+	}
+	return nil
+}
+
+func marshalWellKnown(m protoreflect.Message) (string, error) {
+	b, err := protojson.Marshal(m.Interface())
+	if err != nil {
+		return "", err
+	}
+	if s, err := strconv.Unquote(string(b)); err == nil {
+		return s, nil
+	}
+	return string(b), nil
+}
+
+// unmarshalWellKnown parses a value in its protojson form, quoted or not.
+func unmarshalWellKnown(m protoreflect.Message, s string) error {
+	if err := protojson.Unmarshal([]byte(s), m.Interface()); err == nil {
+		return nil
+	}
+	return protojson.Unmarshal([]byte(strconv.Quote(s)), m.Interface())
+}
+
+func decodeProto(m protoreflect.Message, vs url.Values) error {
+	for key, values := range vs {
+		if len(values) == 0 {
+			continue
+		}
+		if err := decodeProtoField(m, strings.Split(key, "."), values); err != nil {
+			return fmt.Errorf("form: %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func decodeProtoField(m protoreflect.Message, path []string, values []string) error {
+	if wellKnown(m.Descriptor()) && len(path) == 0 {
+		return unmarshalWellKnown(m, values[0])
+	}
+	if len(path) == 0 {
+		return nil
+	}
+	fields := m.Descriptor().Fields()
+	fd := fields.ByJSONName(path[0])
+	if fd == nil {
+		fd = fields.ByName(protoreflect.Name(path[0]))
+	}
+	if fd == nil {
+		return nil
+	}
+	switch {
+	case fd.IsList():
+		if len(path) > 1 {
+			return nil
+		}
+		list := m.Mutable(fd).List()
+		for _, s := range values {
+			if fd.Message() != nil {
+				elem := list.NewElement()
+				if err := decodeProtoField(elem.Message(), nil, []string{s}); err != nil {
+					return err
+				}
+				list.Append(elem)
+				continue
+			}
+			v, err := parseProtoValue(fd, s)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	case fd.IsMap():
+		if len(path) != 2 {
+			return nil
+		}
+		k, err := parseProtoValue(fd.MapKey(), path[1])
+		if err != nil {
+			return err
+		}
+		mp := m.Mutable(fd).Map()
+		if fd.MapValue().Message() != nil {
+			v := mp.NewValue()
+			if err = decodeProtoField(v.Message(), nil, values); err != nil {
+				return err
+			}
+			mp.Set(k.MapKey(), v)
+			return nil
+		}
+		v, err := parseProtoValue(fd.MapValue(), values[0])
+		if err != nil {
+			return err
+		}
+		mp.Set(k.MapKey(), v)
+		return nil
+	case fd.Message() != nil:
+		return decodeProtoField(m.Mutable(fd).Message(), path[1:], values)
+	}
+	if len(path) > 1 {
+		return nil
+	}
+	v, err := parseProtoValue(fd, values[0])
+	if err != nil {
+		return err
+	}
+	m.Set(fd, v)
+	return nil
+}
+
+func parseProtoValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown enum value %q", s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		for _, enc := range []*base64.Encoding{base64.URLEncoding, base64.StdEncoding, base64.RawURLEncoding, base64.RawStdEncoding} {
+			if b, err := enc.DecodeString(s); err == nil {
+				return protoreflect.ValueOfBytes(b), nil
+			}
+		}
+		return protoreflect.ValueOfBytes([]byte(s)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
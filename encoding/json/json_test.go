@@ -0,0 +1,34 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/apus-run/gaia/encoding"
+	pb "github.com/apus-run/gaia/internal/testdata/encoding"
+)
+
+func TestCodec(t *testing.T) {
+	c := encoding.GetCodec(Name)
+	if c == nil {
+		t.Fatal("expected the json codec to be registered")
+	}
+
+	data, err := c.Marshal(&pb.TestModel{Id: 1, Name: "gaia"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m *pb.TestModel
+	if err = c.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Id != 1 || m.Name != "gaia" {
+		t.Errorf("unexpected message %v", m)
+	}
+
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err = c.Unmarshal([]byte(`{"name":"gaia","unknown":1}`), &v); err != nil || v.Name != "gaia" {
+		t.Errorf("unexpected struct %+v, %v", v, err)
+	}
+}
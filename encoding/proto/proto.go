@@ -0,0 +1,40 @@
+// Package proto is the protobuf codec.
+package proto
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apus-run/gaia/encoding"
+)
+
+// Name is the name registered for the proto codec.
+const Name = "proto"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with protobuf. It is the default codec for Transport.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("proto: %T is not a proto message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("proto: %T is not a proto message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (codec) Name() string {
+	return Name
+}
@@ -0,0 +1,30 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apus-run/gaia/encoding"
+	pb "github.com/apus-run/gaia/internal/testdata/encoding"
+)
+
+func TestCodec(t *testing.T) {
+	c := encoding.GetCodec(Name)
+	if c == nil {
+		t.Fatal("expected the yaml codec to be registered")
+	}
+	data, err := c.Marshal(&pb.TestModel{Id: 1, Name: "gaia", Hobby: []string{"go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "name: gaia") {
+		t.Errorf("unexpected document %s", data)
+	}
+	m := &pb.TestModel{}
+	if err = c.Unmarshal(data, m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Id != 1 || m.Name != "gaia" || len(m.Hobby) != 1 {
+		t.Errorf("unexpected message %v", m)
+	}
+}
@@ -6,20 +6,36 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/apus-run/sea-kit/log"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/apus-run/gaia/admin"
+	"github.com/apus-run/gaia/config"
+	"github.com/apus-run/gaia/internal/inherit"
 	"github.com/apus-run/gaia/registry"
 	"github.com/apus-run/gaia/transport"
 )
 
+// ErrReadyTimeout is returned by Run when the servers are not serving
+// within the ready timeout.
+var ErrReadyTimeout = errors.New("timed out waiting for servers to be ready")
+
+// exit and startProcess are replaced in tests.
+var (
+	exit         = os.Exit
+	startProcess = inherit.StartProcess
+)
+
 type AppInfo interface {
 	ID() string
 	Name() string
 	Version() string
 	Metadata() map[string]string
 	Endpoint() []string
+	Config() config.Config
 }
 
 type Gaia struct {
@@ -28,21 +44,85 @@ type Gaia struct {
 	cancel   func()
 	mu       sync.Mutex
 	instance *registry.ServiceInstance
+	admin    *admin.Server
+	ready    atomic.Bool
+
+	// components in levels, see sortComponents
+	components [][]*component
+
+	// registration state, changed by the health aggregator, see watchHealth
+	regMu      sync.Mutex
+	registered bool
+	syncing    bool
 }
 
-// New create an application lifecycle manager.
+// New create an application lifecycle manager. It panics when the component
+// dependencies are invalid.
 func New(opts ...Option) *Gaia {
 	o := Apply(opts...)
+	if o.health != nil {
+		// checks run once the components they check have started
+		deps := make([]string, 0, len(o.components))
+		for _, c := range o.components {
+			deps = append(deps, c.name)
+		}
+		o.components = append(o.components, &component{name: "health", c: o.health, deps: deps})
+	}
+	components, err := sortComponents(o.components)
+	if err != nil {
+		panic(err)
+	}
 
 	if o.logger != nil {
 		log.SetLogger(o.logger)
 	}
 	ctx, cancel := context.WithCancel(o.ctx)
-	return &Gaia{
-		ctx:    ctx,
-		cancel: cancel,
-		opts:   o,
+	a := &Gaia{
+		ctx:        ctx,
+		cancel:     cancel,
+		opts:       o,
+		components: components,
 	}
+	if o.adminAddr != "" {
+		a.admin = a.newAdmin()
+	}
+	return a
+}
+
+// newAdmin creates the admin server. With WithLogger, the global log level
+// is adjustable through it, otherwise the global logger is left untouched
+// and /loglevel is not found.
+func (a *Gaia) newAdmin() *admin.Server {
+	opts := []admin.Option{
+		admin.Address(a.opts.adminAddr),
+		admin.Instance(a.serviceInstance),
+		admin.Readiness(a.ready.Load),
+		admin.Framework(Name, Version),
+	}
+	if a.opts.logger != nil {
+		level := admin.NewLogger(a.opts.logger, log.LevelDebug)
+		log.SetLogger(level)
+		opts = append(opts, admin.WithLogger(level))
+	}
+	if a.opts.health != nil {
+		opts = append(opts, admin.Health(a.opts.health))
+	}
+	return admin.NewServer(opts...)
+}
+
+// servers returns the application servers followed by the admin server,
+// which is never part of the registered endpoints.
+func (a *Gaia) servers() []transport.Server {
+	if a.admin == nil {
+		return a.opts.servers
+	}
+	return append(append([]transport.Server{}, a.opts.servers...), a.admin)
+}
+
+func (a *Gaia) serviceInstance() *registry.ServiceInstance {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.instance
 }
 
 // ID returns app instance id.
@@ -57,6 +137,9 @@ func (a *Gaia) Version() string { return a.opts.version }
 // Metadata returns service metadata.
 func (a *Gaia) Metadata() map[string]string { return a.opts.metadata }
 
+// Config returns the application config, nil if none was given.
+func (a *Gaia) Config() config.Config { return a.opts.config }
+
 // Endpoint returns endpoints.
 func (a *Gaia) Endpoint() []string {
 	if a.instance != nil {
@@ -65,7 +148,21 @@ func (a *Gaia) Endpoint() []string {
 	return nil
 }
 
-// Run executes all OnStart hooks registered with the application's Lifecycle.
+// Run starts the application and blocks until it stops.
+//
+// The lifecycle runs in the order beforeStart, start, register, afterStart
+// and, once a signal arrives, Stop is called or a server fails, beforeStop,
+// deregister, drain, stop and afterStop. Every error is returned joined
+// together. A second signal during shutdown exits the process immediately.
+//
+// Components start in dependency order before the servers, and stop in the
+// reverse order after them. When a component fails to start, the components
+// already started are stopped and the servers never start.
+//
+// With graceful restart enabled, a restart signal starts the executable
+// again with the listeners of every server and stops this process once the
+// new one has started, without deregistering the instance the new process
+// registered.
 func (a *Gaia) Run() error {
 	// build service instance
 	instance, err := a.buildInstance()
@@ -75,87 +172,224 @@ func (a *Gaia) Run() error {
 	a.mu.Lock()
 	a.instance = instance
 	a.mu.Unlock()
-	eg, ctx := errgroup.WithContext(NewContext(a.ctx, a))
-	wg := sync.WaitGroup{}
 
-	for _, fn := range a.opts.beforeStart {
-		if err = fn(ctx); err != nil {
-			return err
+	// watch signal, a signal during startup stops the app once it started
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, a.opts.sigs...)
+	defer signal.Stop(quit)
+	var restart chan os.Signal
+	if len(a.opts.restartSigs) > 0 {
+		restart = make(chan os.Signal, 1)
+		signal.Notify(restart, a.opts.restartSigs...)
+		defer signal.Stop(restart)
+	}
+
+	var errs []error
+	if errs = a.runHooks(NewContext(a.ctx, a), phaseBeforeStart, a.opts.beforeStart, true); len(errs) > 0 {
+		errs = append(errs, a.runHooks(NewContext(a.opts.ctx, a), phaseAfterStop, a.opts.afterStop, false)...)
+		return errors.Join(errs...)
+	}
+
+	eg, ctx := errgroup.WithContext(NewContext(a.ctx, a))
+	started, err := a.startComponents(ctx, eg)
+	serving := false
+	if err == nil {
+		serving = true
+		for _, srv := range a.servers() {
+			srv := srv
+			eg.Go(func() error {
+				return srv.Start(ctx)
+			})
 		}
+		err = a.waitReady(ctx)
 	}
 
-	for _, srv := range a.opts.servers {
-		srv := srv
-		eg.Go(func() error {
-			<-ctx.Done() // wait for stop signal
-			stopCtx, cancel := context.WithTimeout(NewContext(a.opts.ctx, a), a.opts.stopTimeout)
-			defer cancel()
-			return srv.Stop(stopCtx)
-		})
-		wg.Add(1)
-		eg.Go(func() error {
-			wg.Done()
-			return srv.Start(ctx)
-		})
+	if err != nil {
+		// a canceled context means Stop was called or a server failed,
+		// the latter is reported by eg.Wait below.
+		if !errors.Is(err, context.Canceled) {
+			errs = append(errs, phaseError(phaseStart, err))
+		}
+	} else if errs = a.register(ctx, instance); len(errs) == 0 {
+		a.regMu.Lock()
+		a.registered = a.opts.registry != nil
+		a.regMu.Unlock()
+		a.watchHealth()
+		errs = a.runHooks(ctx, phaseAfterStart, a.opts.afterStart, true)
 	}
-	wg.Wait()
 
-	// register service
-	if a.opts.registry != nil {
-		c, cancel := context.WithTimeout(ctx, a.opts.registryTimeout)
-		defer cancel()
-		if err := a.opts.registry.Register(c, instance); err != nil {
-			return err
+	handover := false
+	if len(errs) == 0 {
+		a.ready.Store(true)
+		if err := inherit.Ready(); err != nil {
+			log.Errorf("[gaia] failed to notify the parent process: %v", err)
 		}
+		handover = a.wait(ctx, quit, restart)
 	}
+	// any further signal skips the graceful shutdown
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case sig := <-quit:
+			log.Warnf("[gaia] received %v while stopping, exiting immediately", sig)
+			exit(1)
+		case <-done:
+		}
+	}()
 
-	for _, fn := range a.opts.afterStart {
-		if err = fn(ctx); err != nil {
-			return err
+	a.ready.Store(false)
+	stopCtx := NewContext(a.opts.ctx, a)
+	if serving {
+		errs = append(errs, a.runHooks(stopCtx, phaseBeforeStop, a.opts.beforeStop, false)...)
+	}
+	// after a handover the new process registered itself, possibly with
+	// the same instance ID, so the registration is left to it
+	if a.unwatchHealth() && !handover {
+		errs = append(errs, a.deregister(stopCtx, a.serviceInstance())...)
+	}
+	a.drain(stopCtx)
+	if serving {
+		errs = append(errs, a.stopServers(stopCtx)...)
+	}
+	errs = append(errs, a.stopComponents(stopCtx, started)...)
+	// cancel app
+	a.cancel()
+	if err := eg.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		errs = append(errs, phaseError(phaseStart, err))
+	}
+	errs = append(errs, a.runHooks(stopCtx, phaseAfterStop, a.opts.afterStop, false)...)
+	return errors.Join(errs...)
+}
+
+// wait blocks until the application is stopped, a stop signal arrives or a
+// new process took over after a restart signal, in which case it returns
+// true.
+func (a *Gaia) wait(ctx context.Context, quit, restart <-chan os.Signal) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-quit:
+			return false
+		case sig := <-restart:
+			log.Infof("[gaia] received %v, starting a new process", sig)
+			p, err := startProcess(a.opts.restartTimeout)
+			if err != nil {
+				log.Errorf("[gaia] graceful restart failed: %v", err)
+				continue
+			}
+			log.Infof("[gaia] new process %d is ready, stopping", p.Pid)
+			return true
 		}
 	}
+}
 
-	// watch signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, a.opts.sigs...)
-	eg.Go(func() error {
+// Stop asks the application to stop. The shutdown phases run inside Run,
+// which reports their errors.
+func (a *Gaia) Stop() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	return nil
+}
+
+// waitReady blocks until every server implementing transport.Readier is
+// serving, the context is done or the ready timeout elapses.
+func (a *Gaia) waitReady(ctx context.Context) error {
+	var timeout <-chan time.Time
+	if a.opts.readyTimeout > 0 {
+		timer := time.NewTimer(a.opts.readyTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for _, srv := range a.servers() {
+		r, ok := srv.(transport.Readier)
+		if !ok {
+			continue
+		}
 		select {
+		case <-r.Ready():
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-quit:
-			return a.Stop()
+		case <-timeout:
+			return ErrReadyTimeout
 		}
-	})
-	if err := eg.Wait(); err != nil && !errors.Is(err, context.Canceled) {
-		return err
 	}
 	return nil
 }
 
-// Stop gracefully stops the application.
-func (a *Gaia) Stop() (err error) {
-	ctx := NewContext(a.ctx, a)
-	for _, fn := range a.opts.beforeStop {
-		err = fn(ctx)
+func (a *Gaia) register(ctx context.Context, instance *registry.ServiceInstance) []error {
+	if a.opts.registry == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.opts.registryTimeout)
+	defer cancel()
+	if err := a.opts.registry.Register(ctx, instance); err != nil {
+		return []error{phaseError(phaseRegister, err)}
+	}
+	return nil
+}
+
+func (a *Gaia) deregister(ctx context.Context, instance *registry.ServiceInstance) []error {
+	ctx, cancel := context.WithTimeout(ctx, a.opts.registryTimeout)
+	defer cancel()
+	if err := a.opts.registry.Deregister(ctx, instance); err != nil {
+		return []error{phaseError(phaseDeregister, err)}
 	}
+	return nil
+}
 
-	// deregister instance
-	a.mu.Lock()
-	instance := a.instance
-	a.mu.Unlock()
-	if a.opts.registry != nil && instance != nil {
-		ctx, cancel := context.WithTimeout(NewContext(a.ctx, a), a.opts.registryTimeout)
-		defer cancel()
-		if err := a.opts.registry.Deregister(ctx, instance); err != nil {
-			return err
+// drain tells every transport.Drainer server to stop advertising itself as
+// ready, then waits for the drain delay so that peers notice the instance
+// is gone and in-flight requests finish.
+func (a *Gaia) drain(ctx context.Context) {
+	for _, srv := range a.servers() {
+		if d, ok := srv.(transport.Drainer); ok {
+			d.Drain()
 		}
 	}
+	for _, level := range a.components {
+		for _, c := range level {
+			if d, ok := c.c.(transport.Drainer); ok {
+				d.Drain()
+			}
+		}
+	}
+	if a.opts.drainDelay <= 0 {
+		return
+	}
+	timer := time.NewTimer(a.opts.drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
 
-	// cancel app
-	if a.cancel != nil {
-		a.cancel()
+// stopServers stops every server concurrently, each within the stop timeout.
+func (a *Gaia) stopServers(ctx context.Context) []error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	for _, srv := range a.servers() {
+		srv := srv
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, a.opts.stopTimeout)
+			defer cancel()
+			if err := srv.Stop(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, phaseError(phaseStop, err))
+				mu.Unlock()
+			}
+		}()
 	}
-	return err
+	wg.Wait()
+	return errs
 }
 
 func (a *Gaia) buildInstance() (*registry.ServiceInstance, error) {
@@ -164,7 +398,15 @@ func (a *Gaia) buildInstance() (*registry.ServiceInstance, error) {
 		endpoints = append(endpoints, e.String())
 	}
 	if len(endpoints) == 0 {
+		// components may be servers too, e.g. a gRPC server others call
+		servers := make([]Component, 0, len(a.opts.components)+len(a.opts.servers))
+		for _, c := range a.opts.components {
+			servers = append(servers, c.c)
+		}
 		for _, srv := range a.opts.servers {
+			servers = append(servers, srv)
+		}
+		for _, srv := range servers {
 			if r, ok := srv.(transport.Endpointer); ok {
 				e, err := r.Endpoint()
 				if err != nil {
@@ -3,12 +3,17 @@ package gaia
 import (
 	"context"
 	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/apus-run/sea-kit/log"
+
 	"github.com/apus-run/gaia/registry"
 	"github.com/apus-run/gaia/transport/grpc"
 	"github.com/apus-run/gaia/transport/http"
@@ -267,3 +272,38 @@ func TestApp_Context(t *testing.T) {
 		})
 	}
 }
+
+type countLogger struct {
+	n atomic.Int32
+}
+
+func (l *countLogger) Log(log.Level, ...interface{}) error {
+	l.n.Add(1)
+	return nil
+}
+
+func TestApp_AdminLogger(t *testing.T) {
+	defer log.SetLogger(log.DefaultLogger)
+
+	logger := &countLogger{}
+	log.SetLogger(logger)
+	app := New(WithAdmin(":0"))
+	if log.Info("gaia"); logger.n.Load() != 1 {
+		t.Error("expected the global logger to be left untouched without WithLogger")
+	}
+	res := httptest.NewRecorder()
+	app.admin.Handler.ServeHTTP(res, httptest.NewRequest(nethttp.MethodGet, "/loglevel", nil))
+	if res.Code != nethttp.StatusNotFound {
+		t.Errorf("expected status %d got %d", nethttp.StatusNotFound, res.Code)
+	}
+
+	app = New(WithAdmin(":0"), WithLogger(logger))
+	res = httptest.NewRecorder()
+	app.admin.Handler.ServeHTTP(res, httptest.NewRequest(nethttp.MethodPut, "/loglevel?level=error", nil))
+	if res.Code != nethttp.StatusOK {
+		t.Errorf("expected status %d got %d", nethttp.StatusOK, res.Code)
+	}
+	if log.Info("gaia"); logger.n.Load() != 1 {
+		t.Errorf("expected info logs to be dropped, got %d records", logger.n.Load())
+	}
+}
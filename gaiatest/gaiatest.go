@@ -0,0 +1,287 @@
+// Package gaiatest boots a gaia application in-process for tests, with
+// in-memory listeners and an in-memory registry.
+//
+//	app := gaiatest.New(t)
+//	gs := grpc.NewServer(grpc.Listener(app.GRPCListener()))
+//	pb.RegisterGreeterServer(gs, &greeter{})
+//	hs := http.NewServer(http.Listener(app.HTTPListener()), http.ReadinessPath("/readyz"))
+//	app.Start(gaia.WithName("greeter"), gaia.WithServer(gs, hs))
+//
+//	client := pb.NewGreeterClient(app.ClientConn())
+//	res, err := app.HTTPClient().Get(app.HTTPURL() + "/readyz")
+//
+// The application is stopped through t.Cleanup, which also fails the test
+// when goroutines started during the test are still running afterwards.
+package gaiatest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/apus-run/gaia"
+)
+
+// Option is a harness option.
+type Option func(*App)
+
+// StartTimeout with the time to wait for the application to start, ten
+// seconds by default.
+func StartTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.startTimeout = d
+	}
+}
+
+// StopTimeout with the time to wait for the application to stop, ten
+// seconds by default.
+func StopTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.stopTimeout = d
+	}
+}
+
+// IgnoreGoroutines ignores leaked goroutines whose stack contains any of
+// the substrings, e.g. a function name.
+func IgnoreGoroutines(s ...string) Option {
+	return func(a *App) {
+		a.ignore = append(a.ignore, s...)
+	}
+}
+
+// NoLeakCheck disables the goroutine leak check, e.g. for parallel tests.
+func NoLeakCheck() Option {
+	return func(a *App) {
+		a.leakCheck = false
+	}
+}
+
+// App is a gaia application under test.
+type App struct {
+	t        testing.TB
+	Registry *Registry
+
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+	leakCheck    bool
+	ignore       []string
+	base         map[string]string
+
+	mu        sync.Mutex
+	port      int
+	listeners map[int]*Listener
+	grpcLis   []*Listener
+	httpLis   []*Listener
+	conns     []*grpc.ClientConn
+	clients   []*http.Client
+
+	app *gaia.Gaia
+}
+
+// New creates a harness, the application is started by Start.
+func New(t testing.TB, opts ...Option) *App {
+	t.Helper()
+	a := &App{
+		t:            t,
+		Registry:     NewRegistry(),
+		startTimeout: 10 * time.Second,
+		stopTimeout:  10 * time.Second,
+		leakCheck:    true,
+		ignore:       append([]string(nil), ignoredGoroutines...),
+		base:         goroutines(),
+		port:         10000,
+		listeners:    make(map[int]*Listener),
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	t.Cleanup(a.cleanup)
+	return a
+}
+
+// addr returns a fake address with a port unique within the harness.
+func (a *App) addr() *net.TCPAddr {
+	a.port++
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: a.port}
+}
+
+// GRPCListener returns a new bufconn listener for a gRPC server.
+func (a *App) GRPCListener() net.Listener {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	lis := newBufconn(a.addr())
+	a.listeners[lis.addr.Port] = lis
+	a.grpcLis = append(a.grpcLis, lis)
+	return lis
+}
+
+// HTTPListener returns a new in-memory pipe listener for an HTTP server.
+func (a *App) HTTPListener() net.Listener {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	lis := newPipe(a.addr())
+	a.listeners[lis.addr.Port] = lis
+	a.httpLis = append(a.httpLis, lis)
+	return lis
+}
+
+// Dial connects to the in-memory listener of address. Only the port is
+// considered, servers may advertise any host for it.
+func (a *App) Dial(ctx context.Context, address string) (net.Conn, error) {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	var lis *Listener
+	for p, l := range a.listeners {
+		if fmt.Sprint(p) == port {
+			lis = l
+			break
+		}
+	}
+	a.mu.Unlock()
+	if lis == nil {
+		return nil, fmt.Errorf("gaiatest: no listener on %s", address)
+	}
+	return lis.Dial(ctx)
+}
+
+// Start starts the application with opts and the in-memory registry, and
+// waits until it is ready. The test fails when the application does not
+// start.
+func (a *App) Start(opts ...gaia.Option) *gaia.Gaia {
+	a.t.Helper()
+	started := make(chan struct{})
+	opts = append([]gaia.Option{gaia.WithRegistry(a.Registry)}, opts...)
+	opts = append(opts, gaia.AfterStart(func(context.Context) error {
+		close(started)
+		return nil
+	}))
+	app := gaia.New(opts...)
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+
+	a.mu.Lock()
+	a.app = app
+	a.mu.Unlock()
+	// cleanup waits for Run through done
+	a.t.Cleanup(func() { a.stop(app, done) })
+
+	select {
+	case <-started:
+	case err := <-done:
+		done <- err
+		a.t.Fatalf("gaiatest: application failed to start: %v", err)
+	case <-time.After(a.startTimeout):
+		a.t.Fatalf("gaiatest: application did not start within %v", a.startTimeout)
+	}
+	return app
+}
+
+// ClientConn returns a client connection to the first gRPC listener, closed
+// on cleanup.
+func (a *App) ClientConn(opts ...grpc.DialOption) *grpc.ClientConn {
+	a.t.Helper()
+	a.mu.Lock()
+	if len(a.grpcLis) == 0 {
+		a.mu.Unlock()
+		a.t.Fatal("gaiatest: no gRPC listener")
+	}
+	target := a.grpcLis[0].addr.String()
+	a.mu.Unlock()
+
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(a.Dial),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	conn, err := grpc.Dial("passthrough:///"+target, opts...)
+	if err != nil {
+		a.t.Fatalf("gaiatest: failed to dial %s: %v", target, err)
+	}
+	a.mu.Lock()
+	a.conns = append(a.conns, conn)
+	a.mu.Unlock()
+	return conn
+}
+
+// HTTPClient returns an HTTP client whose connections go to the in-memory
+// listeners, its idle connections are closed on cleanup.
+func (a *App) HTTPClient() *http.Client {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, address string) (net.Conn, error) {
+				return a.Dial(ctx, address)
+			},
+		},
+	}
+	a.mu.Lock()
+	a.clients = append(a.clients, client)
+	a.mu.Unlock()
+	return client
+}
+
+// HTTPURL returns the base URL of the first HTTP listener.
+func (a *App) HTTPURL() string {
+	a.t.Helper()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.httpLis) == 0 {
+		a.t.Fatal("gaiatest: no HTTP listener")
+	}
+	return "http://" + a.httpLis[0].addr.String()
+}
+
+// stop stops the application and reports its error.
+func (a *App) stop(app *gaia.Gaia, done chan error) {
+	a.closeClients()
+	_ = app.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			a.t.Errorf("gaiatest: application stopped with error: %v", err)
+		}
+	case <-time.After(a.stopTimeout):
+		a.t.Errorf("gaiatest: application did not stop within %v", a.stopTimeout)
+	}
+}
+
+func (a *App) closeClients() {
+	a.mu.Lock()
+	conns, clients := a.conns, a.clients
+	a.conns, a.clients = nil, nil
+	a.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	for _, c := range clients {
+		c.CloseIdleConnections()
+	}
+}
+
+// cleanup runs last, after the application stopped, and reports leaked
+// goroutines.
+func (a *App) cleanup() {
+	a.closeClients()
+	a.mu.Lock()
+	for _, lis := range a.listeners {
+		_ = lis.Close()
+	}
+	a.mu.Unlock()
+	if !a.leakCheck {
+		return
+	}
+	if leaks := leaked(a.base, a.ignore, 2*time.Second); len(leaks) > 0 {
+		a.t.Errorf("gaiatest: %d goroutines leaked:\n\n%s", len(leaks), strings.Join(leaks, "\n\n"))
+	}
+}
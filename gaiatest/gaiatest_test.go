@@ -0,0 +1,53 @@
+package gaiatest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/apus-run/gaia"
+	pb "github.com/apus-run/gaia/internal/testdata/helloworld"
+	"github.com/apus-run/gaia/transport/grpc"
+	transhttp "github.com/apus-run/gaia/transport/http"
+)
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+func TestApp(t *testing.T) {
+	app := New(t)
+	gs := grpc.NewServer(grpc.Listener(app.GRPCListener()))
+	pb.RegisterGreeterServer(gs, greeter{})
+	hs := transhttp.NewServer(transhttp.Listener(app.HTTPListener()), transhttp.ReadinessPath("/readyz"))
+	app.Start(gaia.WithName("greeter"), gaia.WithServer(gs, hs))
+
+	reply, err := pb.NewGreeterClient(app.ClientConn()).SayHello(context.Background(), &pb.HelloRequest{Name: "gaia"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Message != "Hello gaia" {
+		t.Errorf("expected %q got %q", "Hello gaia", reply.Message)
+	}
+
+	res, err := app.HTTPClient().Get(app.HTTPURL() + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d got %d", http.StatusOK, res.StatusCode)
+	}
+
+	ins, err := app.Registry.GetService(context.Background(), "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ins) != 1 || len(ins[0].Endpoints) != 2 {
+		t.Fatalf("expected one instance with two endpoints, got %+v", ins)
+	}
+}
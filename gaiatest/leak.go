@@ -0,0 +1,67 @@
+package gaiatest
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ignoredGoroutines are goroutines that outlive any application.
+var ignoredGoroutines = []string{
+	"testing.tRunner",
+	"testing.(*T).Run",
+	"testing.(*M).",
+	"testing.runTests",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+	"created by runtime.gc",
+	"runtime.ReadTrace",
+}
+
+// goroutines returns the stack of every goroutine by id line, e.g.
+// "goroutine 7 [running]:".
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := make(map[string]string)
+	for _, g := range bytes.Split(buf, []byte("\n\n")) {
+		header, _, _ := strings.Cut(string(g), "\n")
+		id, _, _ := strings.Cut(header, " [")
+		stacks[id] = string(g)
+	}
+	return stacks
+}
+
+// leaked waits up to timeout for the goroutines started since base to exit
+// and returns the stacks of those that did not.
+func leaked(base map[string]string, ignore []string, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		var leaks []string
+	next:
+		for id, stack := range goroutines() {
+			if _, ok := base[id]; ok {
+				continue
+			}
+			for _, s := range ignore {
+				if strings.Contains(stack, s) {
+					continue next
+				}
+			}
+			leaks = append(leaks, stack)
+		}
+		if len(leaks) == 0 || time.Now().After(deadline) {
+			return leaks
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,21 @@
+package gaiatest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaked(t *testing.T) {
+	base := goroutines()
+	stop := make(chan struct{})
+	go func() {
+		<-stop
+	}()
+	if leaks := leaked(base, ignoredGoroutines, 20*time.Millisecond); len(leaks) != 1 {
+		t.Fatalf("expected one leaked goroutine, got %d", len(leaks))
+	}
+	close(stop)
+	if leaks := leaked(base, ignoredGoroutines, time.Second); len(leaks) != 0 {
+		t.Fatalf("expected no leaked goroutines, got %v", leaks)
+	}
+}
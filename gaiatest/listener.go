@@ -0,0 +1,88 @@
+package gaiatest
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1 << 20
+
+// Listener is an in-memory listener. It reports a TCP address so that
+// servers can build their registry endpoint from it, but connections only
+// come from Dial.
+type Listener struct {
+	net.Listener
+	addr *net.TCPAddr
+	dial func(ctx context.Context) (net.Conn, error)
+}
+
+// Addr returns the fake TCP address of the listener.
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
+
+// Dial connects to the listener.
+func (l *Listener) Dial(ctx context.Context) (net.Conn, error) {
+	return l.dial(ctx)
+}
+
+// newBufconn returns a buffered in-memory listener, as used for gRPC.
+func newBufconn(addr *net.TCPAddr) *Listener {
+	lis := bufconn.Listen(bufSize)
+	return &Listener{Listener: lis, addr: addr, dial: lis.DialContext}
+}
+
+// newPipe returns an in-memory listener whose connections are synchronous
+// pipes, as used for HTTP.
+func newPipe(addr *net.TCPAddr) *Listener {
+	lis := &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+		addr:  addr,
+	}
+	return &Listener{Listener: lis, addr: addr, dial: lis.dial}
+}
+
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+	addr  net.Addr
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return l.addr
+}
+
+func (l *pipeListener) dial(ctx context.Context) (net.Conn, error) {
+	server, client := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		_ = server.Close()
+		_ = client.Close()
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		_ = server.Close()
+		_ = client.Close()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,134 @@
+package gaiatest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/apus-run/gaia/registry"
+)
+
+var (
+	_ registry.Registry  = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+// Registry is an in-memory registry and discovery.
+type Registry struct {
+	mu       sync.Mutex
+	services map[string][]*registry.ServiceInstance
+	watchers map[string][]*watcher
+}
+
+// NewRegistry creates an in-memory registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		services: make(map[string][]*registry.ServiceInstance),
+		watchers: make(map[string][]*watcher),
+	}
+}
+
+// Register registers the instance, replacing the instance with the same ID.
+func (r *Registry) Register(ctx context.Context, svc *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services[svc.Name] = append(remove(r.services[svc.Name], svc.ID), svc)
+	r.notify(svc.Name)
+	return nil
+}
+
+// Deregister deregisters the instance.
+func (r *Registry) Deregister(ctx context.Context, svc *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services[svc.Name] = remove(r.services[svc.Name], svc.ID)
+	r.notify(svc.Name)
+	return nil
+}
+
+// GetService returns the instances of a service.
+func (r *Registry) GetService(ctx context.Context, serviceName string) ([]*registry.ServiceInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*registry.ServiceInstance(nil), r.services[serviceName]...), nil
+}
+
+// GetServiceList returns the instances of every service.
+func (r *Registry) GetServiceList(ctx context.Context) ([]*registry.ServiceInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []*registry.ServiceInstance
+	for _, ins := range r.services {
+		list = append(list, ins...)
+	}
+	return list, nil
+}
+
+// Watch watches the instances of a service.
+func (r *Registry) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watcher{
+		r:       r,
+		name:    serviceName,
+		ctx:     ctx,
+		cancel:  cancel,
+		changed: make(chan struct{}, 1),
+	}
+	if len(r.services[serviceName]) > 0 {
+		w.changed <- struct{}{}
+	}
+	r.watchers[serviceName] = append(r.watchers[serviceName], w)
+	return w, nil
+}
+
+// notify wakes up the watchers of a service, r.mu must be held.
+func (r *Registry) notify(name string) {
+	for _, w := range r.watchers[name] {
+		select {
+		case w.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func remove(ins []*registry.ServiceInstance, id string) []*registry.ServiceInstance {
+	out := make([]*registry.ServiceInstance, 0, len(ins))
+	for _, in := range ins {
+		if in.ID != id {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+type watcher struct {
+	r       *Registry
+	name    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	changed chan struct{}
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.changed:
+		return w.r.GetService(w.ctx, w.name)
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	w.r.mu.Lock()
+	defer w.r.mu.Unlock()
+	ws := w.r.watchers[w.name]
+	for i, x := range ws {
+		if x == w {
+			w.r.watchers[w.name] = append(ws[:i], ws[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
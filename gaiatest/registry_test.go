@@ -0,0 +1,45 @@
+package gaiatest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/apus-run/gaia/registry"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	a := &registry.ServiceInstance{ID: "1", Name: "svc"}
+	if err := r.Register(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Watch(ctx, "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ins, err := w.Next()
+	if err != nil || len(ins) != 1 {
+		t.Fatalf("expected the registered instance, got %v %v", ins, err)
+	}
+
+	// registering the same ID replaces the instance
+	if err = r.Register(ctx, &registry.ServiceInstance{ID: "1", Name: "svc", Version: "v2"}); err != nil {
+		t.Fatal(err)
+	}
+	ins, _ = w.Next()
+	if len(ins) != 1 || ins[0].Version != "v2" {
+		t.Errorf("expected the replaced instance, got %+v", ins)
+	}
+
+	_ = r.Deregister(ctx, a)
+	if ins, _ = w.Next(); len(ins) != 0 {
+		t.Errorf("expected no instances, got %+v", ins)
+	}
+
+	_ = w.Stop()
+	if _, err = w.Next(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v got %v", context.Canceled, err)
+	}
+}
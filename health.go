@@ -0,0 +1,82 @@
+package gaia
+
+import (
+	"errors"
+
+	"github.com/apus-run/sea-kit/log"
+
+	"github.com/apus-run/gaia/health"
+)
+
+// watchHealth starts keeping the registration of the instance in line with
+// the readiness of the health aggregator, once the instance is registered.
+func (a *Gaia) watchHealth() {
+	h := a.opts.health
+	if h == nil || a.opts.registry == nil || (!a.opts.healthDeregister && a.opts.healthMetadata == "") {
+		return
+	}
+	a.regMu.Lock()
+	a.syncing = true
+	a.regMu.Unlock()
+	h.OnChange(a.syncHealth)
+	a.syncHealth()
+}
+
+// unwatchHealth stops following the health aggregator and reports whether
+// the instance is registered.
+func (a *Gaia) unwatchHealth() bool {
+	a.regMu.Lock()
+	defer a.regMu.Unlock()
+	a.syncing = false
+	return a.registered
+}
+
+// syncHealth updates the health metadata of the instance and registers or
+// deregisters it according to the readiness of the health aggregator.
+func (a *Gaia) syncHealth() {
+	a.regMu.Lock()
+	defer a.regMu.Unlock()
+	if !a.syncing {
+		return
+	}
+	ctx := NewContext(a.opts.ctx, a)
+	status := a.opts.health.Readiness().Status
+	instance := a.serviceInstance()
+
+	changed := false
+	if key := a.opts.healthMetadata; key != "" && instance.Metadata[key] != status.String() {
+		md := make(map[string]string, len(instance.Metadata)+1)
+		for k, v := range instance.Metadata {
+			md[k] = v
+		}
+		md[key] = status.String()
+		ins := *instance
+		ins.Metadata = md
+		instance = &ins
+		a.mu.Lock()
+		a.instance = instance
+		a.mu.Unlock()
+		changed = true
+	}
+
+	switch {
+	case a.opts.healthDeregister && status == health.StatusUp && !a.registered:
+		if errs := a.register(ctx, instance); len(errs) > 0 {
+			log.Errorf("[gaia] failed to register the healthy instance: %v", errors.Join(errs...))
+			return
+		}
+		log.Info("[gaia] instance is healthy, registered")
+		a.registered = true
+	case a.opts.healthDeregister && status != health.StatusUp && a.registered:
+		if errs := a.deregister(ctx, instance); len(errs) > 0 {
+			log.Errorf("[gaia] failed to deregister the unhealthy instance: %v", errors.Join(errs...))
+			return
+		}
+		log.Warn("[gaia] instance is unhealthy, deregistered")
+		a.registered = false
+	case changed && a.registered:
+		if errs := a.register(ctx, instance); len(errs) > 0 {
+			log.Errorf("[gaia] failed to update the instance health: %v", errors.Join(errs...))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/apus-run/sea-kit/log"
+)
+
+// LivenessHandler serves the liveness report, 503 when the application is
+// not alive.
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		WriteReport(w, h.Liveness())
+	})
+}
+
+// ReadinessHandler serves the readiness report, 503 when the application is
+// not ready.
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		WriteReport(w, h.Readiness())
+	})
+}
+
+// WriteReport writes r as JSON, with status 503 unless it is up.
+func WriteReport(w http.ResponseWriter, r Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Status != StatusUp {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		log.Errorf("[health] failed to encode report: %v", err)
+	}
+}
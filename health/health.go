@@ -0,0 +1,284 @@
+// Package health aggregates liveness and readiness checks of the parts of an
+// application, e.g. a database, a cache or a downstream client.
+//
+// Every check runs periodically within its timeout. The aggregated state
+// drives the gRPC health server, the HTTP and admin probes and, optionally,
+// the registration of the instance:
+//
+//   - the application is alive unless a liveness check fails.
+//   - the application is ready once every check passed at least once and
+//     none of them fails.
+//   - a gRPC service is ready like the application, but only considering the
+//     checks that apply to every service or name that service.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/apus-run/sea-kit/log"
+)
+
+// Status is the health status of a check or of the application.
+type Status int
+
+const (
+	// StatusUnknown is the status of a check that did not run yet.
+	StatusUnknown Status = iota
+	StatusUp
+	StatusDown
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusUp:
+		return "up"
+	case StatusDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText marshals the status as its name.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Checker checks the health of a part of the application.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to use a func as a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type kind int
+
+const (
+	liveness kind = iota
+	readiness
+)
+
+type check struct {
+	name     string
+	kind     kind
+	checker  Checker
+	interval time.Duration
+	timeout  time.Duration
+	services []string
+
+	// guarded by Health.mu
+	status Status
+	err    error
+}
+
+// Health is the health aggregator. It implements the lifecycle of a gaia
+// component, checks run between Start and Stop.
+type Health struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	checks    []*check
+	observers []func()
+
+	ready     chan struct{}
+	readyOnce sync.Once
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// New creates a health aggregator by options.
+func New(opts ...Option) *Health {
+	h := &Health{
+		interval: 10 * time.Second,
+		timeout:  time.Second,
+		ready:    make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// AddLiveness adds a liveness check, the application is restarted by the
+// orchestrator when it fails. Checks must be added before Start.
+func (h *Health) AddLiveness(name string, c Checker, opts ...CheckOption) {
+	h.add(name, liveness, c, opts)
+}
+
+// AddReadiness adds a readiness check, the application does not receive
+// traffic while it fails. Checks must be added before Start.
+func (h *Health) AddReadiness(name string, c Checker, opts ...CheckOption) {
+	h.add(name, readiness, c, opts)
+}
+
+func (h *Health) add(name string, k kind, c Checker, opts []CheckOption) {
+	ck := &check{
+		name:     name,
+		kind:     k,
+		checker:  c,
+		interval: h.interval,
+		timeout:  h.timeout,
+	}
+	for _, o := range opts {
+		o(ck)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, ck)
+}
+
+// OnChange calls fn whenever the status of a check changes.
+func (h *Health) OnChange(fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observers = append(h.observers, fn)
+}
+
+// Start runs every check periodically until Stop is called or ctx is done.
+func (h *Health) Start(ctx context.Context) error {
+	h.mu.RLock()
+	checks := append([]*check(nil), h.checks...)
+	h.mu.RUnlock()
+
+	var (
+		first sync.WaitGroup
+		wg    sync.WaitGroup
+	)
+	first.Add(len(checks))
+	wg.Add(len(checks))
+	for _, ck := range checks {
+		ck := ck
+		go func() {
+			defer wg.Done()
+			h.run(ctx, ck)
+			first.Done()
+			ticker := time.NewTicker(ck.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-h.stop:
+					return
+				case <-ticker.C:
+					h.run(ctx, ck)
+				}
+			}
+		}()
+	}
+	first.Wait()
+	h.readyOnce.Do(func() { close(h.ready) })
+	wg.Wait()
+	return nil
+}
+
+// Ready returns a channel that is closed once every check ran once.
+func (h *Health) Ready() <-chan struct{} {
+	return h.ready
+}
+
+// Stop stops running the checks.
+func (h *Health) Stop(ctx context.Context) error {
+	h.stopOnce.Do(func() { close(h.stop) })
+	return nil
+}
+
+func (h *Health) run(ctx context.Context, ck *check) {
+	ctx, cancel := context.WithTimeout(ctx, ck.timeout)
+	defer cancel()
+	err := ck.checker.Check(ctx)
+	if err == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = ctx.Err()
+	}
+	status := StatusUp
+	if err != nil {
+		status = StatusDown
+	}
+
+	h.mu.Lock()
+	changed := ck.status != status
+	if changed && status == StatusDown {
+		log.Warnf("[health] check %s is down: %v", ck.name, err)
+	} else if changed && ck.status == StatusDown {
+		log.Infof("[health] check %s is up", ck.name)
+	}
+	ck.status, ck.err = status, err
+	observers := h.observers
+	h.mu.Unlock()
+
+	if changed {
+		for _, fn := range observers {
+			fn()
+		}
+	}
+}
+
+// CheckReport is the state of a single check.
+type CheckReport struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the aggregated state of a set of checks.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckReport `json:"checks,omitempty"`
+}
+
+// Liveness reports whether the application is alive.
+func (h *Health) Liveness() Report {
+	return h.report(func(ck *check) bool { return ck.kind == liveness }, false)
+}
+
+// Readiness reports whether the application is ready.
+func (h *Health) Readiness() Report {
+	return h.report(func(*check) bool { return true }, true)
+}
+
+// Service reports whether the gRPC service is ready.
+func (h *Health) Service(service string) Report {
+	return h.report(func(ck *check) bool {
+		if len(ck.services) == 0 {
+			return true
+		}
+		for _, s := range ck.services {
+			if s == service {
+				return true
+			}
+		}
+		return false
+	}, true)
+}
+
+// report aggregates the checks matching fn, unknown checks fail the report
+// when strict is set.
+func (h *Health) report(fn func(*check) bool, strict bool) Report {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	r := Report{Status: StatusUp, Checks: make(map[string]CheckReport)}
+	for _, ck := range h.checks {
+		if !fn(ck) {
+			continue
+		}
+		cr := CheckReport{Status: ck.status}
+		if ck.err != nil {
+			cr.Error = ck.err.Error()
+		}
+		r.Checks[ck.name] = cr
+		if ck.status == StatusDown || (strict && ck.status == StatusUnknown) {
+			r.Status = StatusDown
+		}
+	}
+	return r
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flag is a check whose result is switched by the test.
+type flag struct {
+	err atomic.Value
+}
+
+func (f *flag) set(err error) {
+	f.err.Store(&err)
+}
+
+func (f *flag) Check(context.Context) error {
+	if err, ok := f.err.Load().(*error); ok {
+		return *err
+	}
+	return nil
+}
+
+func start(t *testing.T, h *Health) {
+	t.Helper()
+	go func() {
+		_ = h.Start(context.Background())
+	}()
+	t.Cleanup(func() { _ = h.Stop(context.Background()) })
+	select {
+	case <-h.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("checks did not run")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	h := New(Interval(10 * time.Millisecond))
+	db := &flag{}
+	cache := &flag{}
+	h.AddLiveness("deadlock", CheckerFunc(func(context.Context) error { return nil }))
+	h.AddReadiness("db", db)
+	h.AddReadiness("cache", cache, Services("helloworld.Greeter"))
+
+	if r := h.Readiness(); r.Status != StatusDown {
+		t.Errorf("expected not ready before the checks ran, got %v", r.Status)
+	}
+	changed := make(chan struct{}, 10)
+	h.OnChange(func() { changed <- struct{}{} })
+	start(t, h)
+
+	if r := h.Readiness(); r.Status != StatusUp || len(r.Checks) != 3 {
+		t.Errorf("expected ready with 3 checks, got %+v", r)
+	}
+
+	// drop the changes of the first run
+	for len(changed) > 0 {
+		<-changed
+	}
+	cache.set(errors.New("timeout"))
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("expected a change")
+	}
+	if r := h.Readiness(); r.Status != StatusDown || r.Checks["cache"].Error != "timeout" {
+		t.Errorf("expected cache down, got %+v", r)
+	}
+	if r := h.Liveness(); r.Status != StatusUp || len(r.Checks) != 1 {
+		t.Errorf("expected alive, got %+v", r)
+	}
+	if r := h.Service("helloworld.Greeter"); r.Status != StatusDown {
+		t.Errorf("expected service down, got %+v", r)
+	}
+	if r := h.Service("helloworld.Other"); r.Status != StatusUp {
+		t.Errorf("expected service up, got %+v", r)
+	}
+}
+
+func TestHealth_Timeout(t *testing.T) {
+	h := New()
+	h.AddReadiness("slow", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}), CheckTimeout(10*time.Millisecond))
+	start(t, h)
+	if r := h.Readiness(); r.Status != StatusDown || r.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected timeout, got %+v", r)
+	}
+}
+
+func TestHealth_Handler(t *testing.T) {
+	h := New()
+	h.AddLiveness("live", CheckerFunc(func(context.Context) error { return nil }))
+	h.AddReadiness("ready", CheckerFunc(func(context.Context) error { return errors.New("down") }))
+	start(t, h)
+
+	res := httptest.NewRecorder()
+	h.LivenessHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	h.ReadinessHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d got %d", http.StatusServiceUnavailable, res.Code)
+	}
+	var r struct {
+		Status string                       `json:"status"`
+		Checks map[string]map[string]string `json:"checks"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != "down" || r.Checks["ready"]["error"] != "down" {
+		t.Errorf("unexpected report %+v", r)
+	}
+}
@@ -0,0 +1,45 @@
+package health
+
+import "time"
+
+// Option is a health aggregator option.
+type Option func(*Health)
+
+// Interval with the default interval between two runs of a check.
+func Interval(d time.Duration) Option {
+	return func(h *Health) {
+		h.interval = d
+	}
+}
+
+// Timeout with the default timeout of a check.
+func Timeout(d time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = d
+	}
+}
+
+// CheckOption is a check option.
+type CheckOption func(*check)
+
+// CheckInterval with the interval between two runs of the check.
+func CheckInterval(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.interval = d
+	}
+}
+
+// CheckTimeout with the timeout of the check.
+func CheckTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = d
+	}
+}
+
+// Services with the gRPC services the check applies to, it applies to every
+// service by default.
+func Services(services ...string) CheckOption {
+	return func(c *check) {
+		c.services = services
+	}
+}
@@ -0,0 +1,134 @@
+package gaia
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/health"
+	"github.com/apus-run/gaia/registry"
+)
+
+type metadataRegistry struct {
+	recordRegistry
+	metadata atomic.Value
+}
+
+func (r *metadataRegistry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	r.metadata.Store(service.Metadata)
+	return r.recordRegistry.Register(ctx, service)
+}
+
+func waitFor(t *testing.T, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealth_Deregister(t *testing.T) {
+	rec := &recorder{}
+	var down atomic.Bool
+	h := health.New(health.Interval(5 * time.Millisecond))
+	h.AddReadiness("db", health.CheckerFunc(func(context.Context) error {
+		if down.Load() {
+			return errors.New("down")
+		}
+		return nil
+	}))
+	r := &metadataRegistry{recordRegistry: recordRegistry{rec: rec}}
+	app := New(
+		WithServer(newMockServer(&recorder{})),
+		WithRegistry(r),
+		WithHealth(h),
+		WithHealthDeregister(),
+		WithHealthMetadata("health"),
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+
+	// registered, then registered again with the health metadata
+	waitFor(t, func() bool { return len(rec.get()) == 2 })
+	if md, _ := r.metadata.Load().(map[string]string); md["health"] != "up" {
+		t.Errorf("expected health metadata up, got %v", md)
+	}
+	down.Store(true)
+	waitFor(t, func() bool { return len(rec.get()) == 3 })
+	down.Store(false)
+	waitFor(t, func() bool { return len(rec.get()) == 4 })
+	if md, _ := r.metadata.Load().(map[string]string); md["health"] != "up" {
+		t.Errorf("expected health metadata up, got %v", md)
+	}
+
+	_ = app.Stop()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"register", "register", "deregister", "register", "deregister"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+// dbComponent is checked by the health aggregator, it takes a while to
+// start.
+type dbComponent struct {
+	up    atomic.Bool
+	ready chan struct{}
+}
+
+func (c *dbComponent) Start(context.Context) error {
+	time.Sleep(20 * time.Millisecond)
+	c.up.Store(true)
+	close(c.ready)
+	return nil
+}
+
+func (c *dbComponent) Ready() <-chan struct{} {
+	return c.ready
+}
+
+func (c *dbComponent) Stop(context.Context) error {
+	c.up.Store(false)
+	return nil
+}
+
+func TestHealth_ChecksStartedComponents(t *testing.T) {
+	rec := &recorder{}
+	db := &dbComponent{ready: make(chan struct{})}
+	h := health.New(health.Interval(time.Hour))
+	h.AddReadiness("db", health.CheckerFunc(func(context.Context) error {
+		if !db.up.Load() {
+			return errors.New("not started")
+		}
+		return nil
+	}))
+	var app *Gaia
+	app = New(
+		WithServer(newMockServer(&recorder{})),
+		WithRegistry(&recordRegistry{rec: rec}),
+		WithComponent("db", db),
+		WithHealth(h),
+		WithHealthDeregister(),
+		AfterStart(func(ctx context.Context) error {
+			rec.add("afterStart")
+			return app.Stop()
+		}),
+	)
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"register", "afterStart", "deregister"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
@@ -0,0 +1,222 @@
+// Package inherit hands listening sockets over to a child process so that a
+// new binary can take over without dropping connections.
+//
+// Listeners created through Listen are tracked. StartProcess passes them to a
+// copy of the running executable as extra file descriptors, described by an
+// environment variable, and waits for the child to call Ready. In the child,
+// Listen returns the inherited socket instead of binding a new one.
+package inherit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	listenersEnv = "GAIA_INHERITED_LISTENERS"
+	readyEnv     = "GAIA_INHERITED_READY_FD"
+
+	// the first file descriptor passed through exec.Cmd.ExtraFiles
+	firstFd = 3
+)
+
+// ErrReadyTimeout is returned by StartProcess when the child does not become
+// ready in time.
+var ErrReadyTimeout = errors.New("inherit: timed out waiting for child process")
+
+type inherited struct {
+	key string
+	lis net.Listener
+}
+
+var (
+	mu        sync.Mutex
+	once      sync.Once
+	inherits  []*inherited
+	listeners []*inherited
+	readyOnce sync.Once
+)
+
+func key(network, address string) string {
+	return network + "=" + address
+}
+
+// load parses the listeners inherited from the parent process, if any.
+func load() {
+	env := os.Getenv(listenersEnv)
+	if env == "" {
+		return
+	}
+	for i, k := range strings.Split(env, ",") {
+		f := os.NewFile(uintptr(firstFd+i), k)
+		lis, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+		inherits = append(inherits, &inherited{key: k, lis: lis})
+	}
+}
+
+// Listen returns the listener inherited for network and address, or
+// announces a new one on the local network address.
+func Listen(network, address string) (net.Listener, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	once.Do(load)
+
+	k := key(network, address)
+	for i, in := range inherits {
+		if in.key == k {
+			inherits = append(inherits[:i], inherits[i+1:]...)
+			listeners = append(listeners, in)
+			return in.lis, nil
+		}
+	}
+	lis, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	listeners = append(listeners, &inherited{key: k, lis: lis})
+	return lis, nil
+}
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// export duplicates the file descriptor of every open listener.
+func export() (keys []string, files []*os.File, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, in := range listeners {
+		fl, ok := in.lis.(filer)
+		if !ok {
+			continue
+		}
+		// the child keeps using the socket file after we close the listener
+		if ul, ok := in.lis.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+		f, err := fl.File()
+		if errors.Is(err, net.ErrClosed) {
+			continue
+		}
+		if err != nil {
+			closeFiles(files)
+			return nil, nil, err
+		}
+		keys = append(keys, in.key)
+		files = append(files, f)
+	}
+	return keys, files, nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
+
+// environ returns the current environment without the inherit variables.
+func environ() []string {
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, listenersEnv+"=") || strings.HasPrefix(kv, readyEnv+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// StartProcess starts a copy of the running executable with the same
+// arguments, hands it every listener created by Listen and waits until it
+// calls Ready. The child is killed if it is not ready within timeout, zero
+// means no timeout.
+func StartProcess(timeout time.Duration) (*os.Process, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	keys, files, err := export()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFiles(files)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, w)
+	cmd.Env = append(environ(),
+		listenersEnv+"="+strings.Join(keys, ","),
+		readyEnv+"="+strconv.Itoa(firstFd+len(files)),
+	)
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	ready := make(chan error, 1)
+	go func() {
+		// a read only returns once the child reports ready or exits
+		buf := make([]byte, 1)
+		_, err := r.Read(buf)
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+		if err != nil {
+			err = fmt.Errorf("inherit: child process exited before ready: %w", err)
+		}
+	case <-timeoutC:
+		err = ErrReadyTimeout
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	return cmd.Process, nil
+}
+
+// Ready tells the parent process that this process is serving. It is a no-op
+// unless the process was started by StartProcess.
+func Ready() (err error) {
+	readyOnce.Do(func() {
+		env := os.Getenv(readyEnv)
+		if env == "" {
+			return
+		}
+		var fd int
+		if fd, err = strconv.Atoi(env); err != nil {
+			return
+		}
+		f := os.NewFile(uintptr(fd), "ready")
+		defer f.Close()
+		_, err = f.Write([]byte{1})
+	})
+	return
+}
@@ -0,0 +1,80 @@
+package inherit
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+const testAddrEnv = "GAIA_INHERIT_TEST_ADDR"
+
+func TestStartProcess(t *testing.T) {
+	if os.Getenv(readyEnv) != "" {
+		// running as the child process
+		lis, err := Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if os.Getenv(testAddrEnv) == "fail" {
+			os.Exit(1)
+		}
+		if got, want := lis.Addr().String(), os.Getenv(testAddrEnv); got != want {
+			t.Fatalf("expected inherited listener on %s got %s", want, got)
+		}
+		if err := Ready(); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	lis, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	t.Setenv(testAddrEnv, lis.Addr().String())
+
+	p, err := StartProcess(10 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := p.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Success() {
+		t.Errorf("child process failed: %v", state)
+	}
+
+	t.Setenv(testAddrEnv, "fail")
+	if _, err = StartProcess(10 * time.Second); err == nil {
+		t.Error("expected error when the child exits before ready")
+	}
+}
+
+func TestListen(t *testing.T) {
+	lis, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, files, err := export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeFiles(files)
+	if len(keys) == 0 || keys[len(keys)-1] != "tcp=127.0.0.1:0" {
+		t.Errorf("expected exported listener, got %v", keys)
+	}
+
+	_ = lis.Close()
+	n := len(keys)
+	if keys, _, err = export(); err != nil || len(keys) != n-1 {
+		t.Errorf("expected closed listener to be skipped, got %v %v", keys, err)
+	}
+}
+
+func TestReady(t *testing.T) {
+	if err := Ready(); err != nil {
+		t.Errorf("expected nil got %v", err)
+	}
+}
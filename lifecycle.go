@@ -0,0 +1,78 @@
+package gaia
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Lifecycle phases, in the order Run walks through them. The start phase
+// starts the components level by level in dependency order, then the
+// servers, and completes once every server implementing transport.Readier is
+// serving.
+//
+// Startup phases run until the first failure. Once any startup phase fails, or
+// the application is asked to stop, the shutdown phases run to completion and
+// every error is collected:
+//
+//   - beforeStop runs only if the servers were started.
+//   - deregister runs only if the instance was registered.
+//   - drain marks every transport.Drainer as not ready and waits for the
+//     drain delay.
+//   - stop runs for every server that was started, then for every started
+//     component in the reverse order.
+//   - afterStop always runs, even if beforeStart failed.
+const (
+	phaseBeforeStart = "beforeStart"
+	phaseStart       = "start"
+	phaseRegister    = "register"
+	phaseAfterStart  = "afterStart"
+	phaseBeforeStop  = "beforeStop"
+	phaseDeregister  = "deregister"
+	phaseStop        = "stop"
+	phaseAfterStop   = "afterStop"
+)
+
+// phaseError annotates err with the lifecycle phase that produced it.
+func phaseError(phase string, err error) error {
+	return fmt.Errorf("gaia: %s: %w", phase, err)
+}
+
+// runHooks runs fns in order. When stopOnError is set it returns at the first
+// failure, otherwise it runs every hook and returns all errors.
+func (a *Gaia) runHooks(ctx context.Context, phase string, fns []func(context.Context) error, stopOnError bool) []error {
+	var errs []error
+	for _, fn := range fns {
+		if err := a.runHook(ctx, fn); err != nil {
+			errs = append(errs, phaseError(phase, err))
+			if stopOnError {
+				return errs
+			}
+		}
+	}
+	return errs
+}
+
+// runHook runs fn bounded by the hook timeout. A hook that ignores its
+// context is abandoned once the timeout elapses. Cancellation of ctx itself
+// is left to the hook so a hook finishing during Stop is not an error.
+func (a *Gaia) runHook(ctx context.Context, fn func(context.Context) error) error {
+	if a.opts.hookTimeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.opts.hookTimeout)
+	defer cancel()
+	timer := time.NewTimer(a.opts.hookTimeout)
+	defer timer.Stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- fn(ctx)
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-timer.C:
+		return context.DeadlineExceeded
+	}
+}
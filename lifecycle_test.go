@@ -0,0 +1,367 @@
+package gaia
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/internal/inherit"
+	"github.com/apus-run/gaia/registry"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(e string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *recorder) hook(name string, err error) func(context.Context) error {
+	return func(context.Context) error {
+		r.add(name)
+		return err
+	}
+}
+
+type mockServer struct {
+	rec     *recorder
+	stopErr error
+	started chan struct{}
+	stop    chan struct{}
+}
+
+func newMockServer(rec *recorder) *mockServer {
+	return &mockServer{rec: rec, started: make(chan struct{}), stop: make(chan struct{})}
+}
+
+func (s *mockServer) Start(ctx context.Context) error {
+	s.rec.add("start")
+	close(s.started)
+	<-s.stop
+	return nil
+}
+
+func (s *mockServer) Ready() <-chan struct{} {
+	return s.started
+}
+
+func (s *mockServer) Stop(ctx context.Context) error {
+	s.rec.add("stop")
+	close(s.stop)
+	return s.stopErr
+}
+
+type recordRegistry struct {
+	rec           *recorder
+	registerErr   error
+	deregisterErr error
+}
+
+func (r *recordRegistry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	r.rec.add("register")
+	return r.registerErr
+}
+
+func (r *recordRegistry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	r.rec.add("deregister")
+	return r.deregisterErr
+}
+
+func TestLifecycle_Order(t *testing.T) {
+	rec := &recorder{}
+	var app *Gaia
+	app = New(
+		WithServer(newMockServer(rec)),
+		WithRegistry(&recordRegistry{rec: rec}),
+		BeforeStart(rec.hook("beforeStart", nil)),
+		AfterStart(func(ctx context.Context) error {
+			rec.add("afterStart")
+			return app.Stop()
+		}),
+		BeforeStop(rec.hook("beforeStop", nil)),
+		AfterStop(rec.hook("afterStop", nil)),
+	)
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"beforeStart", "start", "register", "afterStart", "beforeStop", "deregister", "stop", "afterStop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_BeforeStartError(t *testing.T) {
+	rec := &recorder{}
+	errBoom := errors.New("boom")
+	app := New(
+		WithServer(newMockServer(rec)),
+		WithRegistry(&recordRegistry{rec: rec}),
+		BeforeStart(rec.hook("beforeStart", errBoom)),
+		BeforeStart(rec.hook("beforeStart2", nil)),
+		BeforeStop(rec.hook("beforeStop", nil)),
+		AfterStop(rec.hook("afterStop", nil)),
+	)
+	if err := app.Run(); !errors.Is(err, errBoom) {
+		t.Fatalf("expected %v got %v", errBoom, err)
+	}
+	want := []string{"beforeStart", "afterStop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_AfterStartErrorRollsBack(t *testing.T) {
+	rec := &recorder{}
+	errStart := errors.New("after start")
+	errStop := errors.New("before stop")
+	errDeregister := errors.New("deregister")
+	srv := newMockServer(rec)
+	srv.stopErr = errors.New("stop")
+	app := New(
+		WithServer(srv),
+		WithRegistry(&recordRegistry{rec: rec, deregisterErr: errDeregister}),
+		AfterStart(rec.hook("afterStart", errStart)),
+		BeforeStop(rec.hook("beforeStop", errStop)),
+		BeforeStop(rec.hook("beforeStop2", nil)),
+		AfterStop(rec.hook("afterStop", nil)),
+	)
+	err := app.Run()
+	for _, e := range []error{errStart, errStop, errDeregister, srv.stopErr} {
+		if !errors.Is(err, e) {
+			t.Errorf("expected %v in %v", e, err)
+		}
+	}
+	want := []string{"start", "register", "afterStart", "beforeStop", "beforeStop2", "deregister", "stop", "afterStop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_RegisterErrorSkipsDeregister(t *testing.T) {
+	rec := &recorder{}
+	errRegister := errors.New("register")
+	app := New(
+		WithServer(newMockServer(rec)),
+		WithRegistry(&recordRegistry{rec: rec, registerErr: errRegister}),
+		AfterStart(rec.hook("afterStart", nil)),
+		AfterStop(rec.hook("afterStop", nil)),
+	)
+	if err := app.Run(); !errors.Is(err, errRegister) {
+		t.Fatalf("expected %v got %v", errRegister, err)
+	}
+	want := []string{"start", "register", "stop", "afterStop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_HookTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	app := New(
+		WithHookTimeout(10*time.Millisecond),
+		BeforeStart(func(context.Context) error {
+			<-block
+			return nil
+		}),
+	)
+	if err := app.Run(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v got %v", context.DeadlineExceeded, err)
+	}
+}
+
+type readyServer struct {
+	*mockServer
+	ready    chan struct{}
+	startErr error
+}
+
+func (s *readyServer) Start(ctx context.Context) error {
+	s.rec.add("start")
+	if s.startErr != nil {
+		return s.startErr
+	}
+	<-s.stop
+	return nil
+}
+
+func (s *readyServer) Ready() <-chan struct{} {
+	return s.ready
+}
+
+func TestLifecycle_WaitReady(t *testing.T) {
+	rec := &recorder{}
+	srv := &readyServer{mockServer: newMockServer(rec), ready: make(chan struct{})}
+	time.AfterFunc(50*time.Millisecond, func() {
+		rec.add("ready")
+		close(srv.ready)
+	})
+	var app *Gaia
+	app = New(
+		WithServer(srv),
+		WithRegistry(&recordRegistry{rec: rec}),
+		AfterStart(func(ctx context.Context) error {
+			return app.Stop()
+		}),
+	)
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start", "ready", "register", "deregister", "stop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_ReadyTimeout(t *testing.T) {
+	rec := &recorder{}
+	srv := &readyServer{mockServer: newMockServer(rec), ready: make(chan struct{})}
+	app := New(
+		WithServer(srv),
+		WithRegistry(&recordRegistry{rec: rec}),
+		WithReadyTimeout(10*time.Millisecond),
+	)
+	if err := app.Run(); !errors.Is(err, ErrReadyTimeout) {
+		t.Fatalf("expected %v got %v", ErrReadyTimeout, err)
+	}
+	want := []string{"start", "stop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_StartError(t *testing.T) {
+	rec := &recorder{}
+	errStart := errors.New("listen")
+	srv := &readyServer{mockServer: newMockServer(rec), ready: make(chan struct{}), startErr: errStart}
+	app := New(
+		WithServer(srv),
+		WithRegistry(&recordRegistry{rec: rec}),
+	)
+	if err := app.Run(); !errors.Is(err, errStart) {
+		t.Fatalf("expected %v got %v", errStart, err)
+	}
+	want := []string{"start", "stop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+type drainServer struct {
+	*mockServer
+}
+
+func (s *drainServer) Drain() {
+	s.rec.add("drain")
+}
+
+func TestLifecycle_Drain(t *testing.T) {
+	rec := &recorder{}
+	var app *Gaia
+	app = New(
+		WithServer(&drainServer{newMockServer(rec)}),
+		WithRegistry(&recordRegistry{rec: rec}),
+		WithDrainDelay(50*time.Millisecond),
+		AfterStart(func(ctx context.Context) error {
+			return app.Stop()
+		}),
+	)
+	start := time.Now()
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("expected drain delay, stopped after %v", d)
+	}
+	want := []string{"start", "register", "deregister", "drain", "stop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_ForceExit(t *testing.T) {
+	exited := make(chan int, 1)
+	exit = func(code int) { exited <- code }
+	defer func() { exit = os.Exit }()
+
+	app := New(
+		WithSignal(syscall.SIGUSR1),
+		WithServer(newMockServer(&recorder{})),
+		WithDrainDelay(time.Second),
+		AfterStart(func(ctx context.Context) error {
+			return syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		}),
+		BeforeStop(func(ctx context.Context) error {
+			return syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		}),
+	)
+	go func() {
+		_ = app.Run()
+	}()
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("expected exit code 1 got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected second signal to force exit")
+	}
+}
+
+func TestLifecycle_RestartSkipsDeregister(t *testing.T) {
+	startProcess = func(time.Duration) (*os.Process, error) {
+		return &os.Process{Pid: 42}, nil
+	}
+	defer func() { startProcess = inherit.StartProcess }()
+
+	rec := &recorder{}
+	app := New(
+		WithGracefulRestart(syscall.SIGUSR2),
+		WithServer(newMockServer(rec)),
+		WithRegistry(&recordRegistry{rec: rec}),
+		AfterStart(func(ctx context.Context) error {
+			return syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+		}),
+	)
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start", "register", "stop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
+
+func TestLifecycle_StartupErrorSkipsBeforeStop(t *testing.T) {
+	rec := &recorder{}
+	errStart := errors.New("connect")
+	app := New(
+		WithServer(newMockServer(rec)),
+		WithRegistry(&recordRegistry{rec: rec}),
+		WithComponent("db", &mockComponent{name: "db", rec: rec, startErr: errStart}),
+		BeforeStop(rec.hook("beforeStop", nil)),
+		AfterStop(rec.hook("afterStop", nil)),
+	)
+	if err := app.Run(); !errors.Is(err, errStart) {
+		t.Fatalf("expected %v got %v", errStart, err)
+	}
+	want := []string{"start db", "stop db", "afterStop"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+)
+
+// Attempt is an attempt of a client call sent again by retrying middleware.
+type Attempt struct {
+	// Index is the index of the attempt, 0 for the first one.
+	Index int
+	// Hedged is true when the attempts of the call run concurrently, the
+	// transports then decode the reply of a single attempt.
+	Hedged bool
+}
+
+type attemptKey struct{}
+
+// NewAttemptContext returns a new Context of an attempt of a client call.
+func NewAttemptContext(ctx context.Context, a Attempt) context.Context {
+	return context.WithValue(ctx, attemptKey{}, a)
+}
+
+// FromAttemptContext returns the attempt of the client call when it is sent
+// by retrying middleware.
+func FromAttemptContext(ctx context.Context) (Attempt, bool) {
+	a, ok := ctx.Value(attemptKey{}).(Attempt)
+	return a, ok
+}
@@ -0,0 +1,235 @@
+package circuitbreaker
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BreakerOption is breaker option, the options of the other kind of
+// breaker are ignored.
+type BreakerOption func(*breakerOptions)
+
+type breakerOptions struct {
+	window  time.Duration
+	buckets int
+	request int64
+
+	// adaptive throttling
+	k float64
+
+	// closed/open/half-open
+	failureRatio     float64
+	openTimeout      time.Duration
+	halfOpenRequests int
+
+	now func() time.Time
+}
+
+// WithWindow with the rolling window of the calls counted, and the number
+// of its buckets, 10s of 40 buckets by default.
+func WithWindow(window time.Duration, buckets int) BreakerOption {
+	return func(o *breakerOptions) {
+		o.window = window
+		o.buckets = buckets
+	}
+}
+
+// WithMinRequests with the calls in the window below which the breaker
+// allows every call, 100 by default for NewSRE and 20 for NewClassic.
+func WithMinRequests(n int64) BreakerOption {
+	return func(o *breakerOptions) {
+		o.request = n
+	}
+}
+
+// WithK with the multiplier of the successes of NewSRE, the lower the more
+// aggressive the throttling, 1.5 by default.
+func WithK(k float64) BreakerOption {
+	return func(o *breakerOptions) {
+		o.k = k
+	}
+}
+
+// WithFailureRatio with the ratio of failures in the window opening
+// NewClassic, 0.5 by default.
+func WithFailureRatio(r float64) BreakerOption {
+	return func(o *breakerOptions) {
+		o.failureRatio = r
+	}
+}
+
+// WithOpenTimeout with the time NewClassic stays open before it lets
+// calls probe the downstream, 5s by default.
+func WithOpenTimeout(d time.Duration) BreakerOption {
+	return func(o *breakerOptions) {
+		o.openTimeout = d
+	}
+}
+
+// WithHalfOpenRequests with the probing calls of NewClassic when half
+// open, which close it when they all succeed, 1 by default.
+func WithHalfOpenRequests(n int) BreakerOption {
+	return func(o *breakerOptions) {
+		o.halfOpenRequests = n
+	}
+}
+
+func newBreakerOptions(request int64, opts []BreakerOption) breakerOptions {
+	o := breakerOptions{
+		window:           10 * time.Second,
+		buckets:          40,
+		request:          request,
+		k:                1.5,
+		failureRatio:     0.5,
+		openTimeout:      5 * time.Second,
+		halfOpenRequests: 1,
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// sre is the adaptive throttling of the Google SRE book: it rejects the
+// calls with the probability max(0, (requests - k * successes) / (requests
+// + 1)), the rejected calls being requests too.
+type sre struct {
+	w       *window
+	k       float64
+	request int64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewSRE returns an adaptive throttling breaker, which sheds the calls in
+// proportion to the failures without a hard open state.
+func NewSRE(opts ...BreakerOption) Breaker {
+	o := newBreakerOptions(100, opts)
+	return &sre{
+		w:       newWindow(o.window, o.buckets, o.now),
+		k:       o.k,
+		request: o.request,
+		rand:    rand.New(rand.NewSource(o.now().UnixNano())),
+	}
+}
+
+func (b *sre) Allow() error {
+	requests, successes := b.w.sum()
+	if requests < b.request {
+		return nil
+	}
+	p := (float64(requests) - b.k*float64(successes)) / float64(requests+1)
+	if p <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	drop := b.rand.Float64() < p
+	b.mu.Unlock()
+	if drop {
+		b.w.add(1, 0)
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+func (b *sre) MarkSuccess() {
+	b.w.add(1, 1)
+}
+
+func (b *sre) MarkFailed() {
+	b.w.add(1, 0)
+}
+
+// state is the state of a classic breaker.
+type state int
+
+const (
+	// stateClosed allows the calls.
+	stateClosed state = iota
+	// stateOpen rejects the calls.
+	stateOpen
+	// stateHalfOpen allows a few calls to probe the downstream.
+	stateHalfOpen
+)
+
+// classic is the closed/open/half-open circuit breaker.
+type classic struct {
+	o breakerOptions
+	w *window
+
+	mu       sync.Mutex
+	state    state
+	openedAt time.Time
+	// probes and passed are the calls and the successes when half open
+	probes int
+	passed int
+}
+
+// NewClassic returns a closed/open/half-open breaker. It opens when the
+// ratio of failures in the window reaches the failure ratio, rejects the
+// calls while open, then lets a few calls probe the downstream: it closes
+// when they all succeed and opens again on a failure.
+func NewClassic(opts ...BreakerOption) Breaker {
+	o := newBreakerOptions(20, opts)
+	return &classic{
+		o: o,
+		w: newWindow(o.window, o.buckets, o.now),
+	}
+}
+
+func (b *classic) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case stateOpen:
+		if b.o.now().Sub(b.openedAt) < b.o.openTimeout {
+			return ErrNotAllowed
+		}
+		b.state, b.probes, b.passed = stateHalfOpen, 0, 0
+		fallthrough
+	case stateHalfOpen:
+		if b.probes >= b.o.halfOpenRequests {
+			return ErrNotAllowed
+		}
+		b.probes++
+	}
+	return nil
+}
+
+func (b *classic) MarkSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case stateClosed:
+		b.w.add(1, 1)
+	case stateHalfOpen:
+		b.passed++
+		if b.passed >= b.o.halfOpenRequests {
+			b.state = stateClosed
+			b.w.reset()
+		}
+	}
+}
+
+func (b *classic) MarkFailed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case stateClosed:
+		b.w.add(1, 0)
+		requests, successes := b.w.sum()
+		if requests >= b.o.request && float64(requests-successes) >= b.o.failureRatio*float64(requests) {
+			b.open()
+		}
+	case stateHalfOpen:
+		b.open()
+	}
+}
+
+func (b *classic) open() {
+	b.state = stateOpen
+	b.openedAt = b.o.now()
+}
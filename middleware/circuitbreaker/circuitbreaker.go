@@ -0,0 +1,121 @@
+package circuitbreaker
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/transport"
+)
+
+// ErrNotAllowed is returned when the breaker of the operation rejects the
+// call, it is Unavailable in gRPC and 503 in HTTP.
+var ErrNotAllowed = errcode.Newf(http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN", "request failed due to circuit breaker triggered")
+
+// Breaker is a circuit breaker of an operation.
+type Breaker interface {
+	// Allow returns an error when the call is rejected.
+	Allow() error
+	// MarkSuccess records a call that succeeded.
+	MarkSuccess()
+	// MarkFailed records a call that failed.
+	MarkFailed()
+}
+
+// Option is circuit breaker option.
+type Option func(*options)
+
+type options struct {
+	breaker func() Breaker
+	failure func(err error) bool
+}
+
+// WithBreaker with the breaker of each operation, NewSRE() by default.
+func WithBreaker(f func() Breaker) Option {
+	return func(o *options) {
+		o.breaker = f
+	}
+}
+
+// WithFailure with the errors counted as failures, DefaultFailure by
+// default.
+func WithFailure(f func(err error) bool) Option {
+	return func(o *options) {
+		o.failure = f
+	}
+}
+
+// DefaultFailure counts the errors of an overloaded or unavailable
+// downstream as failures: Unknown, DeadlineExceeded, ResourceExhausted,
+// Internal, Unavailable and DataLoss, the errors of the request are not.
+func DefaultFailure(err error) bool {
+	switch errcode.FromError(err).GRPCCode() {
+	case codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// Client is a client middleware that rejects the calls of an operation
+// with ErrNotAllowed while its breaker is open. The breakers are keyed by
+// the operation of the client transport, the messages of streams are not
+// counted.
+func Client(opts ...Option) middleware.Middleware {
+	o := options{
+		breaker: func() Breaker { return NewSRE() },
+		failure: DefaultFailure,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	g := &group{new: o.breaker, breakers: make(map[string]Breaker)}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := middleware.FromMessageContext(ctx); ok {
+				return handler(ctx, req)
+			}
+			var operation string
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				operation = tr.Operation()
+			}
+			b := g.get(operation)
+			if err := b.Allow(); err != nil {
+				return nil, err
+			}
+			reply, err := handler(ctx, req)
+			if err != nil && o.failure(err) {
+				b.MarkFailed()
+			} else {
+				b.MarkSuccess()
+			}
+			return reply, err
+		}
+	}
+}
+
+// group is the breakers by operation.
+type group struct {
+	new      func() Breaker
+	mu       sync.RWMutex
+	breakers map[string]Breaker
+}
+
+func (g *group) get(operation string) Breaker {
+	g.mu.RLock()
+	b, ok := g.breakers[operation]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok = g.breakers[operation]; !ok {
+		b = g.new()
+		g.breakers[operation] = b
+	}
+	return b
+}
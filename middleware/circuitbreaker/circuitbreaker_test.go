@@ -0,0 +1,181 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/transport"
+)
+
+type testTransport struct{ operation string }
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return nil }
+func (tr *testTransport) ReplyHeader() transport.Header   { return nil }
+
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time        { return c.t }
+func (c *testClock) add(d time.Duration)   { c.t = c.t.Add(d) }
+func (c *testClock) option() BreakerOption { return func(o *breakerOptions) { o.now = c.now } }
+
+func TestSRE(t *testing.T) {
+	b := NewSRE(WithMinRequests(10))
+	for i := 0; i < 10; i++ {
+		b.MarkSuccess()
+	}
+	for i := 0; i < 100; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("expect the calls allowed, got %v", err)
+		}
+		b.MarkSuccess()
+	}
+
+	b = NewSRE(WithMinRequests(10))
+	for i := 0; i < 100; i++ {
+		b.MarkFailed()
+	}
+	rejected := 0
+	for i := 0; i < 100; i++ {
+		if err := b.Allow(); err != nil {
+			rejected++
+		}
+	}
+	if rejected < 90 {
+		t.Errorf("expect most calls rejected, got %d", rejected)
+	}
+}
+
+func TestSRE_window(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	b := NewSRE(WithMinRequests(10), WithWindow(time.Second, 10), clock.option())
+	for i := 0; i < 100; i++ {
+		b.MarkFailed()
+	}
+	clock.add(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Errorf("expect the failures out of the window, got %v", err)
+	}
+}
+
+func TestClassic(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	b := NewClassic(WithMinRequests(4), WithFailureRatio(0.5), WithOpenTimeout(time.Second), WithHalfOpenRequests(2), clock.option())
+	c := b.(*classic)
+
+	b.MarkSuccess()
+	b.MarkSuccess()
+	b.MarkFailed()
+	if c.state != stateClosed {
+		t.Fatalf("expect closed below the min requests, got %v", c.state)
+	}
+	b.MarkFailed()
+	if c.state != stateOpen {
+		t.Fatalf("expect open, got %v", c.state)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expect %v, got %v", ErrNotAllowed, err)
+	}
+
+	// half open: the probes fail
+	clock.add(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expect a probe allowed, got %v", err)
+	}
+	b.MarkFailed()
+	if c.state != stateOpen {
+		t.Fatalf("expect open again, got %v", c.state)
+	}
+
+	// half open: the probes succeed
+	clock.add(time.Second)
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("expect a probe allowed, got %v", err)
+		}
+	}
+	if err := b.Allow(); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expect the calls above the probes rejected, got %v", err)
+	}
+	b.MarkSuccess()
+	b.MarkSuccess()
+	if c.state != stateClosed {
+		t.Fatalf("expect closed, got %v", c.state)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expect the calls allowed, got %v", err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	failed := errcode.ErrServiceUnavailable
+	m := Client(WithBreaker(func() Breaker {
+		return NewClassic(WithMinRequests(2), WithOpenTimeout(time.Hour))
+	}))
+	next := func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch req {
+		case "fail":
+			return nil, failed
+		case "invalid":
+			return nil, errcode.ErrInvalidParam
+		}
+		return "reply", nil
+	}
+	call := func(operation string, req interface{}) error {
+		ctx := transport.NewClientContext(context.Background(), &testTransport{operation: operation})
+		_, err := m(next)(ctx, req)
+		return err
+	}
+
+	// the errors of the request are not failures
+	for i := 0; i < 10; i++ {
+		if err := call("/a", "invalid"); !errors.Is(err, errcode.ErrInvalidParam) {
+			t.Fatalf("expect %v, got %v", errcode.ErrInvalidParam, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := call("/b", "fail"); !errors.Is(err, failed) {
+			t.Fatalf("expect %v, got %v", failed, err)
+		}
+	}
+	err := call("/b", "ok")
+	if !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expect %v, got %v", ErrNotAllowed, err)
+	}
+	e := errcode.FromError(err)
+	if e.GRPCCode() != codes.Unavailable || e.StatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("expect Unavailable and 503, got %s and %d", e.GRPCCode(), e.StatusCode())
+	}
+	// the breakers are keyed by operation
+	if err = call("/a", "ok"); err != nil {
+		t.Errorf("expect the calls of another operation allowed, got %v", err)
+	}
+	// the messages of the streams are not counted
+	ctx := middleware.NewMessageContext(transport.NewClientContext(context.Background(), &testTransport{operation: "/b"}), middleware.SendMessage)
+	if _, err = m(next)(ctx, "ok"); err != nil {
+		t.Errorf("expect the messages passed, got %v", err)
+	}
+}
+
+func TestWithFailure(t *testing.T) {
+	m := Client(
+		WithBreaker(func() Breaker { return NewClassic(WithMinRequests(1), WithOpenTimeout(time.Hour)) }),
+		WithFailure(func(err error) bool { return errors.Is(err, errcode.ErrInvalidParam) }),
+	)
+	next := func(context.Context, interface{}) (interface{}, error) {
+		return nil, errcode.ErrInvalidParam
+	}
+	_, _ = m(next)(context.Background(), nil)
+	if _, err := m(next)(context.Background(), nil); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("expect %v, got %v", ErrNotAllowed, err)
+	}
+}
@@ -0,0 +1,85 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+// window counts the requests and the successes of the last buckets of a
+// rolling window.
+type window struct {
+	mu      sync.Mutex
+	buckets []bucket
+	width   time.Duration
+	// last is the index and the start time of the current bucket
+	last  int
+	start time.Time
+	now   func() time.Time
+}
+
+type bucket struct {
+	requests  int64
+	successes int64
+}
+
+func newWindow(size time.Duration, buckets int, now func() time.Time) *window {
+	if buckets < 1 {
+		buckets = 1
+	}
+	width := size / time.Duration(buckets)
+	if width <= 0 {
+		width = time.Millisecond
+	}
+	return &window{
+		buckets: make([]bucket, buckets),
+		width:   width,
+		start:   now(),
+		now:     now,
+	}
+}
+
+// advance resets the buckets elapsed since the current one.
+func (w *window) advance() {
+	now := w.now()
+	n := int(now.Sub(w.start) / w.width)
+	if n <= 0 {
+		return
+	}
+	if n > len(w.buckets) {
+		n = len(w.buckets)
+	}
+	for i := 1; i <= n; i++ {
+		w.buckets[(w.last+i)%len(w.buckets)] = bucket{}
+	}
+	w.last = (w.last + n) % len(w.buckets)
+	w.start = now.Add(-now.Sub(w.start) % w.width)
+}
+
+func (w *window) add(requests, successes int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance()
+	w.buckets[w.last].requests += requests
+	w.buckets[w.last].successes += successes
+}
+
+// sum returns the requests and the successes of the window.
+func (w *window) sum() (requests, successes int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance()
+	for _, b := range w.buckets {
+		requests += b.requests
+		successes += b.successes
+	}
+	return requests, successes
+}
+
+func (w *window) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+	w.start = w.now()
+}
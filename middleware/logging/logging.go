@@ -0,0 +1,215 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/transport"
+	"github.com/apus-run/sea-kit/log"
+)
+
+// Redacter is implemented by the requests that redact their sensitive
+// fields when logged, see WithArgs.
+type Redacter interface {
+	Redact() string
+}
+
+// RedactFunc returns the value logged for the key, e.g. "peer" or "args".
+type RedactFunc func(key string, value interface{}) interface{}
+
+// Option is logging option.
+type Option func(*options)
+
+type options struct {
+	logger     log.Logger
+	sampleRate float64
+	slow       time.Duration
+	args       bool
+	redact     RedactFunc
+}
+
+// WithLogger with the logger, the global logger by default.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSampleRate with the rate in [0, 1] of the successful requests logged,
+// 1 by default. The failed and the slow requests are always logged.
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithSlowThreshold with the latency above which the requests are logged at
+// the warn level, disabled by default.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slow = d
+	}
+}
+
+// WithArgs logs the requests, redacted by their Redacter.
+func WithArgs() Option {
+	return func(o *options) {
+		o.args = true
+	}
+}
+
+// WithRedact with the redaction of the logged fields.
+func WithRedact(f RedactFunc) Option {
+	return func(o *options) {
+		o.redact = f
+	}
+}
+
+// Server is a server middleware that logs the requests with their
+// operation, transport kind, endpoint, peer, latency, error code and
+// reason, and the sizes of the requests and the replies when they are
+// known. The streams are logged once when they end.
+func Server(opts ...Option) middleware.Middleware {
+	return logging("server", func(ctx context.Context) (transport.Transporter, bool) {
+		return transport.FromServerContext(ctx)
+	}, opts...)
+}
+
+// Client is a client middleware that logs the calls like Server, without
+// the peer.
+func Client(opts ...Option) middleware.Middleware {
+	return logging("client", func(ctx context.Context) (transport.Transporter, bool) {
+		return transport.FromClientContext(ctx)
+	}, opts...)
+}
+
+func logging(kind string, fromContext func(context.Context) (transport.Transporter, bool), opts ...Option) middleware.Middleware {
+	o := options{sampleRate: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := middleware.FromMessageContext(ctx); ok {
+				return handler(ctx, req)
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			latency := time.Since(start)
+
+			level := log.LevelInfo
+			slow := o.slow > 0 && latency >= o.slow
+			switch {
+			case err != nil:
+				level = log.LevelError
+			case slow:
+				level = log.LevelWarn
+			case o.sampleRate < 1 && rand.Float64() >= o.sampleRate:
+				return reply, err
+			}
+
+			kvs := []interface{}{"kind", kind}
+			if tr, ok := fromContext(ctx); ok {
+				kvs = append(kvs,
+					"component", string(tr.Kind()),
+					"operation", tr.Operation(),
+					"endpoint", tr.Endpoint(),
+				)
+			}
+			if kind == "server" {
+				if addr := peerAddr(ctx); addr != "" {
+					kvs = append(kvs, "peer", addr)
+				}
+			}
+			if _, ok := req.(*middleware.StreamInfo); !ok {
+				if n := size(ctx, req, fromContext); n >= 0 {
+					kvs = append(kvs, "req_size", n)
+				}
+				if n := size(ctx, reply, nil); n >= 0 && err == nil {
+					kvs = append(kvs, "reply_size", n)
+				}
+				if o.args {
+					kvs = append(kvs, "args", args(req))
+				}
+			}
+			var (
+				code   int
+				reason string
+			)
+			if e := errcode.FromError(err); e != nil {
+				code, reason = e.Code(), e.Reason()
+			}
+			kvs = append(kvs,
+				"code", code,
+				"reason", reason,
+				"latency", latency.Seconds(),
+			)
+			if slow {
+				kvs = append(kvs, "slow", true)
+			}
+			if o.redact != nil {
+				for i := 0; i+1 < len(kvs); i += 2 {
+					kvs[i+1] = o.redact(kvs[i].(string), kvs[i+1])
+				}
+			}
+
+			logger := o.logger
+			if logger == nil {
+				logger = log.GetLogger()
+			}
+			_ = log.WithContext(ctx, logger).Log(level, kvs...)
+			return reply, err
+		}
+	}
+}
+
+// peerAddr returns the address of the peer of a gRPC or an HTTP server.
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if r, ok := tr.(interface{ Request() *http.Request }); ok && r.Request() != nil {
+			return r.Request().RemoteAddr
+		}
+	}
+	return ""
+}
+
+// size returns the size of a proto message or of the body of the HTTP
+// request of the transport, -1 when it is unknown.
+func size(ctx context.Context, m interface{}, fromContext func(context.Context) (transport.Transporter, bool)) int {
+	switch m := m.(type) {
+	case proto.Message:
+		return proto.Size(m)
+	case []byte:
+		return len(m)
+	}
+	if fromContext == nil {
+		return -1
+	}
+	if tr, ok := fromContext(ctx); ok {
+		if r, ok := tr.(interface{ Request() *http.Request }); ok && r.Request() != nil && r.Request().ContentLength >= 0 {
+			return int(r.Request().ContentLength)
+		}
+	}
+	return -1
+}
+
+func args(req interface{}) string {
+	if r, ok := req.(Redacter); ok {
+		return r.Redact()
+	}
+	if s, ok := req.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%+v", req)
+}
@@ -0,0 +1,203 @@
+package logging
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/peer"
+
+	pb "github.com/apus-run/gaia/internal/testdata/helloworld"
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/transport"
+	"github.com/apus-run/sea-kit/log"
+)
+
+type testTransport struct {
+	kind transport.Kind
+	req  *http.Request
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *testTransport) Endpoint() string                { return "127.0.0.1:8000" }
+func (tr *testTransport) Operation() string               { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return nil }
+func (tr *testTransport) ReplyHeader() transport.Header   { return nil }
+func (tr *testTransport) Request() *http.Request          { return tr.req }
+
+type entry struct {
+	level log.Level
+	kvs   map[string]interface{}
+}
+
+type testLogger struct{ entries []entry }
+
+func (l *testLogger) Log(level log.Level, keyvals ...interface{}) error {
+	kvs := make(map[string]interface{}, len(keyvals)/2)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		kvs[keyvals[i].(string)] = keyvals[i+1]
+	}
+	l.entries = append(l.entries, entry{level: level, kvs: kvs})
+	return nil
+}
+
+type secret struct{ password string }
+
+func (s *secret) Redact() string { return "password:***" }
+
+func TestServer(t *testing.T) {
+	reply := &pb.HelloReply{Message: "hello"}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		req   interface{}
+		err   error
+		level log.Level
+		kvs   map[string]interface{}
+	}{
+		{
+			name: "grpc",
+			ctx: peer.NewContext(
+				transport.NewServerContext(context.Background(), &testTransport{kind: transport.KindGRPC}),
+				&peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}},
+			),
+			req:   &pb.HelloRequest{Name: "gaia"},
+			level: log.LevelInfo,
+			kvs: map[string]interface{}{
+				"kind":       "server",
+				"component":  "grpc",
+				"operation":  "/helloworld.Greeter/SayHello",
+				"endpoint":   "127.0.0.1:8000",
+				"peer":       "10.0.0.1:1234",
+				"req_size":   6,
+				"reply_size": 7,
+				"code":       0,
+			},
+		},
+		{
+			name: "fasthttp",
+			ctx: transport.NewServerContext(context.Background(), &testTransport{
+				kind: "fasthttp",
+				req:  &http.Request{RemoteAddr: "10.0.0.2:1234", ContentLength: 42},
+			}),
+			req:   struct{}{},
+			err:   errcode.ErrInvalidParam,
+			level: log.LevelError,
+			kvs: map[string]interface{}{
+				"component": "fasthttp",
+				"peer":      "10.0.0.2:1234",
+				"req_size":  42,
+				"code":      errcode.ErrInvalidParam.Code(),
+				"reason":    errcode.ErrInvalidParam.Reason(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &testLogger{}
+			_, _ = Server(WithLogger(logger))(func(context.Context, interface{}) (interface{}, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return reply, nil
+			})(tt.ctx, tt.req)
+			if len(logger.entries) != 1 {
+				t.Fatalf("expect 1 entry, got %d", len(logger.entries))
+			}
+			e := logger.entries[0]
+			if e.level != tt.level {
+				t.Errorf("expect level %s, got %s", tt.level, e.level)
+			}
+			for k, v := range tt.kvs {
+				if e.kvs[k] != v {
+					t.Errorf("expect %s %v, got %v", k, v, e.kvs[k])
+				}
+			}
+			if _, ok := e.kvs["latency"].(float64); !ok {
+				t.Errorf("expect the latency, got %v", e.kvs["latency"])
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	logger := &testLogger{}
+	ctx := transport.NewClientContext(context.Background(), &testTransport{kind: transport.KindHTTP})
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{}})
+	_, _ = Client(WithLogger(logger))(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, nil)
+	if len(logger.entries) != 1 {
+		t.Fatalf("expect 1 entry, got %d", len(logger.entries))
+	}
+	if kvs := logger.entries[0].kvs; kvs["kind"] != "client" || kvs["component"] != "http" || kvs["peer"] != nil {
+		t.Errorf("expect a client entry without the peer, got %v", kvs)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	logger := &testLogger{}
+	m := Server(WithLogger(logger), WithSampleRate(0), WithSlowThreshold(10*time.Millisecond))
+	h := func(_ context.Context, req interface{}) (interface{}, error) {
+		switch req {
+		case "slow":
+			time.Sleep(10 * time.Millisecond)
+		case "error":
+			return nil, errcode.ErrInternalServer
+		}
+		return nil, nil
+	}
+	for _, req := range []string{"ok", "slow", "error"} {
+		_, _ = m(h)(context.Background(), req)
+	}
+	if len(logger.entries) != 2 {
+		t.Fatalf("expect the slow and the failed requests logged, got %d entries", len(logger.entries))
+	}
+	if e := logger.entries[0]; e.level != log.LevelWarn || e.kvs["slow"] != true {
+		t.Errorf("expect a slow warning, got %v %v", e.level, e.kvs)
+	}
+	if e := logger.entries[1]; e.level != log.LevelError {
+		t.Errorf("expect an error, got %v", e.level)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	logger := &testLogger{}
+	ctx := transport.NewServerContext(context.Background(), &testTransport{
+		kind: transport.KindHTTP,
+		req:  &http.Request{RemoteAddr: "10.0.0.1:1234"},
+	})
+	m := Server(WithLogger(logger), WithArgs(), WithRedact(func(key string, value interface{}) interface{} {
+		if key == "peer" {
+			return "***"
+		}
+		return value
+	}))
+	_, _ = m(func(context.Context, interface{}) (interface{}, error) { return nil, nil })(ctx, &secret{password: "pass"})
+	kvs := logger.entries[0].kvs
+	if kvs["peer"] != "***" {
+		t.Errorf("expect the peer redacted, got %v", kvs["peer"])
+	}
+	if args := kvs["args"].(string); strings.Contains(args, "pass:") || args != "password:***" {
+		t.Errorf("expect the args redacted, got %v", args)
+	}
+}
+
+func TestStream(t *testing.T) {
+	logger := &testLogger{}
+	m := Server(WithLogger(logger), WithArgs())
+	h := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	ctx := context.Background()
+	_, _ = m(h)(middleware.NewMessageContext(ctx, middleware.RecvMessage), "message")
+	_, _ = m(h)(ctx, &middleware.StreamInfo{ServerStream: true})
+	if len(logger.entries) != 1 {
+		t.Fatalf("expect the stream logged once, got %d entries", len(logger.entries))
+	}
+	if _, ok := logger.entries[0].kvs["args"]; ok {
+		t.Errorf("expect no args of a stream, got %v", logger.entries[0].kvs)
+	}
+}
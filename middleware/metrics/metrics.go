@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	pkgmetrics "github.com/apus-run/gaia/pkg/metrics"
+	"github.com/apus-run/gaia/pkg/metrics/prometheus"
+	"github.com/apus-run/gaia/transport"
+)
+
+// Option is metrics option.
+type Option func(*options)
+
+type options struct {
+	recorder pkgmetrics.Recorder
+	buckets  []float64
+}
+
+// WithRecorder with the metrics recorder, the Prometheus default registry
+// by default.
+func WithRecorder(r pkgmetrics.Recorder) Option {
+	return func(o *options) {
+		o.recorder = r
+	}
+}
+
+// WithBuckets with the buckets of the duration histogram in seconds,
+// metrics.DefaultBuckets by default.
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// Server is a server middleware recording the requests:
+//
+//   - gaia_server_requests_total{kind, operation, code},
+//   - gaia_server_request_duration_seconds{kind, operation, code},
+//   - gaia_server_requests_in_flight{kind, operation}.
+//
+// The code is the gRPC code name for gRPC and the HTTP status otherwise.
+// The streams are recorded once when they end.
+func Server(opts ...Option) middleware.Middleware {
+	return newMetrics("server", func(ctx context.Context) (transport.Transporter, bool) {
+		return transport.FromServerContext(ctx)
+	}, opts...)
+}
+
+// Client is a client middleware recording the calls like Server, with the
+// gaia_client prefix.
+func Client(opts ...Option) middleware.Middleware {
+	return newMetrics("client", func(ctx context.Context) (transport.Transporter, bool) {
+		return transport.FromClientContext(ctx)
+	}, opts...)
+}
+
+func newMetrics(side string, fromContext func(context.Context) (transport.Transporter, bool), opts ...Option) middleware.Middleware {
+	o := options{buckets: pkgmetrics.DefaultBuckets}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.recorder == nil {
+		o.recorder = prometheus.New(nil)
+	}
+	prefix := "gaia_" + side + "_"
+	var (
+		requests = o.recorder.Counter(prefix+"requests_total",
+			"The requests completed.", "kind", "operation", "code")
+		duration = o.recorder.Histogram(prefix+"request_duration_seconds",
+			"The duration of the requests in seconds.", o.buckets, "kind", "operation", "code")
+		inflight = o.recorder.Gauge(prefix+"requests_in_flight",
+			"The requests in flight.", "kind", "operation")
+	)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := middleware.FromMessageContext(ctx); ok {
+				return handler(ctx, req)
+			}
+			var kind, operation string
+			if tr, ok := fromContext(ctx); ok {
+				kind, operation = string(tr.Kind()), tr.Operation()
+			}
+			inflight.Add(1, kind, operation)
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			inflight.Add(-1, kind, operation)
+
+			code := statusCode(kind, err)
+			requests.Add(1, kind, operation, code)
+			duration.Observe(time.Since(start).Seconds(), kind, operation, code)
+			return reply, err
+		}
+	}
+}
+
+func statusCode(kind string, err error) string {
+	if kind == string(transport.KindGRPC) {
+		if err == nil {
+			return "OK"
+		}
+		return errcode.FromError(err).GRPCCode().String()
+	}
+	if err == nil {
+		return "200"
+	}
+	return strconv.Itoa(errcode.FromError(err).StatusCode())
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	pkgmetrics "github.com/apus-run/gaia/pkg/metrics"
+	"github.com/apus-run/gaia/transport"
+)
+
+type testTransport struct{ kind transport.Kind }
+
+func (tr *testTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return nil }
+func (tr *testTransport) ReplyHeader() transport.Header   { return nil }
+
+const operation = "/helloworld.Greeter/SayHello"
+
+func TestServer(t *testing.T) {
+	m := pkgmetrics.NewMemory()
+	mw := Server(WithRecorder(m))
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		switch req {
+		case "ok", "error":
+			if v := m.Value("gaia_server_requests_in_flight", "grpc", operation); v != 1 {
+				t.Errorf("expected 1 request in flight got %v", v)
+			}
+		}
+		if req == "error" {
+			return nil, errcode.ErrServiceUnavailable
+		}
+		return nil, nil
+	}
+	grpcCtx := transport.NewServerContext(context.Background(), &testTransport{kind: transport.KindGRPC})
+	httpCtx := transport.NewServerContext(context.Background(), &testTransport{kind: transport.KindHTTP})
+	_, _ = mw(h)(grpcCtx, "ok")
+	_, _ = mw(h)(grpcCtx, "error")
+	_, _ = mw(h)(httpCtx, "http")
+	_, _ = mw(h)(middleware.NewMessageContext(grpcCtx, middleware.RecvMessage), "message")
+
+	tests := []struct {
+		kind, code string
+	}{
+		{"grpc", "OK"},
+		{"grpc", "Unavailable"},
+		{"http", "200"},
+	}
+	for _, tt := range tests {
+		if v := m.Value("gaia_server_requests_total", tt.kind, operation, tt.code); v != 1 {
+			t.Errorf("expected 1 %s request of %s got %v", tt.kind, tt.code, v)
+		}
+		if n := m.Count("gaia_server_request_duration_seconds", tt.kind, operation, tt.code); n != 1 {
+			t.Errorf("expected 1 %s duration of %s got %d", tt.kind, tt.code, n)
+		}
+	}
+	if v := m.Value("gaia_server_requests_in_flight", "grpc", operation); v != 0 {
+		t.Errorf("expected no request in flight got %v", v)
+	}
+}
+
+func TestClient(t *testing.T) {
+	m := pkgmetrics.NewMemory()
+	ctx := transport.NewClientContext(context.Background(), &testTransport{kind: transport.KindHTTP})
+	_, _ = Client(WithRecorder(m))(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errcode.ErrTooManyRequests
+	})(ctx, nil)
+	if v := m.Value("gaia_client_requests_total", "http", operation, "429"); v != 1 {
+		t.Errorf("expected 1 request of 429 got %v", v)
+	}
+}
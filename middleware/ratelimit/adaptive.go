@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apus-run/gaia/pkg/errcode"
+)
+
+// AdaptiveOption is adaptive limiter option.
+type AdaptiveOption func(*adaptive)
+
+// WithCPUThreshold with the CPU usage in permille above which the requests
+// are shed, 800 by default.
+func WithCPUThreshold(permille int64) AdaptiveOption {
+	return func(a *adaptive) {
+		a.threshold = permille
+	}
+}
+
+// WithCPU with the CPU usage in permille, CPUUsage by default, e.g. the
+// usage of the cgroup of a container.
+func WithCPU(f func() int64) AdaptiveOption {
+	return func(a *adaptive) {
+		a.cpu = f
+	}
+}
+
+// WithWindow with the rolling window of the passes and the latencies, and
+// the number of its buckets, 10s of 100 buckets by default.
+func WithWindow(window time.Duration, buckets int) AdaptiveOption {
+	return func(a *adaptive) {
+		a.window = window
+		a.buckets = buckets
+	}
+}
+
+// WithCoolOff with the time after a shed during which the requests are
+// still shed above the in-flight limit, whatever the CPU usage, 1s by
+// default.
+func WithCoolOff(d time.Duration) AdaptiveOption {
+	return func(a *adaptive) {
+		a.coolOff = d
+	}
+}
+
+// adaptive sheds the load like BBR: when the CPU usage is above the
+// threshold, the requests above the in-flight limit are shed. The limit is
+// the max passes per second times the min latency of the window, the
+// requests in flight of a server at full throughput by Little's law.
+type adaptive struct {
+	threshold int64
+	cpu       func() int64
+	window    time.Duration
+	buckets   int
+	coolOff   time.Duration
+	now       func() time.Time
+
+	inflight atomic.Int64
+	// dropped is the unix nano time of the last shed
+	dropped atomic.Int64
+
+	mu    sync.Mutex
+	stats []stat
+	width time.Duration
+	last  int
+	start time.Time
+}
+
+type stat struct {
+	passes int64
+	rt     time.Duration
+}
+
+// NewAdaptive returns a limiter shedding the load by the CPU usage and the
+// requests in flight, the keys are ignored.
+func NewAdaptive(opts ...AdaptiveOption) Limiter {
+	a := &adaptive{
+		threshold: 800,
+		cpu:       CPUUsage,
+		window:    10 * time.Second,
+		buckets:   100,
+		coolOff:   time.Second,
+		now:       time.Now,
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	if a.buckets < 1 {
+		a.buckets = 1
+	}
+	a.width = a.window / time.Duration(a.buckets)
+	if a.width <= 0 {
+		a.width = time.Millisecond
+	}
+	a.stats = make([]stat, a.buckets)
+	a.start = a.now()
+	return a
+}
+
+func (a *adaptive) Allow(string) (DoneFunc, error) {
+	if a.shouldDrop() {
+		return nil, Rejected(errcode.ErrLimitExceed, a.coolOff)
+	}
+	a.inflight.Add(1)
+	start := a.now()
+	return func() {
+		a.inflight.Add(-1)
+		a.add(a.now().Sub(start))
+	}, nil
+}
+
+func (a *adaptive) shouldDrop() bool {
+	now := a.now()
+	if a.cpu() < a.threshold {
+		dropped := a.dropped.Load()
+		if dropped == 0 || now.UnixNano()-dropped > int64(a.coolOff) {
+			return false
+		}
+	}
+	inflight := a.inflight.Load()
+	if inflight <= 1 || inflight <= a.maxInflight() {
+		return false
+	}
+	a.dropped.Store(now.UnixNano())
+	return true
+}
+
+// maxInflight is the max passes per bucket times the min latency in
+// buckets.
+func (a *adaptive) maxInflight() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.advance()
+	var (
+		maxPass int64 = 1
+		minRT         = time.Duration(math.MaxInt64)
+	)
+	for _, s := range a.stats {
+		if s.passes == 0 {
+			continue
+		}
+		if s.passes > maxPass {
+			maxPass = s.passes
+		}
+		if rt := s.rt / time.Duration(s.passes); rt < minRT {
+			minRT = rt
+		}
+	}
+	if minRT == time.Duration(math.MaxInt64) {
+		return 1
+	}
+	return int64(math.Ceil(float64(maxPass) * float64(minRT) / float64(a.width)))
+}
+
+func (a *adaptive) add(rt time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.advance()
+	a.stats[a.last].passes++
+	a.stats[a.last].rt += rt
+}
+
+// advance resets the buckets elapsed since the current one.
+func (a *adaptive) advance() {
+	now := a.now()
+	n := int(now.Sub(a.start) / a.width)
+	if n <= 0 {
+		return
+	}
+	if n > len(a.stats) {
+		n = len(a.stats)
+	}
+	for i := 1; i <= n; i++ {
+		a.stats[(a.last+i)%len(a.stats)] = stat{}
+	}
+	a.last = (a.last + n) % len(a.stats)
+	a.start = now.Add(-now.Sub(a.start) % a.width)
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/apus-run/gaia/pkg/errcode"
+)
+
+// maxIdleKeys is the number of keys above which the buckets that are full
+// again are removed.
+const maxIdleKeys = 4096
+
+// tokenBucket is a token bucket per key.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a limiter allowing rate requests per second per
+// key, with bursts of burst requests.
+func NewTokenBucket(rate float64, burst int) Limiter {
+	return newTokenBucket(rate, burst, time.Now)
+}
+
+func newTokenBucket(rate float64, burst int, now func() time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (l *tokenBucket) Allow(key string) (DoneFunc, error) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxIdleKeys {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+	if b.tokens < 1 {
+		retryAfter := time.Minute
+		if l.rate > 0 {
+			retryAfter = time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		}
+		return nil, Rejected(errcode.ErrTooManyRequests, retryAfter)
+	}
+	b.tokens--
+	return func() {}, nil
+}
+
+func (l *tokenBucket) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return math.Min(l.burst, b.tokens+elapsed*l.rate)
+}
+
+// sweep removes the buckets that are full again.
+func (l *tokenBucket) sweep(now time.Time) {
+	for k, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	cpuInterval = 500 * time.Millisecond
+	// cpuDecay is the weight of the previous usage in its EWMA
+	cpuDecay = 0.8
+)
+
+var (
+	cpuOnce  sync.Once
+	cpuUsage atomic.Int64
+)
+
+// CPUUsage returns the EWMA CPU usage of the process in permille of the
+// usable CPUs, sampled every 500ms from the first call.
+func CPUUsage() int64 {
+	cpuOnce.Do(func() {
+		go sampleCPU()
+	})
+	return cpuUsage.Load()
+}
+
+func sampleCPU() {
+	prev, ok := processCPUTime()
+	if !ok {
+		return
+	}
+	last := time.Now()
+	usage := 0.0
+	ticker := time.NewTicker(cpuInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		cur, ok := processCPUTime()
+		if !ok {
+			continue
+		}
+		wall := now.Sub(last) * time.Duration(runtime.GOMAXPROCS(0))
+		if wall > 0 {
+			sample := float64(cur-prev) / float64(wall) * 1000
+			usage = usage*cpuDecay + sample*(1-cpuDecay)
+			cpuUsage.Store(int64(usage))
+		}
+		prev, last = cur, now
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package ratelimit
+
+import (
+	"time"
+)
+
+// processCPUTime is not supported on Windows, the CPU usage is 0 unless
+// set with WithCPU.
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
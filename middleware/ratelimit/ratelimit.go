@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/transport"
+)
+
+// retryAfterKey is the metadata key of the retry-after hint of the
+// rejections, in seconds, it is also sent as the Retry-After reply header.
+const retryAfterKey = "retry-after"
+
+// DoneFunc is called when an allowed request is done.
+type DoneFunc func()
+
+// Limiter decides whether the requests of a key are allowed.
+type Limiter interface {
+	// Allow returns an error when the request is rejected, see Rejected.
+	Allow(key string) (DoneFunc, error)
+}
+
+// Rejected returns the error of a rejected request, err with the
+// retry-after hint in its metadata.
+func Rejected(err *errcode.Error, retryAfter time.Duration) *errcode.Error {
+	md := make(map[string]string, len(err.Metadata())+1)
+	for k, v := range err.Metadata() {
+		md[k] = v
+	}
+	md[retryAfterKey] = strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+	return err.WithMetadata(md)
+}
+
+// KeyFunc returns the key of the request limited.
+type KeyFunc func(ctx context.Context, req interface{}) string
+
+// Option is rate limit option.
+type Option func(*options)
+
+type options struct {
+	limiter Limiter
+	key     KeyFunc
+}
+
+// WithLimiter with the limiter, NewTokenBucket(100, 100) by default.
+func WithLimiter(l Limiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithKey with the key of the requests, OperationKey by default.
+func WithKey(f KeyFunc) Option {
+	return func(o *options) {
+		o.key = f
+	}
+}
+
+// OperationKey keys the requests by operation.
+func OperationKey(ctx context.Context, _ interface{}) string {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		return tr.Operation()
+	}
+	return ""
+}
+
+// HeaderKey returns a key of the value of the request header, e.g. a user
+// or an API key.
+func HeaderKey(name string) KeyFunc {
+	return func(ctx context.Context, _ interface{}) string {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return tr.RequestHeader().Get(name)
+		}
+		return ""
+	}
+}
+
+// PeerKey keys the requests by the IP of the peer.
+func PeerKey(ctx context.Context, _ interface{}) string {
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	} else if tr, ok := transport.FromServerContext(ctx); ok {
+		if r, ok := tr.(interface{ Request() *http.Request }); ok && r.Request() != nil {
+			addr = r.Request().RemoteAddr
+		}
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// Server is a server middleware that rejects the requests over the limits
+// of the limiter, with ResourceExhausted in gRPC and 429 in HTTP, and a
+// Retry-After reply header. Each Server has its own limits, so the
+// selectors of Use have their own limits:
+//
+//	srv.Use("/helloworld.Greeter/*", ratelimit.Server(ratelimit.WithLimiter(ratelimit.NewTokenBucket(10, 20))))
+//
+// The messages of streams are not limited.
+func Server(opts ...Option) middleware.Middleware {
+	o := options{key: OperationKey}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.limiter == nil {
+		o.limiter = NewTokenBucket(100, 100)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := middleware.FromMessageContext(ctx); ok {
+				return handler(ctx, req)
+			}
+			done, err := o.limiter.Allow(o.key(ctx, req))
+			if err != nil {
+				if e := errcode.FromError(err); e.Metadata()[retryAfterKey] != "" {
+					if tr, ok := transport.FromServerContext(ctx); ok {
+						tr.ReplyHeader().Set("Retry-After", e.Metadata()[retryAfterKey])
+					}
+				}
+				return nil, err
+			}
+			defer done()
+			return handler(ctx, req)
+		}
+	}
+}
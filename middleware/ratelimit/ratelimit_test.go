@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/transport"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string        { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type testTransport struct {
+	operation   string
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+	req         *http.Request
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *testTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+func (tr *testTransport) Request() *http.Request          { return tr.req }
+
+func newTransport(operation string) *testTransport {
+	return &testTransport{operation: operation, reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+}
+
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time      { return c.t }
+func (c *testClock) add(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestTokenBucket(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	l := newTokenBucket(2, 3, clock.now)
+	for i := 0; i < 3; i++ {
+		if _, err := l.Allow("a"); err != nil {
+			t.Fatalf("expect the burst allowed, got %v", err)
+		}
+	}
+	_, err := l.Allow("a")
+	if !errors.Is(err, errcode.ErrTooManyRequests) {
+		t.Fatalf("expect %v, got %v", errcode.ErrTooManyRequests, err)
+	}
+	if v := errcode.FromError(err).Metadata()[retryAfterKey]; v != "1" {
+		t.Errorf("expect retry after 1s, got %q", v)
+	}
+	// the keys have their own buckets
+	if _, err = l.Allow("b"); err != nil {
+		t.Errorf("expect another key allowed, got %v", err)
+	}
+	clock.add(500 * time.Millisecond)
+	if _, err = l.Allow("a"); err != nil {
+		t.Errorf("expect a token refilled, got %v", err)
+	}
+	if _, err = l.Allow("a"); err == nil {
+		t.Error("expect a single token refilled")
+	}
+}
+
+func TestTokenBucket_sweep(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	l := newTokenBucket(1, 1, clock.now)
+	for i := 0; i < maxIdleKeys; i++ {
+		_, _ = l.Allow(string(rune(i)))
+	}
+	clock.add(time.Second)
+	_, _ = l.Allow("new")
+	if len(l.buckets) != 1 {
+		t.Errorf("expect the full buckets removed, got %d keys", len(l.buckets))
+	}
+}
+
+func TestServer(t *testing.T) {
+	m := Server(WithLimiter(NewTokenBucket(0, 1)))
+	next := func(context.Context, interface{}) (interface{}, error) { return "reply", nil }
+
+	tr := newTransport("/a")
+	ctx := transport.NewServerContext(context.Background(), tr)
+	if _, err := m(next)(ctx, nil); err != nil {
+		t.Fatalf("expect the request allowed, got %v", err)
+	}
+	_, err := m(next)(ctx, nil)
+	e := errcode.FromError(err)
+	if e.GRPCCode() != codes.ResourceExhausted || e.StatusCode() != http.StatusTooManyRequests {
+		t.Errorf("expect ResourceExhausted and 429, got %s and %d", e.GRPCCode(), e.StatusCode())
+	}
+	if v := tr.ReplyHeader().Get("Retry-After"); v != "60" {
+		t.Errorf("expect Retry-After 60, got %q", v)
+	}
+	// the operations have their own limits
+	if _, err = m(next)(transport.NewServerContext(context.Background(), newTransport("/b")), nil); err != nil {
+		t.Errorf("expect another operation allowed, got %v", err)
+	}
+	// the messages of the streams are not limited
+	if _, err = m(next)(middleware.NewMessageContext(ctx, middleware.RecvMessage), nil); err != nil {
+		t.Errorf("expect the messages passed, got %v", err)
+	}
+}
+
+func TestHeaderKey(t *testing.T) {
+	tr := newTransport("/a")
+	tr.reqHeader.Set("X-Api-Key", "key")
+	if k := HeaderKey("x-api-key")(transport.NewServerContext(context.Background(), tr), nil); k != "key" {
+		t.Errorf("expect key, got %q", k)
+	}
+}
+
+func TestPeerKey(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+	if k := PeerKey(ctx, nil); k != "10.0.0.1" {
+		t.Errorf("expect 10.0.0.1, got %q", k)
+	}
+	tr := newTransport("/a")
+	tr.req = &http.Request{RemoteAddr: "10.0.0.2:1234"}
+	if k := PeerKey(transport.NewServerContext(context.Background(), tr), nil); k != "10.0.0.2" {
+		t.Errorf("expect 10.0.0.2, got %q", k)
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	clock := &testClock{t: time.Now()}
+	cpu := int64(0)
+	l := NewAdaptive(WithWindow(time.Second, 10), WithCPU(func() int64 { return cpu }))
+	a := l.(*adaptive)
+	a.now = clock.now
+	a.start = clock.now()
+
+	// 10 requests of 100ms per bucket of 100ms: 10 in flight at most
+	for i := 0; i < 10; i++ {
+		done, err := l.Allow("")
+		if err != nil {
+			t.Fatalf("expect the requests allowed, got %v", err)
+		}
+		clock.add(100 * time.Millisecond)
+		done()
+		clock.add(-100 * time.Millisecond)
+	}
+	var dones []DoneFunc
+	for i := 0; i < 20; i++ {
+		done, err := l.Allow("")
+		if err != nil {
+			t.Fatalf("expect the requests allowed under the CPU threshold, got %v", err)
+		}
+		dones = append(dones, done)
+	}
+
+	cpu = 900
+	_, err := l.Allow("")
+	if !errors.Is(err, errcode.ErrLimitExceed) {
+		t.Fatalf("expect %v, got %v", errcode.ErrLimitExceed, err)
+	}
+	if e := errcode.FromError(err); e.GRPCCode() != codes.ResourceExhausted || e.Metadata()[retryAfterKey] != "1" {
+		t.Errorf("expect ResourceExhausted retry after 1s, got %s and %q", e.GRPCCode(), e.Metadata()[retryAfterKey])
+	}
+	// the cool off after a shed
+	cpu = 0
+	if _, err = l.Allow(""); err == nil {
+		t.Error("expect the requests shed during the cool off")
+	}
+	for _, done := range dones[:15] {
+		done()
+	}
+	if _, err = l.Allow(""); err != nil {
+		t.Errorf("expect the requests under the limit allowed, got %v", err)
+	}
+}
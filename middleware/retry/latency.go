@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSamples is the number of the last latencies of an operation kept.
+	maxSamples = 100
+	// minSamples is the number of latencies of an operation below which its
+	// calls are not hedged.
+	minSamples = 10
+)
+
+// latencies keeps the last latencies of the successful calls per
+// operation.
+type latencies struct {
+	m sync.Map // operation -> *samples
+}
+
+type samples struct {
+	mu   sync.Mutex
+	ds   []time.Duration
+	next int
+}
+
+func (l *latencies) add(operation string, d time.Duration) {
+	v, _ := l.m.LoadOrStore(operation, &samples{})
+	s := v.(*samples)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.ds) < maxSamples {
+		s.ds = append(s.ds, d)
+		return
+	}
+	s.ds[s.next] = d
+	s.next = (s.next + 1) % maxSamples
+}
+
+// percentile returns the percentile p of the latencies of the operation,
+// false below minSamples latencies.
+func (l *latencies) percentile(operation string, p float64) (time.Duration, bool) {
+	v, ok := l.m.Load(operation)
+	if !ok {
+		return 0, false
+	}
+	s := v.(*samples)
+	s.mu.Lock()
+	ds := make([]time.Duration, len(s.ds))
+	copy(ds, s.ds)
+	s.mu.Unlock()
+	if len(ds) < minSamples {
+		return 0, false
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	i := int(math.Ceil(p*float64(len(ds)))) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(ds) {
+		i = len(ds) - 1
+	}
+	return ds[i], true
+}
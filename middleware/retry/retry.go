@@ -0,0 +1,244 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	pkgretry "github.com/apus-run/gaia/pkg/retry"
+	"github.com/apus-run/gaia/transport"
+)
+
+var (
+	// DefaultCodes are the gRPC codes retried by default.
+	DefaultCodes = []codes.Code{codes.Unavailable}
+	// DefaultStatuses are the HTTP statuses retried by default.
+	DefaultStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+)
+
+// Option is retry option.
+type Option func(*options)
+
+type options struct {
+	attempts   int
+	backoff    pkgretry.Backoff
+	codes      []codes.Code
+	statuses   []int
+	retryable  func(error) bool
+	percentile float64
+}
+
+// WithAttempts with the max attempts of a call, the first one and the
+// hedged ones included, 3 by default.
+func WithAttempts(n int) Option {
+	return func(o *options) {
+		o.attempts = n
+	}
+}
+
+// WithBackoff with the backoff between the attempts, an exponential backoff
+// with jitter by default, see retry.DefaultBackoff.
+func WithBackoff(b pkgretry.Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithCodes with the gRPC codes retried, DefaultCodes by default.
+func WithCodes(c ...codes.Code) Option {
+	return func(o *options) {
+		o.codes = c
+	}
+}
+
+// WithStatuses with the HTTP statuses retried, DefaultStatuses by default.
+func WithStatuses(statuses ...int) Option {
+	return func(o *options) {
+		o.statuses = statuses
+	}
+}
+
+// WithRetryable with the errors retried, instead of the codes and the
+// statuses.
+func WithRetryable(f func(error) bool) Option {
+	return func(o *options) {
+		o.retryable = f
+	}
+}
+
+// WithHedging sends hedged attempts of the calls that take longer than the
+// percentile of the latencies of their operation, e.g. 0.95, the first
+// successful attempt wins and the others are canceled. The calls must be
+// idempotent.
+func WithHedging(percentile float64) Option {
+	return func(o *options) {
+		o.percentile = percentile
+	}
+}
+
+// Client is a client middleware that retries the calls failing with a
+// retryable error, up to the max attempts and the deadline of the call:
+//
+//	grpc.WithMiddleware(retry.Client(retry.WithAttempts(3), retry.WithHedging(0.95)))
+//
+// The streams are not retried.
+func Client(opts ...Option) middleware.Middleware {
+	o := &options{
+		attempts: 3,
+		backoff:  pkgretry.DefaultBackoff,
+		codes:    DefaultCodes,
+		statuses: DefaultStatuses,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	lat := &latencies{}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := middleware.FromMessageContext(ctx); ok {
+				return handler(ctx, req)
+			}
+			if _, ok := req.(*middleware.StreamInfo); ok {
+				return handler(ctx, req)
+			}
+			var operation string
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				operation = tr.Operation()
+			}
+			if o.percentile > 0 {
+				if delay, ok := lat.percentile(operation, o.percentile); ok {
+					return o.hedge(ctx, req, handler, delay, func(d time.Duration) { lat.add(operation, d) })
+				}
+			}
+			n := 0
+			return pkgretry.DoValue(ctx, func(ctx context.Context) (interface{}, error) {
+				attempt := middleware.Attempt{Index: n}
+				n++
+				start := time.Now()
+				reply, err := handler(middleware.NewAttemptContext(ctx, attempt), req)
+				if err == nil && o.percentile > 0 {
+					lat.add(operation, time.Since(start))
+				}
+				return reply, err
+			},
+				pkgretry.WithAttempts(o.attempts),
+				pkgretry.WithBackoff(o.backoff),
+				pkgretry.WithRetryable(func(err error) bool { return o.shouldRetry(ctx, err) }),
+			)
+		}
+	}
+}
+
+// hedge sends an attempt every delay until one of them succeeds or fails
+// with an error that is not retryable, the failed attempts are retried
+// after the backoff when no other attempt is in flight.
+func (o *options) hedge(ctx context.Context, req interface{}, handler middleware.Handler, delay time.Duration, observe func(time.Duration)) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		reply   interface{}
+		err     error
+		elapsed time.Duration
+	}
+	results := make(chan result)
+	attempts, inflight := 0, 0
+	send := func() {
+		attempt := middleware.Attempt{Index: attempts, Hedged: true}
+		attempts++
+		inflight++
+		go func() {
+			start := time.Now()
+			reply, err := handler(middleware.NewAttemptContext(ctx, attempt), req)
+			select {
+			case results <- result{reply: reply, err: err, elapsed: time.Since(start)}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+	exhausted := func() bool {
+		return o.attempts > 0 && attempts >= o.attempts
+	}
+
+	send()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var lastErr error
+	for {
+		select {
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				observe(r.elapsed)
+				return r.reply, nil
+			}
+			if !o.shouldRetry(ctx, r.err) {
+				return r.reply, r.err
+			}
+			lastErr = r.err
+			if inflight > 0 {
+				continue
+			}
+			if exhausted() {
+				return nil, lastErr
+			}
+			backoff := o.backoff.Backoff(attempts)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+				return nil, lastErr
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(backoff)
+		case <-timer.C:
+			if !exhausted() {
+				send()
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, errcode.FromError(ctx.Err())
+		}
+	}
+}
+
+func (o *options) shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if o.retryable != nil {
+		return o.retryable(err)
+	}
+	if tr, ok := transport.FromClientContext(ctx); ok && tr.Kind() == transport.KindHTTP {
+		// the requests that failed to connect were not sent
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+		status := errcode.FromError(err).StatusCode()
+		for _, s := range o.statuses {
+			if s == status {
+				return true
+			}
+		}
+		return false
+	}
+	code := errcode.FromError(err).GRPCCode()
+	for _, c := range o.codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	pkgretry "github.com/apus-run/gaia/pkg/retry"
+	"github.com/apus-run/gaia/transport"
+)
+
+type testTransport struct {
+	kind      transport.Kind
+	operation string
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return nil }
+func (tr *testTransport) ReplyHeader() transport.Header   { return nil }
+
+func clientContext(kind transport.Kind) context.Context {
+	return transport.NewClientContext(context.Background(), &testTransport{kind: kind, operation: "/a"})
+}
+
+func TestClient(t *testing.T) {
+	m := Client(WithBackoff(pkgretry.Constant(time.Millisecond)))
+	tests := []struct {
+		name     string
+		kind     transport.Kind
+		err      error
+		attempts int
+	}{
+		{"unavailable", transport.KindGRPC, errcode.ErrServiceUnavailable, 3},
+		{"invalid", transport.KindGRPC, errcode.ErrInvalidParam, 1},
+		{"bad gateway", transport.KindHTTP, errcode.Newf(502, "BAD_GATEWAY", "bad gateway"), 3},
+		{"internal", transport.KindHTTP, errcode.Newf(500, "INTERNAL", "internal"), 1},
+		{"dial", transport.KindHTTP, &net.OpError{Op: "dial", Err: errors.New("refused")}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var indexes []int
+			_, err := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+				a, _ := middleware.FromAttemptContext(ctx)
+				indexes = append(indexes, a.Index)
+				return nil, tt.err
+			})(clientContext(tt.kind), nil)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expect %v, got %v", tt.err, err)
+			}
+			if len(indexes) != tt.attempts || indexes[len(indexes)-1] != tt.attempts-1 {
+				t.Errorf("expect %d attempts, got %v", tt.attempts, indexes)
+			}
+		})
+	}
+}
+
+func TestClient_success(t *testing.T) {
+	m := Client(WithAttempts(5), WithBackoff(pkgretry.Constant(time.Millisecond)))
+	n := 0
+	reply, err := m(func(context.Context, interface{}) (interface{}, error) {
+		if n++; n < 3 {
+			return nil, errcode.ErrServiceUnavailable
+		}
+		return "reply", nil
+	})(clientContext(transport.KindGRPC), nil)
+	if err != nil || reply != "reply" || n != 3 {
+		t.Errorf("expect the reply of the third attempt, got %v, %v after %d", reply, err, n)
+	}
+}
+
+func TestClient_deadline(t *testing.T) {
+	m := Client(WithAttempts(10), WithBackoff(pkgretry.Constant(time.Second)))
+	ctx, cancel := context.WithTimeout(clientContext(transport.KindGRPC), 100*time.Millisecond)
+	defer cancel()
+	n := 0
+	start := time.Now()
+	_, err := m(func(context.Context, interface{}) (interface{}, error) {
+		n++
+		return nil, errcode.ErrServiceUnavailable
+	})(ctx, nil)
+	if !errors.Is(err, errcode.ErrServiceUnavailable) || n != 1 {
+		t.Errorf("expect a single attempt before the deadline, got %v after %d", err, n)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Errorf("expect no wait for a retry after the deadline, got %s", time.Since(start))
+	}
+}
+
+func TestClient_stream(t *testing.T) {
+	m := Client()
+	n := 0
+	_, _ = m(func(context.Context, interface{}) (interface{}, error) {
+		n++
+		return nil, errcode.ErrServiceUnavailable
+	})(clientContext(transport.KindGRPC), &middleware.StreamInfo{})
+	if n != 1 {
+		t.Errorf("expect the streams not retried, got %d attempts", n)
+	}
+}
+
+func TestClient_hedging(t *testing.T) {
+	m := Client(WithHedging(0.9))
+	ctx := clientContext(transport.KindGRPC)
+	var slow atomic.Bool
+	var mu sync.Mutex
+	var attempts []middleware.Attempt
+	h := m(func(ctx context.Context, req interface{}) (interface{}, error) {
+		a, _ := middleware.FromAttemptContext(ctx)
+		mu.Lock()
+		attempts = append(attempts, a)
+		mu.Unlock()
+		if slow.Load() && a.Index == 0 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		time.Sleep(10 * time.Millisecond)
+		return a.Index, nil
+	})
+	for i := 0; i < minSamples; i++ {
+		if _, err := h(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slow.Store(true)
+	attempts = nil
+	start := time.Now()
+	reply, err := h(ctx, nil)
+	if err != nil || reply != 1 {
+		t.Fatalf("expect the reply of the hedged attempt, got %v, %v", reply, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("expect the hedged attempt after the latency percentile, got %s", time.Since(start))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 2 || !attempts[0].Hedged || !attempts[1].Hedged {
+		t.Errorf("expect 2 hedged attempts, got %v", attempts)
+	}
+}
+
+func TestClient_hedgingFailures(t *testing.T) {
+	m := Client(WithHedging(0.5), WithAttempts(3), WithBackoff(pkgretry.Constant(time.Millisecond)))
+	ctx := clientContext(transport.KindGRPC)
+	var fail atomic.Bool
+	var n atomic.Int32
+	h := m(func(context.Context, interface{}) (interface{}, error) {
+		n.Add(1)
+		if fail.Load() {
+			return nil, errcode.ErrServiceUnavailable
+		}
+		return nil, nil
+	})
+	for i := 0; i < minSamples; i++ {
+		_, _ = h(ctx, nil)
+	}
+	fail.Store(true)
+	n.Store(0)
+	if _, err := h(ctx, nil); !errors.Is(err, errcode.ErrServiceUnavailable) {
+		t.Errorf("expect %v, got %v", errcode.ErrServiceUnavailable, err)
+	}
+	if n.Load() != 3 {
+		t.Errorf("expect 3 attempts, got %d", n.Load())
+	}
+}
+
+func TestLatencies(t *testing.T) {
+	l := &latencies{}
+	if _, ok := l.percentile("/a", 0.5); ok {
+		t.Error("expect no percentile without latencies")
+	}
+	for i := 1; i <= maxSamples+10; i++ {
+		l.add("/a", time.Duration(i)*time.Millisecond)
+	}
+	if d, _ := l.percentile("/a", 0.5); d != 60*time.Millisecond {
+		t.Errorf("expect 60ms, got %s", d)
+	}
+	if d, _ := l.percentile("/a", 1); d != 110*time.Millisecond {
+		t.Errorf("expect 110ms, got %s", d)
+	}
+}
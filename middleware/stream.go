@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+)
+
+// StreamInfo is the request of the middleware of a streaming call, they run
+// once per stream with a *StreamInfo request, then once per message with
+// the message as request, see FromMessageContext.
+type StreamInfo struct {
+	// ClientStream is true when the client sends a stream of messages.
+	ClientStream bool
+	// ServerStream is true when the server sends a stream of messages.
+	ServerStream bool
+}
+
+// MessageKind is the direction of a message of a stream.
+type MessageKind int
+
+const (
+	// RecvMessage is a message received from the peer, the middleware run
+	// after it is received.
+	RecvMessage MessageKind = iota + 1
+	// SendMessage is a message sent to the peer, the middleware run before
+	// it is sent.
+	SendMessage
+)
+
+func (k MessageKind) String() string {
+	switch k {
+	case RecvMessage:
+		return "recv"
+	case SendMessage:
+		return "send"
+	}
+	return "unknown"
+}
+
+type messageKey struct{}
+
+// NewMessageContext returns a new Context of a message of a stream.
+func NewMessageContext(ctx context.Context, kind MessageKind) context.Context {
+	return context.WithValue(ctx, messageKey{}, kind)
+}
+
+// FromMessageContext returns the direction of the message when the
+// middleware runs for a message of a stream, middleware that run once per
+// call skip messages with it.
+func FromMessageContext(ctx context.Context) (MessageKind, bool) {
+	kind, ok := ctx.Value(messageKey{}).(MessageKind)
+	return kind, ok
+}
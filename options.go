@@ -10,6 +10,8 @@ import (
 	"github.com/apus-run/sea-kit/log"
 	"github.com/google/uuid"
 
+	"github.com/apus-run/gaia/config"
+	"github.com/apus-run/gaia/health"
 	"github.com/apus-run/gaia/registry"
 	"github.com/apus-run/gaia/transport"
 )
@@ -31,9 +33,21 @@ type options struct {
 	registry        registry.Registry
 	registryTimeout time.Duration
 	stopTimeout     time.Duration
+	hookTimeout     time.Duration
+	readyTimeout    time.Duration
+	drainDelay      time.Duration
+	restartTimeout  time.Duration
+	restartSigs     []os.Signal
 	servers         []transport.Server
+	components      []*component
 
-	logger log.Logger
+	health           *health.Health
+	healthDeregister bool
+	healthMetadata   string
+
+	logger    log.Logger
+	adminAddr string
+	config    config.Config
 
 	// Before and After funcs
 	beforeStart []func(context.Context) error
@@ -49,6 +63,9 @@ func defaultOptions() *options {
 		sigs:            []os.Signal{syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT},
 		registryTimeout: 10 * time.Second,
 		stopTimeout:     10 * time.Second,
+		hookTimeout:     10 * time.Second,
+		readyTimeout:    10 * time.Second,
+		restartTimeout:  30 * time.Second,
 	}
 }
 
@@ -126,6 +143,24 @@ func WithLogger(logger log.Logger) Option {
 	}
 }
 
+// WithConfig with the application config, which is then reachable through
+// FromContext. The caller loads and closes it.
+func WithConfig(c config.Config) Option {
+	return func(o *options) {
+		o.config = c
+	}
+}
+
+// WithAdmin with the address of the admin server, which serves pprof,
+// probes, build info, the service instance and, with WithLogger, the
+// runtime log level.
+// The admin server is never registered in the service registry.
+func WithAdmin(addr string) Option {
+	return func(o *options) {
+		o.adminAddr = addr
+	}
+}
+
 // WithServer with a server , http or grpc
 func WithServer(srv ...transport.Server) Option {
 	return func(o *options) {
@@ -133,6 +168,42 @@ func WithServer(srv ...transport.Server) Option {
 	}
 }
 
+// WithComponent with a named component that starts after the components it
+// depends on and stops before them. Components start before the servers and
+// stop after them. New panics on duplicate names, unknown dependencies and
+// dependency cycles.
+func WithComponent(name string, c Component, dependsOn ...string) Option {
+	return func(o *options) {
+		o.components = append(o.components, &component{name: name, c: c, deps: dependsOn})
+	}
+}
+
+// WithHealth with the health aggregator of the application. It starts
+// after every other component, before the servers, and is reported by the
+// admin probes.
+func WithHealth(h *health.Health) Option {
+	return func(o *options) {
+		o.health = h
+	}
+}
+
+// WithHealthDeregister deregisters the instance while the health aggregator
+// reports it is not ready, and registers it again once it is ready.
+func WithHealthDeregister() Option {
+	return func(o *options) {
+		o.healthDeregister = true
+	}
+}
+
+// WithHealthMetadata with the instance metadata key that holds the readiness
+// of the health aggregator, "up" or "down". The instance is registered again
+// whenever it changes.
+func WithHealthMetadata(key string) Option {
+	return func(o *options) {
+		o.healthMetadata = key
+	}
+}
+
 // WithRegistryTimeout with registrar timeout.
 func WithRegistryTimeout(t time.Duration) Option {
 	return func(o *options) {
@@ -147,6 +218,52 @@ func WithStopTimeout(t time.Duration) Option {
 	}
 }
 
+// WithHookTimeout with the timeout of each before and after hook,
+// zero means no timeout.
+func WithHookTimeout(t time.Duration) Option {
+	return func(o *options) {
+		o.hookTimeout = t
+	}
+}
+
+// WithReadyTimeout with the time to wait for servers to be ready before
+// registering, zero means wait until they are ready or fail.
+func WithReadyTimeout(t time.Duration) Option {
+	return func(o *options) {
+		o.readyTimeout = t
+	}
+}
+
+// WithDrainDelay with the time to wait between deregistering and stopping
+// the servers, so that peers stop routing to this instance first.
+func WithDrainDelay(t time.Duration) Option {
+	return func(o *options) {
+		o.drainDelay = t
+	}
+}
+
+// WithGracefulRestart enables graceful restart on sigs, SIGUSR2 and SIGHUP
+// by default. The executable is started again and inherits the listener of
+// every server, this process drains and stops once the new one is ready.
+// Graceful restart is not supported on windows, where it is disabled unless
+// sigs are given.
+func WithGracefulRestart(sigs ...os.Signal) Option {
+	return func(o *options) {
+		if len(sigs) == 0 {
+			sigs = restartSignals
+		}
+		o.restartSigs = sigs
+	}
+}
+
+// WithRestartTimeout with the time to wait for the new process to be ready
+// on graceful restart.
+func WithRestartTimeout(t time.Duration) Option {
+	return func(o *options) {
+		o.restartTimeout = t
+	}
+}
+
 // Before and Afters
 
 // BeforeStart run funcs before app starts
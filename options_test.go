@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/apus-run/gaia/config"
+	"github.com/apus-run/gaia/health"
 	"github.com/apus-run/gaia/registry"
 	xlog "github.com/apus-run/sea-kit/log"
 )
@@ -146,6 +148,55 @@ func TestStopTimeout(t *testing.T) {
 	}
 }
 
+func TestHookTimeout(t *testing.T) {
+	o := &options{}
+	v := time.Duration(123)
+	WithHookTimeout(v)(o)
+	if !reflect.DeepEqual(v, o.hookTimeout) {
+		t.Fatal("o.hookTimeout is not equal to v")
+	}
+}
+
+func TestReadyTimeout(t *testing.T) {
+	o := &options{}
+	v := time.Duration(123)
+	WithReadyTimeout(v)(o)
+	if !reflect.DeepEqual(v, o.readyTimeout) {
+		t.Fatal("o.readyTimeout is not equal to v")
+	}
+}
+
+func TestDrainDelay(t *testing.T) {
+	o := &options{}
+	v := time.Duration(123)
+	WithDrainDelay(v)(o)
+	if !reflect.DeepEqual(v, o.drainDelay) {
+		t.Fatal("o.drainDelay is not equal to v")
+	}
+}
+
+func TestGracefulRestart(t *testing.T) {
+	o := &options{}
+	WithGracefulRestart()(o)
+	if !reflect.DeepEqual(restartSignals, o.restartSigs) {
+		t.Fatal("o.restartSigs is not equal to the default restart signals")
+	}
+	v := []os.Signal{&mockSignal{}}
+	WithGracefulRestart(v...)(o)
+	if !reflect.DeepEqual(v, o.restartSigs) {
+		t.Fatal("o.restartSigs is not equal to v")
+	}
+}
+
+func TestRestartTimeout(t *testing.T) {
+	o := &options{}
+	v := time.Duration(123)
+	WithRestartTimeout(v)(o)
+	if !reflect.DeepEqual(v, o.restartTimeout) {
+		t.Fatal("o.restartTimeout is not equal to v")
+	}
+}
+
 func TestBeforeStart(t *testing.T) {
 	o := &options{}
 	v := func(_ context.Context) error {
@@ -181,3 +232,42 @@ func TestAfterStop(t *testing.T) {
 	}
 	AfterStop(v)(o)
 }
+
+func TestAdmin(t *testing.T) {
+	o := &options{}
+	v := "127.0.0.1:9090"
+	WithAdmin(v)(o)
+	if !reflect.DeepEqual(v, o.adminAddr) {
+		t.Fatalf("o.adminAddr:%s is not equal to v:%s", o.adminAddr, v)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	o := &options{}
+	v := config.New()
+	WithConfig(v)(o)
+	if !reflect.DeepEqual(v, o.config) {
+		t.Fatalf("o.config:%v is not equal to v:%v", o.config, v)
+	}
+}
+
+func TestComponent(t *testing.T) {
+	o := &options{}
+	c := &mockComponent{name: "db"}
+	WithComponent("db", c, "config")(o)
+	want := []*component{{name: "db", c: c, deps: []string{"config"}}}
+	if !reflect.DeepEqual(want, o.components) {
+		t.Fatalf("o.components:%v is not equal to %v", o.components, want)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	o := &options{}
+	v := health.New()
+	WithHealth(v)(o)
+	WithHealthDeregister()(o)
+	WithHealthMetadata("health")(o)
+	if !reflect.DeepEqual(v, o.health) || !o.healthDeregister || o.healthMetadata != "health" {
+		t.Fatalf("unexpected health options %v %v %v", o.health, o.healthDeregister, o.healthMetadata)
+	}
+}
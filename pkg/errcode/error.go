@@ -0,0 +1,287 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	httpstatus "github.com/apus-run/gaia/transport/http/status"
+)
+
+// Error 返回错误码和消息的结构体
+//
+// Error is the error of both transports. It carries a business code, an
+// HTTP status and a gRPC code, a machine-readable reason, a message,
+// details, metadata and a wrapped cause. It converts to and from a gRPC
+// status without loss, see GRPCStatus and FromError.
+type Error struct {
+	code     int
+	msg      string
+	details  []string
+	reason   string
+	domain   string
+	metadata map[string]string
+	cause    error
+
+	// status and grpcCode are derived from code unless set
+	status      int
+	grpcCode    codes.Code
+	hasGRPCCode bool
+}
+
+// NewError create a error in the global domain of DefaultRegistry, it
+// panics with both definition sites when the code is already defined.
+func NewError(code int, msg string) *Error {
+	e, err := globalDomain.register(code, 0, "", msg, 1)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Newf returns an error with an HTTP status and a reason, its business code
+// is the HTTP status and its gRPC code the one of the HTTP status.
+func Newf(status int, reason, format string, a ...interface{}) *Error {
+	return &Error{
+		code:   status,
+		msg:    fmt.Sprintf(format, a...),
+		reason: reason,
+		status: status,
+	}
+}
+
+// Errorf returns an error with an HTTP status and a reason, see Newf.
+func Errorf(status int, reason, format string, a ...interface{}) error {
+	return Newf(status, reason, format, a...)
+}
+
+// Error return a error string
+func (e *Error) Error() string {
+	s := fmt.Sprintf("code: %d, msg: %s", e.Code(), e.Msg())
+	if e.reason != "" {
+		s = fmt.Sprintf("code: %d, reason: %s, msg: %s", e.Code(), e.reason, e.Msg())
+	}
+	if len(e.details) > 0 {
+		s += fmt.Sprintf(", details: %v", e.details)
+	}
+	if e.cause != nil {
+		s += fmt.Sprintf(", cause: %v", e.cause)
+	}
+	return s
+}
+
+// Code return error code
+func (e *Error) Code() int {
+	return e.code
+}
+
+// Msg return error msg
+func (e *Error) Msg() string {
+	return e.msg
+}
+
+// Msgf format error string
+func (e *Error) Msgf(args []interface{}) string {
+	return fmt.Sprintf(e.msg, args...)
+}
+
+// Details return more error details
+func (e *Error) Details() []string {
+	return e.details
+}
+
+// Reason returns the machine-readable reason of the error.
+func (e *Error) Reason() string {
+	return e.reason
+}
+
+// Domain returns the domain of the error code, see Registry.
+func (e *Error) Domain() string {
+	return e.domain
+}
+
+// Metadata returns the metadata of the error.
+func (e *Error) Metadata() map[string]string {
+	return e.metadata
+}
+
+// StatusCode returns the HTTP status of the error.
+func (e *Error) StatusCode() int {
+	if e.status != 0 {
+		return e.status
+	}
+	return ToHTTPStatusCode(e.code)
+}
+
+// GRPCCode returns the gRPC code of the error.
+func (e *Error) GRPCCode() codes.Code {
+	if e.hasGRPCCode {
+		return e.grpcCode
+	}
+	if e.status != 0 {
+		return httpstatus.DefaultConverter.ToGRPCCode(e.status)
+	}
+	return ToRPCCode(e.code)
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors with the same domain, code and reason.
+func (e *Error) Is(err error) bool {
+	if t := new(Error); errors.As(err, &t) {
+		return t.domain == e.domain && t.code == e.code && t.reason == e.reason
+	}
+	return false
+}
+
+// WithDetails return err with detail
+func (e *Error) WithDetails(details ...string) *Error {
+	newError := *e
+	newError.details = []string{}
+	newError.details = append(newError.details, details...)
+
+	return &newError
+}
+
+// WithCode returns a copy of the error with the business code, which is
+// not registered.
+func (e *Error) WithCode(code int) *Error {
+	newError := *e
+	newError.code = code
+	return &newError
+}
+
+// WithDomain returns a copy of the error with the domain, which is not
+// registered.
+func (e *Error) WithDomain(domain string) *Error {
+	newError := *e
+	newError.domain = domain
+	return &newError
+}
+
+// WithReason returns a copy of the error with the reason.
+func (e *Error) WithReason(reason string) *Error {
+	newError := *e
+	newError.reason = reason
+	return &newError
+}
+
+// WithMetadata returns a copy of the error with the metadata.
+func (e *Error) WithMetadata(md map[string]string) *Error {
+	newError := *e
+	newError.metadata = md
+	return &newError
+}
+
+// WithCause returns a copy of the error wrapping the cause.
+func (e *Error) WithCause(cause error) *Error {
+	newError := *e
+	newError.cause = cause
+	return &newError
+}
+
+// GRPCStatus returns the gRPC status of the error. The reason and the
+// metadata are sent as an errdetails.ErrorInfo, the business code, the
+// HTTP status and the details as a structpb.Struct, the domain as the
+// domain of the ErrorInfo.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.GRPCCode(), e.msg)
+	details := make([]interface{}, 0, len(e.details))
+	for _, d := range e.details {
+		details = append(details, d)
+	}
+	extra, err := structpb.NewStruct(map[string]interface{}{
+		"code":    e.code,
+		"status":  e.StatusCode(),
+		"details": details,
+	})
+	if err != nil {
+		return st
+	}
+	if s, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.reason, Domain: e.domain, Metadata: e.metadata}, extra); err == nil {
+		return s
+	}
+	return st
+}
+
+// FromError converts an error into an *Error:
+//   - an *Error it wraps is returned as is,
+//   - a gRPC status is converted back, see GRPCStatus,
+//   - a context error becomes ErrDeadlineExceeded or a canceled error,
+//   - any other error becomes ErrInternalServer with the error message.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if e := new(Error); errors.As(err, &e) {
+		return e
+	}
+	if e := new(Err); errors.As(err, &e) {
+		return &Error{code: e.Code, msg: e.Msg, cause: e.Err}
+	}
+	if st, ok := status.FromError(err); ok {
+		return fromStatus(st)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrDeadlineExceeded.WithCause(err)
+	case errors.Is(err, context.Canceled):
+		return FromError(status.FromContextError(err).Err()).WithCause(err)
+	}
+	return &Error{code: ErrInternalServer.code, msg: err.Error(), cause: err}
+}
+
+func fromStatus(st *status.Status) *Error {
+	e := &Error{
+		code:        httpstatus.DefaultConverter.FromGRPCCode(st.Code()),
+		msg:         st.Message(),
+		grpcCode:    st.Code(),
+		hasGRPCCode: true,
+	}
+	e.status = e.code
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			e.reason = d.Reason
+			e.domain = d.Domain
+			e.metadata = d.Metadata
+		case *structpb.Struct:
+			fields := d.GetFields()
+			if v, ok := fields["code"]; ok {
+				e.code = int(v.GetNumberValue())
+			}
+			if v, ok := fields["status"]; ok {
+				e.status = int(v.GetNumberValue())
+			}
+			for _, v := range fields["details"].GetListValue().GetValues() {
+				e.details = append(e.details, v.GetStringValue())
+			}
+		}
+	}
+	return e
+}
+
+// Code returns the business code of an error, ErrInternalServer's for
+// errors that are not *Error, and Success's for nil.
+func Code(err error) int {
+	if err == nil {
+		return Success.code
+	}
+	return FromError(err).code
+}
+
+// Reason returns the reason of an error, if any.
+func Reason(err error) string {
+	if err == nil {
+		return ""
+	}
+	return FromError(err).reason
+}
@@ -0,0 +1,141 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestError_GRPCStatus(t *testing.T) {
+	err := ErrNotFound.
+		WithReason("USER_NOT_FOUND").
+		WithMetadata(map[string]string{"id": "1"}).
+		WithDetails("user 1")
+
+	st := err.GRPCStatus()
+	if st.Code() != codes.NotFound || st.Message() != ErrNotFound.Msg() {
+		t.Fatalf("unexpected status %v", st)
+	}
+
+	e := FromError(st.Err())
+	if e.Code() != ErrNotFound.Code() || e.Reason() != "USER_NOT_FOUND" || e.Msg() != ErrNotFound.Msg() {
+		t.Errorf("expected %v got %v", err, e)
+	}
+	if e.StatusCode() != http.StatusNotFound || e.GRPCCode() != codes.NotFound {
+		t.Errorf("expected 404 and NotFound got %d and %s", e.StatusCode(), e.GRPCCode())
+	}
+	if !reflect.DeepEqual(e.Metadata(), err.Metadata()) || !reflect.DeepEqual(e.Details(), err.Details()) {
+		t.Errorf("expected %v %v got %v %v", err.Metadata(), err.Details(), e.Metadata(), e.Details())
+	}
+	if !errors.Is(e, err) {
+		t.Errorf("expected %v to match %v", e, err)
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", ErrNotFound.WithReason("USER_NOT_FOUND").WithDetails("user 1"))
+	if !errors.Is(err, ErrNotFound.WithReason("USER_NOT_FOUND")) {
+		t.Errorf("expected %v to match the reason", err)
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInternalServer.WithReason("USER_NOT_FOUND")) {
+		t.Errorf("expected %v not to match another code or reason", err)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Reason() != "USER_NOT_FOUND" {
+		t.Errorf("expected an *Error got %v", err)
+	}
+	if Code(err) != ErrNotFound.Code() || Reason(err) != "USER_NOT_FOUND" {
+		t.Errorf("unexpected code %d or reason %q", Code(err), Reason(err))
+	}
+}
+
+func TestError_Cause(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := ErrInternalServer.WithCause(cause)
+	if !errors.Is(err, cause) || errors.Unwrap(err) != cause {
+		t.Errorf("expected %v to wrap %v", err, cause)
+	}
+}
+
+func TestNewf(t *testing.T) {
+	err := Newf(http.StatusTooManyRequests, "RATE_LIMITED", "limit %d", 10)
+	if err.Code() != http.StatusTooManyRequests || err.Msg() != "limit 10" || err.Reason() != "RATE_LIMITED" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err.StatusCode() != http.StatusTooManyRequests || err.GRPCCode() != codes.ResourceExhausted {
+		t.Errorf("expected 429 and ResourceExhausted got %d and %s", err.StatusCode(), err.GRPCCode())
+	}
+}
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   int
+		status int
+	}{
+		{context.DeadlineExceeded, ErrDeadlineExceeded.Code(), http.StatusGatewayTimeout},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), ErrDeadlineExceeded.Code(), http.StatusGatewayTimeout},
+		{errors.New("boom"), ErrInternalServer.Code(), http.StatusInternalServerError},
+		{status.Error(codes.Unavailable, "down"), http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		{&Err{Code: ErrUnauthorized.Code(), Msg: "unauthorized"}, ErrUnauthorized.Code(), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		e := FromError(tt.err)
+		if e.Code() != tt.code || e.StatusCode() != tt.status {
+			t.Errorf("%v: expected %d %d got %d %d", tt.err, tt.code, tt.status, e.Code(), e.StatusCode())
+		}
+	}
+	if !errors.Is(FromError(context.DeadlineExceeded), context.DeadlineExceeded) {
+		t.Error("expected the context error as cause")
+	}
+	if FromError(nil) != nil {
+		t.Error("expected nil")
+	}
+}
+
+func TestError_Domain(t *testing.T) {
+	d, _ := NewRegistry().Domain("user", 20000, 20999)
+	err := d.New(20001, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+
+	e := FromError(err.GRPCStatus().Err())
+	if e.Domain() != "user" || !errors.Is(e, err) {
+		t.Errorf("expected %v got %v", err, e)
+	}
+	if errors.Is(e, err.WithDomain("order")) {
+		t.Errorf("expected %v not to match another domain", e)
+	}
+}
+
+func TestError_StatusCodes(t *testing.T) {
+	tests := []struct {
+		err    *Error
+		status int
+		code   codes.Code
+	}{
+		{Success, http.StatusOK, codes.OK},
+		{ErrInternalServer, http.StatusInternalServerError, codes.Internal},
+		{ErrInvalidParam, http.StatusBadRequest, codes.InvalidArgument},
+		{ErrValidation, http.StatusBadRequest, codes.InvalidArgument},
+		{ErrInvalidTransaction, http.StatusBadRequest, codes.InvalidArgument},
+		{ErrToken, http.StatusUnauthorized, codes.Unauthenticated},
+		{ErrAccessDenied, http.StatusForbidden, codes.PermissionDenied},
+		{ErrNotFound, http.StatusNotFound, codes.NotFound},
+		{ErrTooManyRequests, http.StatusTooManyRequests, codes.ResourceExhausted},
+		{ErrDeadlineExceeded, http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{ErrServiceUnavailable, http.StatusServiceUnavailable, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		if got := ToHTTPStatusCode(tt.err.Code()); got != tt.status {
+			t.Errorf("%s: expected status %d got %d", tt.err.Msg(), tt.status, got)
+		}
+		if got := ToRPCCode(tt.err.Code()); got != tt.code {
+			t.Errorf("%s: expected code %s got %s", tt.err.Msg(), tt.code, got)
+		}
+	}
+}
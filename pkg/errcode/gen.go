@@ -0,0 +1,3 @@
+package errcode
+
+//go:generate protoc -I ../../third_party --go_out=. --go_opt=module=github.com/apus-run/gaia/pkg/errcode errcode/errors.proto
@@ -5,13 +5,21 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
+
+	httpstatus "github.com/apus-run/gaia/transport/http/status"
 )
 
+// GrpcStatus is a gRPC status with details.
+//
+// Deprecated: use Error, which is converted to a gRPC status by GRPCStatus.
 type GrpcStatus struct {
 	status  *status.Status
 	details []proto.Message
 }
 
+// New returns a gRPC status.
+//
+// Deprecated: use NewError or Newf.
 func New(code codes.Code, msg string) *GrpcStatus {
 	return &GrpcStatus{
 		status: status.New(code, msg),
@@ -42,28 +50,30 @@ func NewDetails(details map[string]interface{}) proto.Message {
 
 // ToRPCCode 自定义错误码转换为RPC识别的错误码，避免返回Unknown状态码
 func ToRPCCode(code int) codes.Code {
-	var statusCode codes.Code
-
 	switch code {
-	case ErrInternalServer.code:
-		statusCode = codes.Internal
-	case ErrInvalidParam.code:
-		statusCode = codes.InvalidArgument
-	case ErrUnauthorized.code:
-		statusCode = codes.Unauthenticated
+	case Success.code:
+		return codes.OK
+	case ErrInternalServer.code, ErrDatabase.code, ErrEncrypt.code:
+		return codes.Internal
+	case ErrInvalidParam.code, ErrSignParam.code, ErrValidation.code, ErrInvalidTransaction.code:
+		return codes.InvalidArgument
+	case ErrUnauthorized.code, ErrToken.code, ErrInvalidToken.code, ErrTokenTimeout.code:
+		return codes.Unauthenticated
 	case ErrNotFound.code:
-		statusCode = codes.NotFound
+		return codes.NotFound
 	case ErrDeadlineExceeded.code:
-		statusCode = codes.DeadlineExceeded
+		return codes.DeadlineExceeded
 	case ErrAccessDenied.code:
-		statusCode = codes.PermissionDenied
-	case ErrLimitExceed.code:
-		statusCode = codes.ResourceExhausted
+		return codes.PermissionDenied
+	case ErrLimitExceed.code, ErrTooManyRequests.code:
+		return codes.ResourceExhausted
 	case ErrMethodNotAllowed.code:
-		statusCode = codes.Unimplemented
-	default:
-		statusCode = codes.Unknown
+		return codes.Unimplemented
+	case ErrServiceUnavailable.code:
+		return codes.Unavailable
 	}
-
-	return statusCode
+	if code >= 100 && code <= 599 {
+		return httpstatus.DefaultConverter.ToGRPCCode(code)
+	}
+	return codes.Unknown
 }
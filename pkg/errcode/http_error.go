@@ -5,78 +5,34 @@ import (
 	"net/http"
 )
 
-// Error 返回错误码和消息的结构体
-type Error struct {
-	code    int
-	msg     string
-	details []string
-}
-
-var errorCodes = map[int]struct{}{}
-
-// NewError create a error
-func NewError(code int, msg string) *Error {
-	if _, ok := errorCodes[code]; ok {
-		panic(fmt.Sprintf("code %d is exsit, please change one", code))
-	}
-	errorCodes[code] = struct{}{}
-	return &Error{code: code, msg: msg}
-}
-
-// Error return a error string
-func (e *Error) Error() string {
-	return fmt.Sprintf("code: %d, msg: %s", e.Code(), e.Msg())
-}
-
-// Code return error code
-func (e *Error) Code() int {
-	return e.code
-}
-
-// Msg return error msg
-func (e *Error) Msg() string {
-	return e.msg
-}
-
-// Msgf format error string
-func (e *Error) Msgf(args []interface{}) string {
-	return fmt.Sprintf(e.msg, args...)
-}
-
-// Details return more error details
-func (e *Error) Details() []string {
-	return e.details
-}
-
-// WithDetails return err with detail
-func (e *Error) WithDetails(details ...string) *Error {
-	newError := *e
-	newError.details = []string{}
-	newError.details = append(newError.details, details...)
-
-	return &newError
-}
-
 // ToHTTPStatusCode convert custom error code to http status code and avoid return unknown status code.
+// Codes of errors created with Newf are HTTP statuses already.
 func ToHTTPStatusCode(code int) int {
 	switch code {
 	case Success.Code():
 		return http.StatusOK
-	case ErrInternalServer.Code():
+	case ErrInternalServer.Code(), ErrDatabase.Code(), ErrEncrypt.Code():
 		return http.StatusInternalServerError
-	case ErrInvalidParam.Code():
+	case ErrInvalidParam.Code(), ErrSignParam.Code(), ErrValidation.Code(), ErrInvalidTransaction.Code():
 		return http.StatusBadRequest
-	case ErrToken.Code():
-		fallthrough
-	case ErrInvalidToken.Code():
-		fallthrough
-	case ErrTokenTimeout.Code():
+	case ErrUnauthorized.Code(), ErrToken.Code(), ErrInvalidToken.Code(), ErrTokenTimeout.Code():
 		return http.StatusUnauthorized
-	case ErrTooManyRequests.Code():
+	case ErrAccessDenied.Code():
+		return http.StatusForbidden
+	case ErrNotFound.Code():
+		return http.StatusNotFound
+	case ErrMethodNotAllowed.Code():
+		return http.StatusMethodNotAllowed
+	case ErrLimitExceed.Code(), ErrTooManyRequests.Code():
 		return http.StatusTooManyRequests
+	case ErrDeadlineExceeded.Code():
+		return http.StatusGatewayTimeout
 	case ErrServiceUnavailable.Code():
 		return http.StatusServiceUnavailable
 	}
+	if code >= 100 && code <= 599 {
+		return code
+	}
 
 	return http.StatusInternalServerError
 }
@@ -98,14 +54,6 @@ func DecodeErr(err error) (int, string) {
 	if err == nil {
 		return Success.code, Success.msg
 	}
-
-	switch typed := err.(type) {
-	case *Err:
-		return typed.Code, typed.Msg
-	case *Error:
-		return typed.code, typed.msg
-	default:
-	}
-
-	return ErrInternalServer.Code(), err.Error()
+	e := FromError(err)
+	return e.code, e.msg
 }
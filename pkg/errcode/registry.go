@@ -0,0 +1,261 @@
+package errcode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultRegistry is the registry of NewError and NewDomain.
+var DefaultRegistry = NewRegistry()
+
+// globalDomain is the unnamed domain of the errors created with NewError.
+var globalDomain = DefaultRegistry.mustDomain("", 0, 0, 0)
+
+// Registry is a set of error code domains. Codes are unique within a
+// domain, so services can import libraries that define the same codes in
+// their own domains.
+//
+//	var user = errcode.NewDomain("user", 20000, 20999)
+//
+//	var ErrUserNotFound = user.New(20001, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+type Registry struct {
+	mu      sync.RWMutex
+	domains map[string]*Domain
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{domains: make(map[string]*Domain)}
+}
+
+// Domain is a namespace of error codes, with an optional code range.
+type Domain struct {
+	name     string
+	min, max int
+	site     string
+	reg      *Registry
+	codes    map[int]*entry
+}
+
+type entry struct {
+	err  *Error
+	site string
+}
+
+// CollisionError is returned when a domain or a code is defined twice, or
+// when the code ranges of two domains overlap. Site and Other are the
+// file:line of both definitions.
+type CollisionError struct {
+	Domain string
+	// IsDomain reports a collision of domains, Code is then unset.
+	IsDomain bool
+	Code     int
+	Site     string
+	Other    string
+}
+
+func (e *CollisionError) Error() string {
+	if e.IsDomain {
+		return fmt.Sprintf("errcode: domain %q defined at %s collides with the domain defined at %s", e.Domain, e.Site, e.Other)
+	}
+	return fmt.Sprintf("errcode: code %d of domain %q defined at %s is already defined at %s", e.Code, e.Domain, e.Site, e.Other)
+}
+
+// NewDomain registers a domain in DefaultRegistry, it panics on collisions.
+// The range min-max bounds the codes of the domain, 0-0 leaves it unbounded.
+func NewDomain(name string, min, max int) *Domain {
+	return DefaultRegistry.mustDomain(name, min, max, 1)
+}
+
+// Domain registers a domain. It returns a *CollisionError when the name is
+// already registered or the range overlaps the range of another domain.
+func (r *Registry) Domain(name string, min, max int) (*Domain, error) {
+	return r.domain(name, min, max, 1)
+}
+
+func (r *Registry) mustDomain(name string, min, max, skip int) *Domain {
+	d, err := r.domain(name, min, max, skip+1)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (r *Registry) domain(name string, min, max, skip int) (*Domain, error) {
+	if min > max {
+		return nil, fmt.Errorf("errcode: invalid range %d-%d of domain %q", min, max, name)
+	}
+	d := &Domain{
+		name:  name,
+		min:   min,
+		max:   max,
+		site:  caller(skip + 1),
+		reg:   r,
+		codes: make(map[int]*entry),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.domains {
+		if o.name == name || (d.ranged() && o.ranged() && d.min <= o.max && o.min <= d.max) {
+			return nil, &CollisionError{Domain: name, IsDomain: true, Site: d.site, Other: o.site}
+		}
+	}
+	r.domains[name] = d
+	return d, nil
+}
+
+// Lookup returns the error registered for the code in the domain.
+func (r *Registry) Lookup(domain string, code int) (*Error, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if d, ok := r.domains[domain]; ok {
+		if e, ok := d.codes[code]; ok {
+			return e.err, true
+		}
+	}
+	return nil, false
+}
+
+// Name returns the name of the domain.
+func (d *Domain) Name() string {
+	return d.name
+}
+
+// New registers an error in the domain, it panics on collisions. A zero
+// status derives the HTTP status from the code, see ToHTTPStatusCode.
+func (d *Domain) New(code, status int, reason, msg string) *Error {
+	e, err := d.register(code, status, reason, msg, 1)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Register registers an error in the domain. It returns a *CollisionError
+// when the code is already registered, and an error when the code is out
+// of the range of the domain.
+func (d *Domain) Register(code, status int, reason, msg string) (*Error, error) {
+	return d.register(code, status, reason, msg, 1)
+}
+
+func (d *Domain) register(code, status int, reason, msg string, skip int) (*Error, error) {
+	if d.ranged() && (code < d.min || code > d.max) {
+		return nil, fmt.Errorf("errcode: code %d is out of the range %d-%d of domain %q", code, d.min, d.max, d.name)
+	}
+	e := &Error{code: code, msg: msg, reason: reason, domain: d.name, status: status}
+	site := caller(skip + 1)
+
+	d.reg.mu.Lock()
+	defer d.reg.mu.Unlock()
+	if o, ok := d.codes[code]; ok {
+		return nil, &CollisionError{Domain: d.name, Code: code, Site: site, Other: o.site}
+	}
+	d.codes[code] = &entry{err: e, site: site}
+	return e, nil
+}
+
+func (d *Domain) ranged() bool {
+	return d.min != 0 || d.max != 0
+}
+
+// caller returns the file:line of the caller skip frames above the caller
+// of caller.
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// Catalog is the list of the codes of a registry, for API documentation
+// and client SDKs.
+type Catalog struct {
+	Domains []DomainCatalog `json:"domains"`
+}
+
+// DomainCatalog is the list of the codes of a domain, sorted by code.
+type DomainCatalog struct {
+	Name  string  `json:"name"`
+	Min   int     `json:"min,omitempty"`
+	Max   int     `json:"max,omitempty"`
+	Codes []Entry `json:"codes"`
+}
+
+// Entry is a registered error.
+type Entry struct {
+	Code       int    `json:"code"`
+	Reason     string `json:"reason,omitempty"`
+	Msg        string `json:"msg"`
+	HTTPStatus int    `json:"http_status"`
+	GRPCCode   string `json:"grpc_code"`
+	// Site is the file:line of the definition.
+	Site string `json:"-"`
+}
+
+// Catalog returns the registered codes sorted by domain and code.
+func (r *Registry) Catalog() Catalog {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c := Catalog{Domains: make([]DomainCatalog, 0, len(r.domains))}
+	for _, d := range r.domains {
+		dc := DomainCatalog{Name: d.name, Min: d.min, Max: d.max, Codes: make([]Entry, 0, len(d.codes))}
+		for _, e := range d.codes {
+			dc.Codes = append(dc.Codes, Entry{
+				Code:       e.err.code,
+				Reason:     e.err.reason,
+				Msg:        e.err.msg,
+				HTTPStatus: e.err.StatusCode(),
+				GRPCCode:   e.err.GRPCCode().String(),
+				Site:       e.site,
+			})
+		}
+		sort.Slice(dc.Codes, func(i, j int) bool { return dc.Codes[i].Code < dc.Codes[j].Code })
+		c.Domains = append(c.Domains, dc)
+	}
+	sort.Slice(c.Domains, func(i, j int) bool { return c.Domains[i].Name < c.Domains[j].Name })
+	return c
+}
+
+// WriteJSON writes the catalog as indented JSON.
+func (c Catalog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+// WriteMarkdown writes the catalog as a Markdown table per domain.
+func (c Catalog) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	for i, d := range c.Domains {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		name := d.Name
+		if name == "" {
+			name = "global"
+		}
+		if d.Min != 0 || d.Max != 0 {
+			fmt.Fprintf(&b, "## %s (%d-%d)\n\n", name, d.Min, d.Max)
+		} else {
+			fmt.Fprintf(&b, "## %s\n\n", name)
+		}
+		b.WriteString("| Code | Reason | HTTP Status | gRPC Code | Message |\n")
+		b.WriteString("| ---: | :--- | ---: | :--- | :--- |\n")
+		for _, e := range d.Codes {
+			fmt.Fprintf(&b, "| %d | %s | %d | %s | %s |\n", e.Code, markdownEscape(e.Reason), e.HTTPStatus, e.GRPCCode, markdownEscape(e.Msg))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
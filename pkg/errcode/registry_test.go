@@ -0,0 +1,155 @@
+package errcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Domain(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.Domain("user", 20000, 20999); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Domain("order", 30000, 30999); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Domain("unbounded", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		min, max int
+	}{
+		{"user", 40000, 40999},
+		{"billing", 20500, 21499},
+		{"billing", 29000, 30000},
+	} {
+		_, err := r.Domain(tt.name, tt.min, tt.max)
+		var e *CollisionError
+		if !errors.As(err, &e) || !e.IsDomain {
+			t.Errorf("%s %d-%d: expected a domain collision, got %v", tt.name, tt.min, tt.max, err)
+			continue
+		}
+		if !strings.Contains(e.Site, "registry_test.go") || !strings.Contains(e.Other, "registry_test.go") {
+			t.Errorf("expected both definition sites, got %v", e)
+		}
+	}
+	if _, err := r.Domain("billing", 2, 1); err == nil {
+		t.Error("expected an invalid range error")
+	}
+}
+
+func TestDomain_Register(t *testing.T) {
+	r := NewRegistry()
+	user, _ := r.Domain("user", 20000, 20999)
+	lib, _ := r.Domain("lib", 0, 0)
+
+	notFound, err := user.Register(20001, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notFound.Domain() != "user" || notFound.StatusCode() != http.StatusNotFound {
+		t.Errorf("unexpected error %v", notFound)
+	}
+	// the same code in another domain
+	other, err := lib.Register(20001, 0, "", "Lib error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errors.Is(other, notFound) {
+		t.Errorf("expected %v not to match %v", other, notFound)
+	}
+
+	_, err = user.Register(20001, http.StatusGone, "USER_GONE", "User gone")
+	var e *CollisionError
+	if !errors.As(err, &e) || e.IsDomain || e.Code != 20001 || e.Domain != "user" {
+		t.Fatalf("expected a collision, got %v", err)
+	}
+	if !strings.Contains(e.Site, "registry_test.go:") || !strings.Contains(e.Other, "registry_test.go:") || e.Site == e.Other {
+		t.Errorf("expected both definition sites, got %v", e)
+	}
+	// code 0 is a code collision too
+	if _, err = lib.Register(0, 0, "", "Zero"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = lib.Register(0, 0, "", "Zero again")
+	if !errors.As(err, &e) || e.IsDomain || !strings.Contains(e.Error(), "code 0 of domain") {
+		t.Errorf("expected a code collision, got %v", err)
+	}
+	if _, err = user.Register(30001, 0, "", "Out of range"); err == nil {
+		t.Error("expected an out of range error")
+	}
+
+	if got, ok := r.Lookup("user", 20001); !ok || got != notFound {
+		t.Errorf("expected %v got %v", notFound, got)
+	}
+	if _, ok := r.Lookup("user", 20002); ok {
+		t.Error("expected no error")
+	}
+}
+
+func TestNewError_Collision(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		var e *CollisionError
+		if !errors.As(err, &e) || !strings.Contains(e.Site, "registry_test.go") || !strings.Contains(e.Other, "code.go") {
+			t.Errorf("expected a collision with code.go, got %v", err)
+		}
+	}()
+	NewError(ErrNotFound.Code(), "Not found")
+}
+
+func TestRegistry_Catalog(t *testing.T) {
+	r := NewRegistry()
+	user, _ := r.Domain("user", 20000, 20999)
+	auth, _ := r.Domain("auth", 0, 0)
+	user.New(20002, http.StatusConflict, "USER_EXISTS", "User exists")
+	user.New(20001, http.StatusNotFound, "USER_NOT_FOUND", "User | not found")
+	auth.New(1, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+
+	c := r.Catalog()
+	if len(c.Domains) != 2 || c.Domains[0].Name != "auth" || c.Domains[1].Name != "user" {
+		t.Fatalf("unexpected domains %+v", c.Domains)
+	}
+	codes := c.Domains[1].Codes
+	if len(codes) != 2 || codes[0].Code != 20001 || codes[1].Code != 20002 {
+		t.Fatalf("unexpected codes %+v", codes)
+	}
+	if codes[0].HTTPStatus != http.StatusNotFound || codes[0].GRPCCode != "NotFound" {
+		t.Errorf("unexpected entry %+v", codes[0])
+	}
+
+	var b bytes.Buffer
+	if err := c.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var got Catalog
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Domains) != 2 || got.Domains[1].Min != 20000 || got.Domains[1].Codes[1].Reason != "USER_EXISTS" {
+		t.Errorf("unexpected catalog %s", b.String())
+	}
+	if strings.Contains(b.String(), "registry_test.go") {
+		t.Errorf("expected no definition sites, got %s", b.String())
+	}
+
+	b.Reset()
+	if err := c.WriteMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"## auth\n",
+		"## user (20000-20999)\n",
+		"| 20001 | USER_NOT_FOUND | 404 | NotFound | User \\| not found |\n",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("expected %q in\n%s", s, b.String())
+		}
+	}
+}
@@ -8,13 +8,10 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/cast"
-	"google.golang.org/grpc/status"
 
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/pkg/errcode"
 	thttp "github.com/apus-run/gaia/transport/http"
-	httpStatus "github.com/apus-run/gaia/transport/http/status"
 )
 
 const (
@@ -34,10 +31,13 @@ const (
 
 // Result defines HTTP JSON response
 type Result struct {
-	Code    int      `json:"code"`
-	Msg     string   `json:"msg"`
-	Data    any      `json:"data"`
-	Details []string `json:"details,omitempty"`
+	Code     int               `json:"code"`
+	Reason   string            `json:"reason,omitempty"`
+	Domain   string            `json:"domain,omitempty"`
+	Msg      string            `json:"msg"`
+	Data     any               `json:"data"`
+	Details  []string          `json:"details,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // Context a wrapper of gin.Context
@@ -133,41 +133,22 @@ func (c *Context) Error(err error) {
 		return
 	}
 
-	if v, ok := err.(*errcode.Error); ok {
-		response := Result{
-			Code:    v.Code(),
-			Msg:     v.Msg(),
-			Data:    gin.H{},
-			Details: []string{},
-		}
-		details := v.Details()
-		if len(details) > 0 {
-			response.Details = details
-		}
-		c.JSON(errcode.ToHTTPStatusCode(v.Code()), response)
-		return
-	} else {
-		// receive gRPC error
-		if st, ok := status.FromError(err); ok {
-			response := Result{
-				Code:    int(st.Code()),
-				Msg:     st.Message(),
-				Data:    gin.H{},
-				Details: []string{},
-			}
-			details := st.Details()
-			if len(details) > 0 {
-				for _, v := range details {
-					response.Details = append(response.Details, cast.ToString(v))
-				}
-			}
-			// https://httpstatus.in/
-			// https://github.com/grpc-ecosystem/grpc-gateway/blob/master/runtime/errors.go#L15
-			// https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
-			c.JSON(httpStatus.FromGRPCCode(st.Code()), response)
-			return
-		}
+	// errcode errors, gRPC status errors received from clients and any
+	// other error are all written with their HTTP status
+	v := errcode.FromError(err)
+	response := Result{
+		Code:     v.Code(),
+		Reason:   v.Reason(),
+		Domain:   v.Domain(),
+		Msg:      v.Msg(),
+		Data:     gin.H{},
+		Details:  []string{},
+		Metadata: v.Metadata(),
+	}
+	if details := v.Details(); len(details) > 0 {
+		response.Details = details
 	}
+	c.JSON(v.StatusCode(), response)
 }
 
 // RouteNotFound 未找到相关路由
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+)
+
+var _ Recorder = (*Memory)(nil)
+
+// Memory is an in-memory recorder, e.g. for tests.
+type Memory struct {
+	mu     sync.Mutex
+	values map[string]float64
+	counts map[string]uint64
+	funcs  map[string]func() float64
+}
+
+// NewMemory returns an in-memory recorder.
+func NewMemory() *Memory {
+	return &Memory{
+		values: make(map[string]float64),
+		counts: make(map[string]uint64),
+		funcs:  make(map[string]func() float64),
+	}
+}
+
+// Counter returns a counter of the recorder.
+func (m *Memory) Counter(name, _ string, _ ...string) Counter {
+	return &memoryMetric{m: m, name: name}
+}
+
+// Gauge returns a gauge of the recorder.
+func (m *Memory) Gauge(name, _ string, _ ...string) Gauge {
+	return &memoryMetric{m: m, name: name}
+}
+
+// Histogram returns a histogram of the recorder, it records the count and
+// the sum of the observations.
+func (m *Memory) Histogram(name, _ string, _ []float64, _ ...string) Histogram {
+	return &memoryMetric{m: m, name: name}
+}
+
+// GaugeFunc records f as the value of the gauge.
+func (m *Memory) GaugeFunc(name, _ string, f func() float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.funcs[name] = f
+}
+
+// Value returns the value of a counter or a gauge, or the sum of the
+// observations of a histogram.
+func (m *Memory) Value(name string, lvs ...string) float64 {
+	m.mu.Lock()
+	f, ok := m.funcs[name]
+	v := m.values[key(name, lvs)]
+	m.mu.Unlock()
+	if ok && len(lvs) == 0 {
+		return f()
+	}
+	return v
+}
+
+// Count returns the number of the observations of a histogram.
+func (m *Memory) Count(name string, lvs ...string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[key(name, lvs)]
+}
+
+type memoryMetric struct {
+	m    *Memory
+	name string
+}
+
+func (c *memoryMetric) Add(delta float64, lvs ...string) {
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	c.m.values[key(c.name, lvs)] += delta
+}
+
+func (c *memoryMetric) Set(value float64, lvs ...string) {
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	c.m.values[key(c.name, lvs)] = value
+}
+
+func (c *memoryMetric) Observe(value float64, lvs ...string) {
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	k := key(c.name, lvs)
+	c.m.values[k] += value
+	c.m.counts[k]++
+}
+
+func key(name string, lvs []string) string {
+	return name + "{" + strings.Join(lvs, ",") + "}"
+}
@@ -0,0 +1,37 @@
+// Package metrics is the interface of the metrics backends, see the
+// prometheus package for a Prometheus backend and NewMemory for an
+// in-memory one.
+package metrics
+
+// DefaultBuckets are the buckets of the latency histograms in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Recorder creates the metrics of a backend. The metrics are keyed by
+// name, creating a metric twice returns the same metric.
+type Recorder interface {
+	// Counter returns a counter with the label names.
+	Counter(name, help string, labels ...string) Counter
+	// Gauge returns a gauge with the label names.
+	Gauge(name, help string, labels ...string) Gauge
+	// Histogram returns a histogram with the buckets and the label names.
+	Histogram(name, help string, buckets []float64, labels ...string) Histogram
+	// GaugeFunc records the value of f when the metrics are collected.
+	GaugeFunc(name, help string, f func() float64)
+}
+
+// Counter is a counter, the label values are in the order of its label
+// names.
+type Counter interface {
+	Add(delta float64, lvs ...string)
+}
+
+// Gauge is a gauge.
+type Gauge interface {
+	Add(delta float64, lvs ...string)
+	Set(value float64, lvs ...string)
+}
+
+// Histogram is a histogram.
+type Histogram interface {
+	Observe(value float64, lvs ...string)
+}
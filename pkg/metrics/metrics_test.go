@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/apus-run/gaia/registry"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	m.Counter("requests", "").Add(2, "a")
+	m.Gauge("inflight", "").Add(1, "a")
+	m.Gauge("inflight", "").Add(-1, "a")
+	m.Histogram("duration", "", DefaultBuckets).Observe(0.5, "a")
+	m.Histogram("duration", "", DefaultBuckets).Observe(1.5, "a")
+	m.GaugeFunc("sessions", "", func() float64 { return 3 })
+
+	if v := m.Value("requests", "a"); v != 2 {
+		t.Errorf("expected 2 got %v", v)
+	}
+	if v := m.Value("requests", "b"); v != 0 {
+		t.Errorf("expected 0 got %v", v)
+	}
+	if v := m.Value("inflight", "a"); v != 0 {
+		t.Errorf("expected 0 got %v", v)
+	}
+	if n, v := m.Count("duration", "a"), m.Value("duration", "a"); n != 2 || v != 2 {
+		t.Errorf("expected 2 observations of sum 2 got %d of %v", n, v)
+	}
+	if v := m.Value("sessions"); v != 3 {
+		t.Errorf("expected 3 got %v", v)
+	}
+}
+
+type testDiscovery struct {
+	registry.Discovery
+	w registry.Watcher
+}
+
+func (d *testDiscovery) Watch(context.Context, string) (registry.Watcher, error) {
+	return d.w, nil
+}
+
+type testWatcher struct {
+	results [][]*registry.ServiceInstance
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	if len(w.results) == 0 {
+		return nil, errors.New("stopped")
+	}
+	ins := w.results[0]
+	w.results = w.results[1:]
+	return ins, nil
+}
+
+func (w *testWatcher) Stop() error { return nil }
+
+func TestDiscovery(t *testing.T) {
+	m := NewMemory()
+	d := Discovery(&testDiscovery{w: &testWatcher{results: [][]*registry.ServiceInstance{
+		{{ID: "1"}, {ID: "2"}},
+		{{ID: "1"}},
+	}}}, m)
+	w, err := d.Watch(context.Background(), "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = w.Next()
+	}
+	if v := m.Value("gaia_registry_watch_events_total", "helloworld", "ok"); v != 2 {
+		t.Errorf("expected 2 events got %v", v)
+	}
+	if v := m.Value("gaia_registry_watch_events_total", "helloworld", "error"); v != 1 {
+		t.Errorf("expected 1 error got %v", v)
+	}
+	if v := m.Value("gaia_registry_instances", "helloworld"); v != 1 {
+		t.Errorf("expected 1 instance got %v", v)
+	}
+}
@@ -0,0 +1,98 @@
+// Package prometheus is a Prometheus backend of the metrics.
+package prometheus
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/apus-run/gaia/pkg/metrics"
+)
+
+var _ metrics.Recorder = (*Recorder)(nil)
+
+// Recorder is a recorder registering the metrics in a Prometheus registry.
+type Recorder struct {
+	reg prometheus.Registerer
+}
+
+// New returns a recorder registering the metrics in reg,
+// prometheus.DefaultRegisterer when nil.
+func New(reg prometheus.Registerer) *Recorder {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	return &Recorder{reg: reg}
+}
+
+// Handler returns the handler exposing the metrics of g,
+// prometheus.DefaultGatherer when nil.
+func Handler(g prometheus.Gatherer) http.Handler {
+	if g == nil {
+		g = prometheus.DefaultGatherer
+	}
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
+
+// Counter returns a counter vector.
+func (r *Recorder) Counter(name, help string, labels ...string) metrics.Counter {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	return &counter{register(r.reg, c)}
+}
+
+// Gauge returns a gauge vector.
+func (r *Recorder) Gauge(name, help string, labels ...string) metrics.Gauge {
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+	return &gauge{register(r.reg, g)}
+}
+
+// Histogram returns a histogram vector.
+func (r *Recorder) Histogram(name, help string, buckets []float64, labels ...string) metrics.Histogram {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	return &histogram{register(r.reg, h)}
+}
+
+// GaugeFunc registers a gauge of the value of f, a gauge of the same name
+// registered before is kept.
+func (r *Recorder) GaugeFunc(name, help string, f func() float64) {
+	register[prometheus.Collector](r.reg, prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, f))
+}
+
+// register registers c, it returns the collector registered before with
+// the same description, e.g. by another server.
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+type counter struct{ *prometheus.CounterVec }
+
+func (c *counter) Add(delta float64, lvs ...string) {
+	c.WithLabelValues(lvs...).Add(delta)
+}
+
+type gauge struct{ *prometheus.GaugeVec }
+
+func (g *gauge) Add(delta float64, lvs ...string) {
+	g.WithLabelValues(lvs...).Add(delta)
+}
+
+func (g *gauge) Set(value float64, lvs ...string) {
+	g.WithLabelValues(lvs...).Set(value)
+}
+
+type histogram struct{ *prometheus.HistogramVec }
+
+func (h *histogram) Observe(value float64, lvs ...string) {
+	h.WithLabelValues(lvs...).Observe(value)
+}
@@ -0,0 +1,36 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRecorder(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r := New(reg)
+	r.Counter("gaia_test_total", "test", "code").Add(1, "OK")
+	// the metrics created twice are shared, e.g. by the middleware of two servers
+	r.Counter("gaia_test_total", "test", "code").Add(1, "OK")
+	r.Gauge("gaia_test_gauge", "test", "kind").Set(3, "grpc")
+	r.Histogram("gaia_test_seconds", "test", []float64{1}, "kind").Observe(0.5, "grpc")
+	r.GaugeFunc("gaia_test_func", "test", func() float64 { return 7 })
+	r.GaugeFunc("gaia_test_func", "test", func() float64 { return 8 })
+
+	res := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := res.Body.String()
+	for _, want := range []string{
+		`gaia_test_total{code="OK"} 2`,
+		`gaia_test_gauge{kind="grpc"} 3`,
+		`gaia_test_seconds_count{kind="grpc"} 1`,
+		`gaia_test_func 7`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in %s", want, body)
+		}
+	}
+}
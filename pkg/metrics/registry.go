@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/apus-run/gaia/registry"
+)
+
+// Discovery returns the discovery recording the events of its watchers:
+//
+//   - gaia_registry_watch_events_total{service, result}: the results of
+//     Next, "ok" or "error",
+//   - gaia_registry_instances{service}: the instances of the last result.
+func Discovery(d registry.Discovery, r Recorder) registry.Discovery {
+	return &discovery{
+		Discovery: d,
+		events:    r.Counter("gaia_registry_watch_events_total", "The events of the registry watchers.", "service", "result"),
+		instances: r.Gauge("gaia_registry_instances", "The instances of the services watched.", "service"),
+	}
+}
+
+type discovery struct {
+	registry.Discovery
+	events    Counter
+	instances Gauge
+}
+
+func (d *discovery) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	w, err := d.Discovery.Watch(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{Watcher: w, d: d, service: serviceName}, nil
+}
+
+type watcher struct {
+	registry.Watcher
+	d       *discovery
+	service string
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	ins, err := w.Watcher.Next()
+	if err != nil {
+		w.d.events.Add(1, w.service, "error")
+		return ins, err
+	}
+	w.d.events.Add(1, w.service, "ok")
+	w.d.instances.Set(float64(len(ins)), w.service)
+	return ins, nil
+}
@@ -0,0 +1,164 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// DefaultBackoff is the backoff of Do by default.
+var DefaultBackoff Backoff = Exponential{
+	Base:       100 * time.Millisecond,
+	Max:        10 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Backoff returns the delay before the retry n, from 1.
+type Backoff interface {
+	Backoff(n int) time.Duration
+}
+
+// BackoffFunc is a function implementing Backoff.
+type BackoffFunc func(n int) time.Duration
+
+// Backoff calls f(n).
+func (f BackoffFunc) Backoff(n int) time.Duration {
+	return f(n)
+}
+
+// Constant returns a backoff of d for all the retries.
+func Constant(d time.Duration) Backoff {
+	return BackoffFunc(func(int) time.Duration { return d })
+}
+
+// Exponential is a backoff of Base times Multiplier to the power of n-1,
+// capped at Max, then randomized by a factor in [1-Jitter, 1+Jitter].
+type Exponential struct {
+	Base       time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// Backoff returns the delay before the retry n.
+func (b Exponential) Backoff(n int) time.Duration {
+	if n < 1 {
+		n = 1
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(b.Base) * math.Pow(multiplier, float64(n-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// Sleep waits for d, it returns the error of ctx when ctx is done first.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Option is retry option.
+type Option func(*options)
+
+type options struct {
+	attempts  int
+	backoff   Backoff
+	retryable func(error) bool
+	onRetry   func(n int, delay time.Duration, err error)
+}
+
+// WithAttempts with the max attempts, the first one included, 3 by default,
+// retrying until ctx is done when 0.
+func WithAttempts(n int) Option {
+	return func(o *options) {
+		o.attempts = n
+	}
+}
+
+// WithBackoff with the backoff between the attempts, DefaultBackoff by
+// default.
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithRetryable with the errors retried, all of them by default. The
+// *ErrNoRetry errors are never retried.
+func WithRetryable(f func(error) bool) Option {
+	return func(o *options) {
+		o.retryable = f
+	}
+}
+
+// WithOnRetry with a function called before each retry, e.g. to log it.
+func WithOnRetry(f func(n int, delay time.Duration, err error)) Option {
+	return func(o *options) {
+		o.onRetry = f
+	}
+}
+
+// Do calls fn until it succeeds, see DoValue.
+func Do(ctx context.Context, fn func(context.Context) error, opts ...Option) error {
+	_, err := DoValue(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+	return err
+}
+
+// DoValue calls fn until it succeeds, it returns an error that is not
+// retryable or the attempts are exhausted. It stops early when ctx is done
+// or its deadline is before the next retry, returning the last error of fn.
+func DoValue[T any](ctx context.Context, fn func(context.Context) (T, error), opts ...Option) (T, error) {
+	o := options{
+		attempts: 3,
+		backoff:  DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for n := 1; ; n++ {
+		v, err := fn(ctx)
+		if err == nil || !o.shouldRetry(err) || (o.attempts > 0 && n >= o.attempts) {
+			return v, err
+		}
+		delay := o.backoff.Backoff(n)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return v, err
+		}
+		if o.onRetry != nil {
+			o.onRetry(n, delay, err)
+		}
+		if Sleep(ctx, delay) != nil {
+			return v, err
+		}
+	}
+}
+
+func (o *options) shouldRetry(err error) bool {
+	var noRetry *ErrNoRetry
+	if errors.As(err, &noRetry) {
+		return false
+	}
+	return o.retryable == nil || o.retryable(err)
+}
@@ -1,6 +1,8 @@
 package retry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -24,19 +26,26 @@ func (e *ErrNoRetry) Error() string {
 
 // DoRetry is a help function to retry the function if it returns error
 func DoRetry(kind, name, tag string, r Retry, fn func() error) error {
-	var err error
-	for i := 0; i < r.Times; i++ {
-		err = fn()
-		_, ok := err.(*ErrNoRetry)
-		if err == nil || ok {
-			return err
-		}
-		log.Warnf("[%s / %s / %s] Retried to send %d/%d - %v", kind, name, tag, i+1, r.Times, err)
+	return DoRetryContext(context.Background(), kind, name, tag, r, fn)
+}
 
-		// last time no need to sleep
-		if i < r.Times-1 {
-			time.Sleep(r.Interval)
-		}
+// DoRetryContext is DoRetry stopping when ctx is done
+func DoRetryContext(ctx context.Context, kind, name, tag string, r Retry, fn func() error) error {
+	if r.Times < 1 {
+		return fmt.Errorf("[%s / %s / %s] failed after %d retries - %v", kind, name, tag, r.Times, nil)
+	}
+	err := Do(ctx, func(context.Context) error {
+		return fn()
+	},
+		WithAttempts(r.Times),
+		WithBackoff(Constant(r.Interval)),
+		WithOnRetry(func(n int, _ time.Duration, err error) {
+			log.Warnf("[%s / %s / %s] Retried to send %d/%d - %v", kind, name, tag, n, r.Times, err)
+		}),
+	)
+	var noRetry *ErrNoRetry
+	if err == nil || errors.As(err, &noRetry) {
+		return err
 	}
 	return fmt.Errorf("[%s / %s / %s] failed after %d retries - %v", kind, name, tag, r.Times, err)
 }
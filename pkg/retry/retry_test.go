@@ -1,6 +1,8 @@
 package retry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -43,3 +45,55 @@ func TestRetry(t *testing.T) {
 	assert.Equal(t, 1, cnt)
 	assert.Equal(t, err.Error(), "No Retry Error")
 }
+
+func TestExponential(t *testing.T) {
+	b := Exponential{Base: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	assert.Equal(t, 100*time.Millisecond, b.Backoff(1))
+	assert.Equal(t, 400*time.Millisecond, b.Backoff(3))
+	assert.Equal(t, time.Second, b.Backoff(10))
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := b.Backoff(2)
+		assert.True(t, d >= 100*time.Millisecond && d <= 300*time.Millisecond, d)
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	cnt := 0
+	v, err := DoValue(context.Background(), func(context.Context) (int, error) {
+		cnt++
+		if cnt < 3 {
+			return 0, fmt.Errorf("error, cnt=%d", cnt)
+		}
+		return cnt, nil
+	}, WithBackoff(Constant(time.Millisecond)))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+
+	cnt = 0
+	err = Do(context.Background(), func(context.Context) error {
+		cnt++
+		return errors.New("not retryable")
+	}, WithRetryable(func(error) bool { return false }))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, cnt)
+}
+
+func TestDo_context(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	cnt := 0
+	start := time.Now()
+	err := Do(ctx, func(context.Context) error {
+		cnt++
+		return errors.New("error")
+	}, WithAttempts(0), WithBackoff(Constant(20*time.Millisecond)))
+	assert.NotNil(t, err)
+	assert.True(t, cnt >= 2 && cnt <= 3, cnt)
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, Sleep(ctx, time.Hour))
+}
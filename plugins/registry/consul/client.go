@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/apus-run/gaia/pkg/retry"
 	"github.com/apus-run/gaia/registry"
 	"github.com/apus-run/sea-kit/log"
 
@@ -149,20 +150,16 @@ func (c *Client) Register(_ context.Context, svc *registry.ServiceInstance, enab
 	}
 	if c.heartbeat {
 		go func() {
-			time.Sleep(time.Second)
-			err = c.cli.Agent().UpdateTTL("service:"+svc.ID, "pass", "pass")
-			if err != nil {
-				log.Errorf("[Consul]update ttl heartbeat to consul failed!err:=%v", err)
+			if retry.Sleep(c.ctx, time.Second) != nil {
+				return
 			}
+			c.updateTTL(svc.ID)
 			ticker := time.NewTicker(time.Second * time.Duration(c.healthcheckInterval))
 			defer ticker.Stop()
 			for {
 				select {
 				case <-ticker.C:
-					err = c.cli.Agent().UpdateTTL("service:"+svc.ID, "pass", "pass")
-					if err != nil {
-						log.Errorf("[Consul]update ttl heartbeat to consul failed!err:=%v", err)
-					}
+					c.updateTTL(svc.ID)
 				case <-c.ctx.Done():
 					return
 				}
@@ -172,6 +169,21 @@ func (c *Client) Register(_ context.Context, svc *registry.ServiceInstance, enab
 	return nil
 }
 
+// updateTTL passes the TTL check of the service, retrying within the
+// heartbeat interval.
+func (c *Client) updateTTL(serviceID string) {
+	ctx, cancel := context.WithTimeout(c.ctx, time.Second*time.Duration(c.healthcheckInterval))
+	defer cancel()
+	err := retry.Do(ctx, func(context.Context) error {
+		return c.cli.Agent().UpdateTTL("service:"+serviceID, "pass", "pass")
+	}, retry.WithOnRetry(func(_ int, _ time.Duration, err error) {
+		log.Warnf("[Consul]update ttl heartbeat to consul failed, retrying!err:=%v", err)
+	}))
+	if err != nil {
+		log.Errorf("[Consul]update ttl heartbeat to consul failed!err:=%v", err)
+	}
+}
+
 // Deregister service by service ID
 func (c *Client) Deregister(_ context.Context, serviceID string) error {
 	c.cancel()
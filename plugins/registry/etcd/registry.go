@@ -3,14 +3,23 @@ package etcd
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 
+	"github.com/apus-run/gaia/pkg/retry"
 	"github.com/apus-run/gaia/registry"
 )
 
+// heartbeatBackoff is the backoff between the attempts to register again
+// when the lease is lost.
+var heartbeatBackoff = retry.Exponential{
+	Base:       time.Second,
+	Max:        time.Minute,
+	Multiplier: 2,
+	Jitter:     0.5,
+}
+
 var (
 	_ registry.Registry  = &Registry{}
 	_ registry.Discovery = &Registry{}
@@ -149,12 +158,9 @@ func (r *Registry) heartBeat(ctx context.Context, leaseID clientv3.LeaseID, key
 	if err != nil {
 		curLeaseID = 0
 	}
-	rand.Seed(time.Now().Unix())
-
 	for {
 		if curLeaseID == 0 {
 			// try to registerWithKV
-			retreat := []int{}
 			for retryCnt := 0; retryCnt < r.opts.maxRetry; retryCnt++ {
 				if ctx.Err() != nil {
 					return
@@ -186,8 +192,9 @@ func (r *Registry) heartBeat(ctx context.Context, leaseID clientv3.LeaseID, key
 				if err == nil {
 					break
 				}
-				retreat = append(retreat, 1<<retryCnt)
-				time.Sleep(time.Duration(retreat[rand.Intn(len(retreat))]) * time.Second)
+				if retry.Sleep(ctx, heartbeatBackoff.Backoff(retryCnt+1)) != nil {
+					return
+				}
 			}
 			if _, ok := <-kac; !ok {
 				// retry failed
@@ -7,11 +7,13 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fasthttp/router"
 	"github.com/valyala/fasthttp"
 
+	"github.com/apus-run/gaia/internal/inherit"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/transport"
 	"github.com/apus-run/sea-kit/log"
@@ -20,6 +22,7 @@ import (
 var (
 	_ transport.Server     = (*Server)(nil)
 	_ transport.Endpointer = (*Server)(nil)
+	_ transport.Readier    = (*Server)(nil)
 )
 
 type Server struct {
@@ -36,6 +39,9 @@ type Server struct {
 
 	strictSlash bool
 	router      *router.Router
+
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -43,6 +49,7 @@ func NewServer(opts ...ServerOption) *Server {
 		timeout:     1 * time.Second,
 		strictSlash: true,
 		router:      router.New(),
+		ready:       make(chan struct{}),
 	}
 
 	srv.init(opts...)
@@ -87,11 +94,16 @@ func (s *Server) Endpoint() (*url.URL, error) {
 func (s *Server) Start(ctx context.Context) error {
 	log.Infof("[fasthttp] server listening on: %s", s.addr)
 
-	var err error
+	lis, err := inherit.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.readyOnce.Do(func() { close(s.ready) })
+
 	if s.tlsConf != nil {
-		err = s.Server.ListenAndServeTLS(s.addr, "", "")
+		err = s.Server.ServeTLS(lis, "", "")
 	} else {
-		err = s.Server.ListenAndServe(s.addr)
+		err = s.Server.Serve(lis)
 	}
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
@@ -100,6 +112,11 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// Ready returns a channel that is closed once the server is serving.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 func (s *Server) Stop(_ context.Context) error {
 	log.Info("[fasthttp] server stopping")
 	return s.Server.Shutdown()
@@ -0,0 +1,239 @@
+package hertz
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/network"
+)
+
+// listenerTransport is a hertz transporter serving a listener bound by
+// the server, so that listeners are inherited across graceful restarts
+// and the server is ready once the listener is bound.
+type listenerTransport struct {
+	opts *config.Options
+
+	mu sync.Mutex
+	ln net.Listener
+}
+
+func newListenerTransport(opts *config.Options) *listenerTransport {
+	return &listenerTransport{opts: opts}
+}
+
+// setListener sets the listener served by ListenAndServe.
+func (t *listenerTransport) setListener(ln net.Listener) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ln = ln
+}
+
+func (t *listenerTransport) ListenAndServe(onData network.OnData) error {
+	t.mu.Lock()
+	ln := t.ln
+	t.mu.Unlock()
+	if ln == nil {
+		return errors.New("hertz: no listener")
+	}
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		ctx := context.Background()
+		if t.opts.OnAccept != nil {
+			ctx = t.opts.OnAccept(c)
+		}
+		if t.opts.TLS != nil {
+			c = tls.Server(c, t.opts.TLS)
+		}
+		var conn network.Conn = newConn(c)
+		if tc, ok := c.(*tls.Conn); ok {
+			conn = &tlsConn{bufConn: newConn(tc), tls: tc}
+		}
+		if t.opts.OnConnect != nil {
+			ctx = t.opts.OnConnect(ctx, conn)
+		}
+		go func() {
+			_ = onData(ctx, conn)
+		}()
+	}
+}
+
+func (t *listenerTransport) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	return t.Shutdown(ctx)
+}
+
+// Shutdown closes the listener, the engine waits for the connections.
+func (t *listenerTransport) Shutdown(context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ln == nil {
+		return nil
+	}
+	err := t.ln.Close()
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// bufConn is a network.Conn buffering the reads and writes of a net.Conn.
+type bufConn struct {
+	net.Conn
+	rbuf         []byte
+	wbuf         net.Buffers
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func newConn(c net.Conn) *bufConn {
+	return &bufConn{Conn: c}
+}
+
+// fill reads from the connection until n bytes are buffered.
+func (c *bufConn) fill(n int) error {
+	for len(c.rbuf) < n {
+		if c.readTimeout > 0 {
+			_ = c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+		buf := make([]byte, 4096)
+		m, err := c.Conn.Read(buf)
+		c.rbuf = append(c.rbuf, buf[:m]...)
+		if err != nil && len(c.rbuf) < n {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *bufConn) Read(p []byte) (int, error) {
+	if len(c.rbuf) == 0 {
+		if err := c.fill(1); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+	return n, nil
+}
+
+func (c *bufConn) Peek(n int) ([]byte, error) {
+	if err := c.fill(n); err != nil {
+		if err == io.EOF && len(c.rbuf) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return c.rbuf, err
+	}
+	return c.rbuf[:n], nil
+}
+
+func (c *bufConn) Skip(n int) error {
+	if err := c.fill(n); err != nil {
+		return err
+	}
+	c.rbuf = c.rbuf[n:]
+	return nil
+}
+
+// Release drops the consumed part of the read buffer, peeked slices stay
+// valid as the unread bytes are copied.
+func (c *bufConn) Release() error {
+	if len(c.rbuf) == 0 {
+		c.rbuf = nil
+	} else if cap(c.rbuf) > 2*len(c.rbuf) {
+		c.rbuf = append([]byte(nil), c.rbuf...)
+	}
+	return nil
+}
+
+func (c *bufConn) Len() int {
+	return len(c.rbuf)
+}
+
+func (c *bufConn) ReadByte() (byte, error) {
+	if err := c.fill(1); err != nil {
+		return 0, err
+	}
+	b := c.rbuf[0]
+	c.rbuf = c.rbuf[1:]
+	return b, nil
+}
+
+func (c *bufConn) ReadBinary(n int) ([]byte, error) {
+	if err := c.fill(n); err != nil {
+		return nil, err
+	}
+	p := append([]byte(nil), c.rbuf[:n]...)
+	c.rbuf = c.rbuf[n:]
+	return p, nil
+}
+
+// Malloc returns a buffer written by Flush, the buffers of earlier calls
+// stay valid.
+func (c *bufConn) Malloc(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	c.wbuf = append(c.wbuf, buf)
+	return buf, nil
+}
+
+// WriteBinary queues b, which must not change until Flush.
+func (c *bufConn) WriteBinary(b []byte) (int, error) {
+	c.wbuf = append(c.wbuf, b)
+	return len(b), nil
+}
+
+func (c *bufConn) Write(b []byte) (int, error) {
+	if err := c.Flush(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *bufConn) Flush() error {
+	if len(c.wbuf) == 0 {
+		return nil
+	}
+	if c.writeTimeout > 0 {
+		_ = c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	bufs := c.wbuf
+	c.wbuf = nil
+	_, err := bufs.WriteTo(c.Conn)
+	return err
+}
+
+func (c *bufConn) SetReadTimeout(t time.Duration) error {
+	c.readTimeout = t
+	return nil
+}
+
+func (c *bufConn) SetWriteTimeout(t time.Duration) error {
+	c.writeTimeout = t
+	return nil
+}
+
+// tlsConn exposes the TLS state of a connection to hertz.
+type tlsConn struct {
+	*bufConn
+	tls *tls.Conn
+}
+
+func (c *tlsConn) Handshake() error {
+	return c.tls.Handshake()
+}
+
+func (c *tlsConn) ConnectionState() tls.ConnectionState {
+	return c.tls.ConnectionState()
+}
@@ -5,11 +5,15 @@ import (
 	"crypto/tls"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/apus-run/sea-kit/log"
 	hertz "github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/network"
 
+	"github.com/apus-run/gaia/internal/inherit"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/transport"
 	thttp "github.com/apus-run/gaia/transport/http"
@@ -18,6 +22,7 @@ import (
 var (
 	_ transport.Server     = (*Server)(nil)
 	_ transport.Endpointer = (*Server)(nil)
+	_ transport.Readier    = (*Server)(nil)
 )
 
 type Server struct {
@@ -31,11 +36,16 @@ type Server struct {
 
 	filters []thttp.FilterFunc
 	ms      []middleware.Middleware
+
+	transport *listenerTransport
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
 func NewServer(opts ...ServerOption) *Server {
 	srv := &Server{
 		timeout: 1 * time.Second,
+		ready:   make(chan struct{}),
 	}
 
 	srv.init(opts...)
@@ -48,7 +58,14 @@ func (s *Server) init(opts ...ServerOption) {
 		o(s)
 	}
 
-	s.Hertz = hertz.Default(hertz.WithHostPorts(s.addr), hertz.WithTLS(s.tlsConf))
+	s.Hertz = hertz.Default(
+		hertz.WithHostPorts(s.addr),
+		hertz.WithTLS(s.tlsConf),
+		hertz.WithTransport(func(o *config.Options) network.Transporter {
+			s.transport = newListenerTransport(o)
+			return s.transport
+		}),
+	)
 }
 
 func (s *Server) Endpoint() (*url.URL, error) {
@@ -73,11 +90,22 @@ func (s *Server) Endpoint() (*url.URL, error) {
 }
 
 func (s *Server) Start(ctx context.Context) error {
-	log.Infof("[hertz] server listening on: %s", s.addr)
+	lis, err := inherit.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.transport.setListener(lis)
+	log.Infof("[hertz] server listening on: %s", lis.Addr().String())
+	s.readyOnce.Do(func() { close(s.ready) })
 
 	return s.Run()
 }
 
+// Ready returns a channel that is closed once the server is serving.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 func (s *Server) Stop(ctx context.Context) error {
 	log.Info("[hertz] server stopping")
 	return s.Shutdown(ctx)
@@ -2,7 +2,10 @@ package hertz
 
 import (
 	"context"
+	"io"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 )
@@ -32,3 +35,36 @@ func TestServer(t *testing.T) {
 		}
 	}()
 }
+
+func TestServer_Ready(t *testing.T) {
+	srv := NewServer(WithAddress("127.0.0.1:0"))
+	srv.GET("/hello", func(ctx context.Context, c *app.RequestContext) {
+		c.String(200, "Hello World!")
+	})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Start(context.Background())
+	}()
+	select {
+	case <-srv.Ready():
+	case err := <-errc:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("expected the server to be ready")
+	}
+	defer func() {
+		if err := srv.Stop(context.Background()); err != nil {
+			t.Errorf("expected nil got %v", err)
+		}
+	}()
+
+	res, err := http.Get("http://" + srv.transport.ln.Addr().String() + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != 200 || string(body) != "Hello World!" {
+		t.Errorf("unexpected response %d %s", res.StatusCode, body)
+	}
+}
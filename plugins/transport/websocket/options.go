@@ -4,10 +4,13 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/apus-run/sea-kit/encoding"
 	ws "github.com/gorilla/websocket"
+
+	"github.com/apus-run/gaia/pkg/metrics"
 )
 
 type PayloadType uint8
@@ -42,6 +45,9 @@ type Server struct {
 	unregister chan *Session
 
 	payloadType PayloadType
+
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
 // defaultServer return a default config server
@@ -66,6 +72,8 @@ func defaultServer() *Server {
 		unregister: make(chan *Session),
 
 		payloadType: PayloadTypeBinary,
+
+		ready: make(chan struct{}),
 	}
 }
 
@@ -131,6 +139,16 @@ func WithPayloadType(payloadType PayloadType) ServerOption {
 	}
 }
 
+// WithMetrics records the sessions of the server as the
+// gaia_websocket_sessions gauge.
+func WithMetrics(r metrics.Recorder) ServerOption {
+	return func(s *Server) {
+		r.GaugeFunc("gaia_websocket_sessions", "The websocket sessions.", func() float64 {
+			return float64(s.SessionCount())
+		})
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type ClientOption func(o *Client)
@@ -27,6 +27,7 @@ type MessageHandlerMap map[MessageType]*HandlerData
 var (
 	_ transport.Server     = (*Server)(nil)
 	_ transport.Endpointer = (*Server)(nil)
+	_ transport.Readier    = (*Server)(nil)
 )
 
 func NewServer(opts ...ServerOption) *Server {
@@ -311,6 +312,7 @@ func (s *Server) Start(ctx context.Context) error {
 		return s.err
 	}
 	s.BaseContext = func(net.Listener) context.Context {
+		s.readyOnce.Do(func() { close(s.ready) })
 		return ctx
 	}
 	LogInfof("server listening on: %s", s.lis.Addr().String())
@@ -329,6 +331,11 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// Ready returns a channel that is closed once the server is serving.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 func (s *Server) Stop(ctx context.Context) error {
 	LogInfo("server stopping")
 	return s.Shutdown(ctx)
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package gaia
+
+import (
+	"os"
+	"syscall"
+)
+
+// restartSignals are the default graceful restart signals.
+var restartSignals = []os.Signal{syscall.SIGUSR2, syscall.SIGHUP}
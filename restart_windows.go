@@ -0,0 +1,9 @@
+//go:build windows
+
+package gaia
+
+import "os"
+
+// restartSignals are the default graceful restart signals, none on windows
+// where listeners can not be passed to a child process.
+var restartSignals []os.Signal
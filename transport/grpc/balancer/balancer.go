@@ -0,0 +1,84 @@
+// Package balancer provides the client load balancers of gaia, registered
+// with grpc-go: round robin, weighted round robin, power of two choices
+// with EWMA latency, and consistent hashing. They pick among the ready
+// nodes passing the node filters of the call, see NodeFilter. The instance
+// metadata of the addresses of the discovery resolver drive the selection,
+// see discovery.ServiceInstance.
+//
+//	conn, err := grpc.DialInsecure(ctx,
+//		grpc.WithEndpoint("discovery:///helloworld"),
+//		grpc.WithDiscovery(r),
+//		grpc.WithBalancer(&balancer.ConsistentHashConfig{Key: "x-md-user"}),
+//	)
+package balancer
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+func init() {
+	balancer.Register(newBuilder(RoundRobin, parseRoundRobinConfig, newRoundRobinPickerBuilder))
+	balancer.Register(newBuilder(WeightedRoundRobin, parseWRRConfig, newWRRPickerBuilder))
+	balancer.Register(newBuilder(P2C, parseP2CConfig, newP2CPickerBuilder))
+	balancer.Register(newBuilder(ConsistentHash, parseConsistentHashConfig, newConsistentHashPickerBuilder))
+}
+
+// pickerBuilder is a base.PickerBuilder configured by the config of the
+// balancer in the service config.
+type pickerBuilder interface {
+	base.PickerBuilder
+	configure(c serviceconfig.LoadBalancingConfig)
+}
+
+// builder builds base balancers with a picker builder per client
+// connection, so the pickers keep their state across the updates of the
+// connection.
+type builder struct {
+	name   string
+	parse  func(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error)
+	picker func() pickerBuilder
+}
+
+func newBuilder(name string, parse func(json.RawMessage) (serviceconfig.LoadBalancingConfig, error), picker func() pickerBuilder) balancer.Builder {
+	return &builder{name: name, parse: parse, picker: picker}
+}
+
+func (b *builder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := b.picker()
+	return &configBalancer{
+		Balancer: base.NewBalancerBuilder(b.name, pb, base.Config{HealthCheck: true}).Build(cc, opts),
+		picker:   pb,
+	}
+}
+
+func (b *builder) Name() string {
+	return b.name
+}
+
+func (b *builder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	return b.parse(js)
+}
+
+// configBalancer configures the picker builder before the base balancer
+// builds the pickers of a new client connection state.
+type configBalancer struct {
+	balancer.Balancer
+	picker pickerBuilder
+}
+
+func (b *configBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	if s.BalancerConfig != nil {
+		b.picker.configure(s.BalancerConfig)
+	}
+	return b.Balancer.UpdateClientConnState(s)
+}
+
+func (b *configBalancer) ExitIdle() {
+	if ei, ok := b.Balancer.(balancer.ExitIdler); ok {
+		ei.ExitIdle()
+	}
+}
@@ -0,0 +1,310 @@
+package balancer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/apus-run/gaia/internal/testdata/helloworld"
+	"github.com/apus-run/gaia/registry"
+	"github.com/apus-run/gaia/transport/grpc/resolver/discovery"
+)
+
+// greeter replies with the address of its server after the delay.
+type greeter struct {
+	pb.UnimplementedGreeterServer
+	addr  string
+	delay time.Duration
+}
+
+func (g *greeter) SayHello(context.Context, *pb.HelloRequest) (*pb.HelloReply, error) {
+	time.Sleep(g.delay)
+	return &pb.HelloReply{Message: g.addr}, nil
+}
+
+// testDiscovery discovers the instances once.
+type testDiscovery struct {
+	instances []*registry.ServiceInstance
+}
+
+func (d *testDiscovery) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return d.instances, nil
+}
+
+func (d *testDiscovery) GetServiceList(context.Context) ([]*registry.ServiceInstance, error) {
+	return d.instances, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	return &testWatcher{ctx: ctx, instances: d.instances}, nil
+}
+
+type testWatcher struct {
+	ctx       context.Context
+	instances []*registry.ServiceInstance
+	sent      bool
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	if !w.sent {
+		w.sent = true
+		return w.instances, nil
+	}
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *testWatcher) Stop() error {
+	return nil
+}
+
+// startServers starts a server per delay and returns their instances and
+// addresses.
+func startServers(t *testing.T, delays ...time.Duration) ([]*registry.ServiceInstance, []string) {
+	instances := make([]*registry.ServiceInstance, 0, len(delays))
+	addrs := make([]string, 0, len(delays))
+	for i, delay := range delays {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := lis.Addr().String()
+		srv := grpc.NewServer()
+		pb.RegisterGreeterServer(srv, &greeter{addr: addr, delay: delay})
+		go func() {
+			_ = srv.Serve(lis)
+		}()
+		t.Cleanup(srv.Stop)
+		instances = append(instances, &registry.ServiceInstance{
+			ID:        strconv.Itoa(i),
+			Name:      "helloworld",
+			Endpoints: []string{"grpc://" + addr + "?isSecure=false"},
+			Metadata:  map[string]string{},
+		})
+		addrs = append(addrs, addr)
+	}
+	return instances, addrs
+}
+
+// dial dials the instances through the discovery resolver with the
+// balancer config.
+func dial(t *testing.T, instances []*registry.ServiceInstance, conf interface{ Name() string }) pb.GreeterClient {
+	lb, err := json.Marshal(map[string]interface{}{conf.Name(): conf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "discovery:///helloworld",
+		grpc.WithResolvers(discovery.NewBuilder(&testDiscovery{instances: instances}, discovery.WithInsecure(true))),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [%s]}`, lb)),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return pb.NewGreeterClient(conn)
+}
+
+func call(t *testing.T, ctx context.Context, client pb.GreeterClient) string {
+	reply, err := client.SayHello(ctx, &pb.HelloRequest{Name: "gaia"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reply.Message
+}
+
+// waitReady calls until every address replied, so every SubConn is ready.
+func waitReady(t *testing.T, client pb.GreeterClient, addrs []string) {
+	seen := make(map[string]bool)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(seen) < len(addrs) {
+		if time.Now().After(deadline) {
+			t.Fatalf("not every address is ready: %v", seen)
+		}
+		seen[call(t, context.Background(), client)] = true
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	instances, addrs := startServers(t, 0, 0, 0)
+	for i, in := range instances {
+		in.Metadata["lb-weight"] = strconv.Itoa(i + 1)
+	}
+	client := dial(t, instances, &WRRConfig{WeightKey: "lb-weight"})
+	waitReady(t, client, addrs)
+
+	counts := make(map[string]int)
+	for i := 0; i < 600; i++ {
+		counts[call(t, context.Background(), client)]++
+	}
+	for i, addr := range addrs {
+		if expected := 100 * (i + 1); counts[addr] != expected {
+			t.Errorf("expect %d calls to %s, got %d", expected, addr, counts[addr])
+		}
+	}
+}
+
+func TestP2C(t *testing.T) {
+	instances, addrs := startServers(t, 0, 20*time.Millisecond)
+	client := dial(t, instances, &P2CConfig{ForcePick: time.Hour})
+	waitReady(t, client, addrs)
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		counts[call(t, context.Background(), client)]++
+	}
+	if counts[addrs[0]] < 95 {
+		t.Errorf("expect the calls to the fast address, got %v", counts)
+	}
+}
+
+func TestConsistentHash(t *testing.T) {
+	instances, addrs := startServers(t, 0, 0, 0)
+	client := dial(t, instances, &ConsistentHashConfig{Key: "x-md-user"})
+	waitReady(t, client, addrs)
+
+	owners := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-md-user", fmt.Sprintf("user-%d", i))
+		addr := call(t, ctx, client)
+		for j := 0; j < 5; j++ {
+			if got := call(t, ctx, client); got != addr {
+				t.Fatalf("expect the calls of user-%d to %s, got %s", i, addr, got)
+			}
+		}
+		owners[addr] = true
+	}
+	if len(owners) < 2 {
+		t.Errorf("expect the keys spread over the addresses, got %v", owners)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	if _, err := parseConsistentHashConfig([]byte(`{}`)); err == nil {
+		t.Error("expect an error without key")
+	}
+	c, err := parseP2CConfig([]byte(`{"decay":1000000000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.(*P2CConfig).Decay != time.Second {
+		t.Errorf("expect %v, got %v", time.Second, c.(*P2CConfig).Decay)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	instances, addrs := startServers(t, 0, 0)
+	client := dial(t, instances, &RoundRobinConfig{})
+	waitReady(t, client, addrs)
+
+	counts := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		counts[call(t, context.Background(), client)]++
+	}
+	for _, addr := range addrs {
+		if counts[addr] != 5 {
+			t.Errorf("expect %d calls to %s, got %d", 5, addr, counts[addr])
+		}
+	}
+}
+
+func TestNodeFilter(t *testing.T) {
+	instances, addrs := startServers(t, 0, 0, 0)
+	instances[0].Version, instances[1].Version, instances[2].Version = "v1", "v1", "v2"
+	instances[0].Metadata["zone"], instances[1].Metadata["zone"], instances[2].Metadata["zone"] = "a", "b", "b"
+	client := dial(t, instances, &RoundRobinConfig{})
+	waitReady(t, client, addrs)
+
+	tests := []struct {
+		name    string
+		filters []NodeFilter
+		header  []string
+		expect  []string
+	}{
+		{"version", []NodeFilter{Version("v2")}, nil, addrs[2:]},
+		{"metadata", []NodeFilter{Metadata("zone", "a")}, nil, addrs[:1]},
+		{"chained", []NodeFilter{Version("v1"), Metadata("zone", "b")}, nil, addrs[1:2]},
+		{"zone", []NodeFilter{ZoneAffinity("zone", "b", 2)}, nil, addrs[1:]},
+		{"zone fallback", []NodeFilter{ZoneAffinity("zone", "a", 2)}, nil, addrs},
+		{"version header", []NodeFilter{VersionHeader("x-md-version")}, []string{"x-md-version", "v1"}, addrs[:2]},
+		{"no version header", []NodeFilter{VersionHeader("x-md-version")}, nil, addrs},
+		{"metadata header", []NodeFilter{MetadataHeader("x-md-zone", "zone")}, []string{"x-md-zone", "b"}, addrs[1:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewFilterContext(metadata.AppendToOutgoingContext(context.Background(), tt.header...), tt.filters...)
+			got := make(map[string]bool)
+			for i := 0; i < 12; i++ {
+				got[call(t, ctx, client)] = true
+			}
+			if len(got) != len(tt.expect) {
+				t.Errorf("expect %v, got %v", tt.expect, got)
+			}
+			for _, addr := range tt.expect {
+				if !got[addr] {
+					t.Errorf("expect calls to %s, got %v", addr, got)
+				}
+			}
+		})
+	}
+
+	ctx := NewFilterContext(context.Background(), Version("v3"))
+	_, err := client.SayHello(ctx, &pb.HelloRequest{Name: "gaia"})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expect %s, got %v", codes.Unavailable, err)
+	}
+}
+
+func TestConsistentHash_filter(t *testing.T) {
+	instances, addrs := startServers(t, 0, 0, 0)
+	instances[0].Version = "v2"
+	client := dial(t, instances, &ConsistentHashConfig{Key: "x-md-user"})
+	waitReady(t, client, addrs)
+
+	for i := 0; i < 10; i++ {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-md-user", fmt.Sprintf("user-%d", i))
+		if got := call(t, NewFilterContext(ctx, Version("v2")), client); got != addrs[0] {
+			t.Errorf("expect the calls to %s, got %s", addrs[0], got)
+		}
+	}
+}
+
+type testSubConn struct {
+	balancer.SubConn
+}
+
+func TestConsistentHash_maxReplicas(t *testing.T) {
+	info := base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{}}
+	for i, weight := range []string{"1", "9223372036854775807", "1000000000"} {
+		addr := resolver.Address{Addr: fmt.Sprintf("127.0.0.%d:9000", i)}
+		addr = discovery.WithServiceInstance(addr, &registry.ServiceInstance{
+			Metadata: map[string]string{DefaultWeightKey: weight},
+		})
+		info.ReadySCs[&testSubConn{}] = base.SubConnInfo{Address: addr}
+	}
+	p, ok := newConsistentHashPickerBuilder().Build(info).(*consistentHashPicker)
+	if !ok {
+		t.Fatal("expected a consistent hash picker")
+	}
+	if expected := defaultReplicas + 2*maxNodeReplicas; len(p.ring) != expected {
+		t.Errorf("expected %d points got %d", expected, len(p.ring))
+	}
+}
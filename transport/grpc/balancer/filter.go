@@ -0,0 +1,104 @@
+package balancer
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Version returns a filter selecting the nodes of the versions, e.g. the
+// canary release of a service.
+func Version(versions ...string) NodeFilter {
+	return func(_ context.Context, nodes []*Node) []*Node {
+		return selectNodes(nodes, func(n *Node) bool {
+			return n.Instance != nil && contains(versions, n.Instance.Version)
+		})
+	}
+}
+
+// Metadata returns a filter selecting the nodes whose instance metadata
+// label key has one of the values, e.g. their cluster.
+func Metadata(key string, values ...string) NodeFilter {
+	return func(_ context.Context, nodes []*Node) []*Node {
+		return selectNodes(nodes, func(n *Node) bool {
+			return hasLabel(n, key, values)
+		})
+	}
+}
+
+// ZoneAffinity returns a filter selecting the nodes of the local zone, the
+// instance metadata label key. It selects every node when fewer than min
+// nodes of the zone are ready, so the calls fail over to the other zones.
+func ZoneAffinity(key, zone string, min int) NodeFilter {
+	if min < 1 {
+		min = 1
+	}
+	return func(_ context.Context, nodes []*Node) []*Node {
+		local := selectNodes(nodes, func(n *Node) bool {
+			return hasLabel(n, key, []string{zone})
+		})
+		if len(local) < min {
+			return nodes
+		}
+		return local
+	}
+}
+
+// VersionHeader returns a filter selecting the nodes of the version in the
+// request header of the call, it selects every node without the header.
+func VersionHeader(header string) NodeFilter {
+	return func(ctx context.Context, nodes []*Node) []*Node {
+		vs := headerValues(ctx, header)
+		if len(vs) == 0 {
+			return nodes
+		}
+		return Version(vs...)(ctx, nodes)
+	}
+}
+
+// MetadataHeader returns a filter selecting the nodes whose instance
+// metadata label key is the value of the request header of the call, it
+// selects every node without the header.
+func MetadataHeader(header, key string) NodeFilter {
+	return func(ctx context.Context, nodes []*Node) []*Node {
+		vs := headerValues(ctx, header)
+		if len(vs) == 0 {
+			return nodes
+		}
+		return Metadata(key, vs...)(ctx, nodes)
+	}
+}
+
+// headerValues returns the values of the request header, the outgoing
+// metadata of the call.
+func headerValues(ctx context.Context, header string) []string {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md.Get(header)
+}
+
+func selectNodes(nodes []*Node, ok func(n *Node) bool) []*Node {
+	selected := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if ok(n) {
+			selected = append(selected, n)
+		}
+	}
+	return selected
+}
+
+func hasLabel(n *Node, key string, values []string) bool {
+	if n.Instance == nil {
+		return false
+	}
+	v, ok := n.Instance.Metadata[key]
+	return ok && contains(values, v)
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
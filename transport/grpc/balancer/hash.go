@@ -0,0 +1,151 @@
+package balancer
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// ConsistentHash is the name of the consistent hashing balancer.
+const ConsistentHash = "gaia_consistent_hash"
+
+const defaultReplicas = 100
+
+// maxNodeReplicas caps the points of an address on the ring, the weights
+// come from the instance metadata and are unbounded.
+const maxNodeReplicas = 100 * defaultReplicas
+
+// ConsistentHashConfig is the config of the consistent hashing balancer,
+// which picks the address of the hash of a request metadata field on a
+// ring of the addresses, so the requests with the same key go to the same
+// address while the addresses don't change. Requests without the key are
+// picked round robin.
+type ConsistentHashConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	// Key is the request metadata field hashed.
+	Key string `json:"key"`
+	// Replicas is the number of points of an address on the ring, in
+	// proportion to the weight of its instance, 100 by default. An address
+	// has at most 10000 points.
+	Replicas int `json:"replicas,omitempty"`
+	// WeightKey is the metadata key of the weight, DefaultWeightKey by
+	// default.
+	WeightKey string `json:"weightKey,omitempty"`
+}
+
+// Name returns the name of the balancer.
+func (*ConsistentHashConfig) Name() string {
+	return ConsistentHash
+}
+
+func parseConsistentHashConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	c := &ConsistentHashConfig{}
+	if err := json.Unmarshal(js, c); err != nil {
+		return nil, err
+	}
+	if c.Key == "" {
+		return nil, errors.New("balancer: no key of consistent hashing")
+	}
+	return c, nil
+}
+
+type consistentHashPickerBuilder struct {
+	key       string
+	replicas  int
+	weightKey string
+}
+
+func newConsistentHashPickerBuilder() pickerBuilder {
+	return &consistentHashPickerBuilder{
+		replicas:  defaultReplicas,
+		weightKey: DefaultWeightKey,
+	}
+}
+
+func (b *consistentHashPickerBuilder) configure(c serviceconfig.LoadBalancingConfig) {
+	c2, ok := c.(*ConsistentHashConfig)
+	if !ok {
+		return
+	}
+	b.key = c2.Key
+	if c2.Replicas > 0 {
+		b.replicas = c2.Replicas
+	}
+	if c2.WeightKey != "" {
+		b.weightKey = c2.WeightKey
+	}
+}
+
+func (b *consistentHashPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes, scs := newNodes(info)
+	p := &consistentHashPicker{key: b.key, nodes: nodes, scs: scs}
+	for _, n := range nodes {
+		replicas := int64(maxNodeReplicas)
+		if w := n.weight(b.weightKey); w <= maxNodeReplicas/int64(b.replicas) {
+			replicas = int64(b.replicas) * w
+		}
+		for i := int64(0); i < replicas; i++ {
+			p.ring = append(p.ring, ringPoint{
+				hash:  crc32.ChecksumIEEE([]byte(n.Address + "#" + strconv.FormatInt(i, 10))),
+				index: n.index,
+			})
+		}
+	}
+	sort.Slice(p.ring, func(i, j int) bool {
+		return p.ring[i].hash < p.ring[j].hash
+	})
+	return p
+}
+
+type ringPoint struct {
+	hash  uint32
+	index int
+}
+
+type consistentHashPicker struct {
+	key   string
+	nodes []*Node
+	scs   []balancer.SubConn
+	ring  []ringPoint
+	next  atomic.Uint64
+}
+
+func (p *consistentHashPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	nodes, err := filterNodes(info.Ctx, p.nodes)
+	if err != nil {
+		return balancer.PickResult{}, err
+	}
+	md, _ := metadata.FromOutgoingContext(info.Ctx)
+	vs := md.Get(p.key)
+	if p.key == "" || len(vs) == 0 {
+		n := p.next.Add(1) - 1
+		return balancer.PickResult{SubConn: p.scs[nodes[n%uint64(len(nodes))].index]}, nil
+	}
+	// the first point of the ring after the hash among the filtered nodes
+	allowed := make([]bool, len(p.nodes))
+	for _, n := range nodes {
+		allowed[n.index] = true
+	}
+	h := crc32.ChecksumIEEE([]byte(vs[0]))
+	start := sort.Search(len(p.ring), func(i int) bool {
+		return p.ring[i].hash >= h
+	})
+	for i := 0; i < len(p.ring); i++ {
+		if pt := p.ring[(start+i)%len(p.ring)]; allowed[pt.index] {
+			return balancer.PickResult{SubConn: p.scs[pt.index]}, nil
+		}
+	}
+	return balancer.PickResult{}, ErrNoAvailable
+}
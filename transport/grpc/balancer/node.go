@@ -0,0 +1,105 @@
+package balancer
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apus-run/gaia/registry"
+	"github.com/apus-run/gaia/transport/grpc/resolver/discovery"
+)
+
+// ErrNoAvailable is returned when the node filters of a call select no
+// ready node.
+var ErrNoAvailable = status.Error(codes.Unavailable, "no available node")
+
+// Node is a ready address of a balancer.
+type Node struct {
+	// Address is the host and port of the node.
+	Address string
+	// Instance is the discovered service instance of the node, it is nil
+	// without discovery.
+	Instance *registry.ServiceInstance
+
+	// index is the index of the node in its picker
+	index int
+}
+
+// NodeFilter selects the nodes a call may be sent to. It returns a subset
+// of the nodes, and can read the context of the call, e.g. its outgoing
+// metadata.
+type NodeFilter func(ctx context.Context, nodes []*Node) []*Node
+
+type filterKey struct{}
+
+// NewFilterContext returns a new Context with the node filters of the
+// calls, after the filters already in the context.
+func NewFilterContext(ctx context.Context, filters ...NodeFilter) context.Context {
+	if len(filters) == 0 {
+		return ctx
+	}
+	prev := FiltersFromContext(ctx)
+	fs := make([]NodeFilter, 0, len(prev)+len(filters))
+	fs = append(append(fs, prev...), filters...)
+	return context.WithValue(ctx, filterKey{}, fs)
+}
+
+// FiltersFromContext returns the node filters in the context.
+func FiltersFromContext(ctx context.Context) []NodeFilter {
+	fs, _ := ctx.Value(filterKey{}).([]NodeFilter)
+	return fs
+}
+
+// newNodes returns the nodes of the ready SubConns sorted by address, and
+// their SubConns by node index.
+func newNodes(info base.PickerBuildInfo) ([]*Node, []balancer.SubConn) {
+	nodes := make([]*Node, 0, len(info.ReadySCs))
+	byAddr := make(map[*Node]balancer.SubConn, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		n := &Node{Address: sci.Address.Addr}
+		n.Instance, _ = discovery.ServiceInstance(sci.Address)
+		nodes = append(nodes, n)
+		byAddr[n] = sc
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Address < nodes[j].Address
+	})
+	scs := make([]balancer.SubConn, len(nodes))
+	for i, n := range nodes {
+		n.index = i
+		scs[i] = byAddr[n]
+	}
+	return nodes, scs
+}
+
+// filterNodes applies the node filters of the call, it returns
+// ErrNoAvailable when no node is left.
+func filterNodes(ctx context.Context, nodes []*Node) ([]*Node, error) {
+	if ctx == nil {
+		return nodes, nil
+	}
+	for _, f := range FiltersFromContext(ctx) {
+		nodes = f(ctx, nodes)
+	}
+	if len(nodes) == 0 {
+		return nil, ErrNoAvailable
+	}
+	return nodes, nil
+}
+
+// weight returns the weight of the node, missing or invalid weights are 1.
+func (n *Node) weight(key string) int64 {
+	if n.Instance == nil {
+		return 1
+	}
+	w, err := strconv.ParseInt(n.Instance.Metadata[key], 10, 64)
+	if err != nil || w <= 0 {
+		return 1
+	}
+	return w
+}
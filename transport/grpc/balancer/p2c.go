@@ -0,0 +1,196 @@
+package balancer
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/serviceconfig"
+	"google.golang.org/grpc/status"
+)
+
+// P2C is the name of the power of two choices balancer.
+const P2C = "gaia_p2c"
+
+const (
+	defaultDecay     = 600 * time.Millisecond
+	defaultForcePick = 3 * time.Second
+	// failurePenalty is the least latency of the calls failing with an
+	// unavailable node, so failing fast doesn't draw the traffic.
+	failurePenalty = 250 * time.Millisecond
+)
+
+// P2CConfig is the config of the power of two choices balancer, which picks
+// two addresses at random and takes the one with the lower load, the EWMA
+// latency of its calls times its in-flight calls.
+type P2CConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	// Decay is the time constant of the EWMA latency, 600ms by default.
+	Decay time.Duration `json:"decay,omitempty"`
+	// ForcePick is the time after which an address that is not picked is
+	// picked anyway to refresh its latency, 3s by default.
+	ForcePick time.Duration `json:"forcePick,omitempty"`
+}
+
+// Name returns the name of the balancer.
+func (*P2CConfig) Name() string {
+	return P2C
+}
+
+func parseP2CConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	c := &P2CConfig{}
+	if err := json.Unmarshal(js, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type p2cPickerBuilder struct {
+	decay     time.Duration
+	forcePick time.Duration
+	// nodes keeps the stats of the SubConns across the pickers
+	nodes map[balancer.SubConn]*p2cNode
+}
+
+func newP2CPickerBuilder() pickerBuilder {
+	return &p2cPickerBuilder{
+		decay:     defaultDecay,
+		forcePick: defaultForcePick,
+		nodes:     make(map[balancer.SubConn]*p2cNode),
+	}
+}
+
+func (b *p2cPickerBuilder) configure(c serviceconfig.LoadBalancingConfig) {
+	c2, ok := c.(*P2CConfig)
+	if !ok {
+		return
+	}
+	if c2.Decay > 0 {
+		b.decay = c2.Decay
+	}
+	if c2.ForcePick > 0 {
+		b.forcePick = c2.ForcePick
+	}
+}
+
+func (b *p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes, scs := newNodes(info)
+	p := &p2cPicker{
+		decay:     b.decay,
+		forcePick: b.forcePick,
+		nodes:     nodes,
+		stats:     make([]*p2cNode, len(nodes)),
+	}
+	for sc := range b.nodes {
+		if _, ok := info.ReadySCs[sc]; !ok {
+			delete(b.nodes, sc)
+		}
+	}
+	for i, sc := range scs {
+		n, ok := b.nodes[sc]
+		if !ok {
+			n = &p2cNode{sc: sc}
+			b.nodes[sc] = n
+		}
+		p.stats[i] = n
+	}
+	return p
+}
+
+type p2cPicker struct {
+	decay     time.Duration
+	forcePick time.Duration
+	nodes     []*Node
+	stats     []*p2cNode
+}
+
+func (p *p2cPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	nodes, err := filterNodes(info.Ctx, p.nodes)
+	if err != nil {
+		return balancer.PickResult{}, err
+	}
+	now := time.Now()
+	picked := p.stats[nodes[0].index]
+	if n := len(nodes); n > 1 {
+		i := rand.Intn(n)
+		j := rand.Intn(n - 1)
+		if j >= i {
+			j++
+		}
+		a, b := p.stats[nodes[i].index], p.stats[nodes[j].index]
+		if b.load() < a.load() {
+			a, b = b, a
+		}
+		picked = a
+		// the other one is picked once in a while to refresh its latency
+		if last := b.picked.Load(); now.UnixNano()-last > int64(p.forcePick) && b.picked.CompareAndSwap(last, now.UnixNano()) {
+			picked = b
+		}
+	}
+	picked.picked.Store(now.UnixNano())
+	picked.inflight.Add(1)
+	return balancer.PickResult{
+		SubConn: picked.sc,
+		Done: func(info balancer.DoneInfo) {
+			picked.inflight.Add(-1)
+			rtt := time.Since(now)
+			switch status.Code(info.Err) {
+			case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+				if rtt < failurePenalty {
+					rtt = failurePenalty
+				}
+			}
+			picked.observe(rtt, p.decay)
+		},
+	}, nil
+}
+
+// p2cNode is the stats of a SubConn.
+type p2cNode struct {
+	sc       balancer.SubConn
+	inflight atomic.Int64
+	// picked is the unix nano time of the last pick
+	picked atomic.Int64
+
+	mu    sync.Mutex
+	lag   float64
+	stamp time.Time
+}
+
+// load is the EWMA latency times the in-flight calls, the addresses
+// without calls yet have the lowest loads.
+func (n *p2cNode) load() float64 {
+	n.mu.Lock()
+	lag := n.lag
+	n.mu.Unlock()
+	return (lag + 1) * float64(n.inflight.Load()+1)
+}
+
+// observe updates the EWMA latency, weighting the last latency by the time
+// since the previous one.
+func (n *p2cNode) observe(rtt, decay time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	if n.stamp.IsZero() {
+		n.lag = float64(rtt)
+	} else {
+		td := now.Sub(n.stamp)
+		if td < 0 {
+			td = 0
+		}
+		w := math.Exp(-float64(td) / float64(decay))
+		n.lag = n.lag*w + float64(rtt)*(1-w)
+	}
+	n.stamp = now
+}
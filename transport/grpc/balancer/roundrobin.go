@@ -0,0 +1,60 @@
+package balancer
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// RoundRobin is the name of the round robin balancer, the default balancer
+// of the clients.
+const RoundRobin = "gaia_round_robin"
+
+// RoundRobinConfig is the config of the round robin balancer, which picks
+// the nodes in turn.
+type RoundRobinConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+}
+
+// Name returns the name of the balancer.
+func (*RoundRobinConfig) Name() string {
+	return RoundRobin
+}
+
+func parseRoundRobinConfig(json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	return &RoundRobinConfig{}, nil
+}
+
+type roundRobinPickerBuilder struct{}
+
+func newRoundRobinPickerBuilder() pickerBuilder {
+	return roundRobinPickerBuilder{}
+}
+
+func (roundRobinPickerBuilder) configure(serviceconfig.LoadBalancingConfig) {}
+
+func (roundRobinPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes, scs := newNodes(info)
+	return &roundRobinPicker{nodes: nodes, scs: scs}
+}
+
+type roundRobinPicker struct {
+	nodes []*Node
+	scs   []balancer.SubConn
+	next  atomic.Uint64
+}
+
+func (p *roundRobinPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	nodes, err := filterNodes(info.Ctx, p.nodes)
+	if err != nil {
+		return balancer.PickResult{}, err
+	}
+	n := p.next.Add(1) - 1
+	return balancer.PickResult{SubConn: p.scs[nodes[n%uint64(len(nodes))].index]}, nil
+}
@@ -0,0 +1,103 @@
+package balancer
+
+import (
+	"encoding/json"
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// WeightedRoundRobin is the name of the weighted round robin balancer.
+const WeightedRoundRobin = "gaia_weighted_round_robin"
+
+// DefaultWeightKey is the metadata key of the weight of an instance.
+const DefaultWeightKey = "weight"
+
+// WRRConfig is the config of the weighted round robin balancer, which picks
+// the addresses in turn in proportion to the weights of their instances,
+// spreading the picks of heavy addresses evenly.
+type WRRConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	// WeightKey is the metadata key of the weight, DefaultWeightKey by
+	// default. Missing or invalid weights are 1.
+	WeightKey string `json:"weightKey,omitempty"`
+}
+
+// Name returns the name of the balancer.
+func (*WRRConfig) Name() string {
+	return WeightedRoundRobin
+}
+
+func parseWRRConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	c := &WRRConfig{}
+	if err := json.Unmarshal(js, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type wrrPickerBuilder struct {
+	weightKey string
+}
+
+func newWRRPickerBuilder() pickerBuilder {
+	return &wrrPickerBuilder{weightKey: DefaultWeightKey}
+}
+
+func (b *wrrPickerBuilder) configure(c serviceconfig.LoadBalancingConfig) {
+	if c, ok := c.(*WRRConfig); ok && c.WeightKey != "" {
+		b.weightKey = c.WeightKey
+	}
+}
+
+func (b *wrrPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes, scs := newNodes(info)
+	p := &wrrPicker{nodes: nodes, states: make([]*wrrState, len(nodes))}
+	for i, n := range nodes {
+		p.states[i] = &wrrState{sc: scs[i], weight: n.weight(b.weightKey)}
+	}
+	return p
+}
+
+// wrrPicker is the smooth weighted round robin of nginx, over the nodes
+// passing the filters of the call.
+type wrrPicker struct {
+	mu     sync.Mutex
+	nodes  []*Node
+	states []*wrrState
+}
+
+type wrrState struct {
+	sc      balancer.SubConn
+	weight  int64
+	current int64
+}
+
+func (p *wrrPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	nodes, err := filterNodes(info.Ctx, p.nodes)
+	if err != nil {
+		return balancer.PickResult{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var (
+		total int64
+		best  *wrrState
+	)
+	for _, n := range nodes {
+		s := p.states[n.index]
+		s.current += s.weight
+		total += s.weight
+		if best == nil || s.current > best.current {
+			best = s
+		}
+	}
+	best.current -= total
+	return balancer.PickResult{SubConn: best.sc}, nil
+}
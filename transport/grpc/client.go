@@ -2,17 +2,18 @@ package grpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/balancer/roundrobin"
 	"google.golang.org/grpc/credentials"
 	grpcInsecure "google.golang.org/grpc/credentials/insecure"
 
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/pkg/tls"
 	"github.com/apus-run/gaia/registry"
+	"github.com/apus-run/gaia/transport/grpc/balancer"
 	"github.com/apus-run/gaia/transport/grpc/resolver/discovery"
 )
 
@@ -27,14 +28,23 @@ type Client struct {
 	streamInts             []grpc.StreamClientInterceptor
 	grpcOpts               []grpc.DialOption
 	balancerName           string
+	balancerConfig         BalancerConfig
+	filters                []balancer.NodeFilter
 	printDiscoveryDebugLog bool
 }
 
+// BalancerConfig is the config of a load balancer, it is sent as the JSON
+// config of the balancer in the service config, see package balancer.
+type BalancerConfig interface {
+	// Name returns the name of the balancer.
+	Name() string
+}
+
 // defaultClient return a default config server
 func defaultClient() *Client {
 	return &Client{
 		timeout:                2000 * time.Millisecond,
-		balancerName:           roundrobin.Name,
+		balancerName:           balancer.RoundRobin,
 		printDiscoveryDebugLog: true,
 	}
 }
@@ -88,6 +98,26 @@ func WithStreamInterceptor(in ...grpc.StreamClientInterceptor) ClientOption {
 func WithBalancerName(name string) ClientOption {
 	return func(c *Client) {
 		c.balancerName = name
+		c.balancerConfig = nil
+	}
+}
+
+// WithBalancer with a load balancer and its selection options, e.g.
+// &balancer.P2CConfig{Decay: time.Second}.
+func WithBalancer(conf BalancerConfig) ClientOption {
+	return func(c *Client) {
+		c.balancerName = conf.Name()
+		c.balancerConfig = conf
+	}
+}
+
+// WithNodeFilter with the node filters of the calls, e.g.
+// balancer.Version("v2"). They are applied by the balancers of package
+// balancer, with the filters of the context of the call, see
+// balancer.NewFilterContext.
+func WithNodeFilter(filters ...balancer.NodeFilter) ClientOption {
+	return func(c *Client) {
+		c.filters = filters
 	}
 }
 
@@ -132,7 +162,7 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		options.unaryClientInterceptor(options.ms, options.timeout),
 	}
 	sints := []grpc.StreamClientInterceptor{
-		options.streamClientInterceptor(),
+		options.streamClientInterceptor(options.ms),
 	}
 	if len(options.ints) > 0 {
 		ints = append(ints, options.ints...)
@@ -140,8 +170,16 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	if len(options.streamInts) > 0 {
 		sints = append(sints, options.streamInts...)
 	}
+	lbConfig := fmt.Sprintf(`{"%s":{}}`, options.balancerName)
+	if options.balancerConfig != nil {
+		b, err := json.Marshal(map[string]BalancerConfig{options.balancerName: options.balancerConfig})
+		if err != nil {
+			return nil, fmt.Errorf("balancer config error - %v", err)
+		}
+		lbConfig = string(b)
+	}
 	grpcOpts := []grpc.DialOption{
-		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}],"healthCheckConfig":{"serviceName":""}}`, options.balancerName)),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [%s],"healthCheckConfig":{"serviceName":""}}`, lbConfig)),
 		grpc.WithChainUnaryInterceptor(ints...),
 		grpc.WithChainStreamInterceptor(sints...),
 	}
@@ -3,12 +3,16 @@ package grpc
 import (
 	"context"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
+	pb "github.com/apus-run/gaia/internal/testdata/helloworld"
 	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/transport/grpc/balancer"
 )
 
 func EmptyMiddleware() middleware.Middleware {
@@ -34,6 +38,42 @@ func TestUnaryClientInterceptor(t *testing.T) {
 	}
 }
 
+func TestUnaryClientInterceptor_hedged(t *testing.T) {
+	o := &Client{}
+	hedge := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, _ = handler(middleware.NewAttemptContext(ctx, middleware.Attempt{Index: i, Hedged: true}), req)
+				}(i)
+			}
+			wg.Wait()
+			return nil, nil
+		}
+	}
+	f := o.unaryClientInterceptor([]middleware.Middleware{hedge}, 0)
+	reply := &pb.HelloReply{}
+	err := f(context.TODO(), "hello", &pb.HelloRequest{}, reply, &grpc.ClientConn{},
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			reply.(*pb.HelloReply).Message = "hello"
+			for _, o := range opts {
+				if h, ok := o.(grpc.HeaderCallOption); ok {
+					*h.HeaderAddr = metadata.Pairs("k", "v")
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if reply.Message != "hello" {
+		t.Errorf("expect the reply of an attempt, got %q", reply.Message)
+	}
+}
+
 func TestWithUnaryInterceptor(t *testing.T) {
 	o := &Client{}
 	v := []grpc.UnaryClientInterceptor{
@@ -50,6 +90,43 @@ func TestWithUnaryInterceptor(t *testing.T) {
 	}
 }
 
+type testBalancerConfig struct {
+	Key string `json:"key"`
+}
+
+func (*testBalancerConfig) Name() string {
+	return "test_balancer"
+}
+
+func TestWithBalancer(t *testing.T) {
+	o := &Client{}
+	conf := &testBalancerConfig{Key: "x-md-user"}
+	WithBalancer(conf)(o)
+	if o.balancerName != "test_balancer" || !reflect.DeepEqual(conf, o.balancerConfig) {
+		t.Errorf("expect %v but got %s %v", conf, o.balancerName, o.balancerConfig)
+	}
+	WithBalancerName("round_robin")(o)
+	if o.balancerName != "round_robin" || o.balancerConfig != nil {
+		t.Errorf("expect round_robin without config but got %s %v", o.balancerName, o.balancerConfig)
+	}
+}
+
+func TestWithNodeFilter(t *testing.T) {
+	o := &Client{}
+	WithNodeFilter(balancer.Version("v2"))(o)
+	f := o.unaryClientInterceptor(nil, 0)
+	err := f(context.TODO(), "hello", &struct{}{}, &struct{}{}, &grpc.ClientConn{},
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if n := len(balancer.FiltersFromContext(ctx)); n != 1 {
+				t.Errorf("expect 1 filter, got %d", n)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestDial(t *testing.T) {
 	o := &Client{}
 	v := []grpc.DialOption{
@@ -0,0 +1,180 @@
+package grpc
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apus-run/sea-kit/log"
+)
+
+// sniffTimeout bounds the time to read the first bytes of a connection.
+const sniffTimeout = 10 * time.Second
+
+// http2Preface starts the connections of gRPC clients.
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// gateway reports whether the server also serves HTTP requests, with
+// gRPC-Web or HTTP/JSON transcoding.
+func (s *Server) gateway() bool {
+	return s.grpcWeb || s.transcoder != nil
+}
+
+// serveGateway serves the HTTP requests of the listener, gRPC requests over
+// HTTP/2 are served by the gRPC server.
+func (s *Server) serveGateway(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case s.grpcWeb && isGrpcWeb(r):
+		s.serveGrpcWeb(w, r)
+	case r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc"):
+		s.Server.ServeHTTP(w, r)
+	case s.transcoder != nil:
+		s.transcoder.ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serve serves the listener. With TLS, every request is served by the HTTP
+// server. Without TLS, connections starting with the HTTP/2 preface are
+// served by the gRPC server and the others by the HTTP server.
+func (s *Server) serve() error {
+	if !s.gateway() {
+		return s.Serve(s.lis)
+	}
+	if s.tlsConf != nil {
+		t, err := s.tlsConf.Config()
+		if err != nil {
+			return err
+		}
+		s.http.TLSConfig = t.Clone()
+		if err = s.http.ServeTLS(s.lis, "", ""); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	}
+
+	grpcLis, httpLis := newConnListener(s.lis), newConnListener(s.lis)
+	go splitListener(s.lis, grpcLis, httpLis)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Serve(grpcLis)
+	}()
+	err := s.http.Serve(httpLis)
+	if err == http.ErrServerClosed {
+		err = nil
+	}
+	if gerr := <-errc; err == nil {
+		err = gerr
+	}
+	return err
+}
+
+// splitListener accepts the connections of the listener until it is
+// closed, and sends them to the gRPC or the HTTP listener by their first
+// bytes.
+func splitListener(lis net.Listener, grpcLis, httpLis *connListener) {
+	defer grpcLis.Close()
+	defer httpLis.Close()
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return
+		}
+		go func() {
+			c, isGRPC, err := sniff(conn)
+			if err != nil {
+				log.Debugf("[gRPC] failed to read the connection preface: %v", err)
+				_ = conn.Close()
+				return
+			}
+			if isGRPC {
+				grpcLis.send(c)
+			} else {
+				httpLis.send(c)
+			}
+		}()
+	}
+}
+
+// sniff reads the first bytes of the connection until they differ from the
+// HTTP/2 preface, and returns a connection reading them again.
+func sniff(conn net.Conn) (net.Conn, bool, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(sniffTimeout)); err != nil {
+		return nil, false, err
+	}
+	br := bufio.NewReaderSize(conn, len(http2Preface))
+	isGRPC := true
+	for i := 1; i <= len(http2Preface); i++ {
+		b, err := br.Peek(i)
+		if err != nil {
+			return nil, false, err
+		}
+		if !bytes.HasPrefix(http2Preface, b) {
+			isGRPC = false
+			break
+		}
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, false, err
+	}
+	return &sniffedConn{Conn: conn, r: br}, isGRPC, nil
+}
+
+type sniffedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *sniffedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// connListener is a listener of the connections sent by splitListener.
+type connListener struct {
+	addr  net.Addr
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newConnListener(lis net.Listener) *connListener {
+	return &connListener{
+		addr:  lis.Addr(),
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *connListener) send(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		_ = conn.Close()
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
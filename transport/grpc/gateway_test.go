@@ -0,0 +1,295 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/apus-run/gaia/internal/testdata/helloworld"
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/transport"
+)
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		vars     map[string]string
+		ok       bool
+	}{
+		{"/hello/{name}", "/hello/gaia", map[string]string{"name": "gaia"}, true},
+		{"/hello/{name}", "/hello/a%2Fb", map[string]string{"name": "a/b"}, true},
+		{"/hello/{name}", "/hello/gaia/x", nil, false},
+		{"/hello/{name}", "/hello/", nil, false},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/1/books/2", map[string]string{"name": "shelves/1/books/2"}, true},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/1/notes/2", nil, false},
+		{"/v1/{book.name=books/*}:publish", "/v1/books/1:publish", map[string]string{"book.name": "books/1"}, true},
+		{"/v1/{book.name=books/*}:publish", "/v1/books/1", nil, false},
+		{"/files/{path=**}", "/files/a/b/c", map[string]string{"path": "a/b/c"}, true},
+		{"/files/**", "/files", map[string]string{}, true},
+		{"/v1/*/items", "/v1/x/items", map[string]string{}, true},
+	}
+	for _, tt := range tests {
+		tpl, err := parsePathTemplate(tt.template)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.template, err)
+		}
+		vars, ok := tpl.match(tt.path)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(vars, tt.vars)) {
+			t.Errorf("%s %s: expected %v %v got %v %v", tt.template, tt.path, tt.vars, tt.ok, vars, ok)
+		}
+	}
+
+	for _, tpl := range []string{"hello", "/a/**/b", "/a//b", "/{}", "/{name"} {
+		if _, err := parsePathTemplate(tpl); err == nil {
+			t.Errorf("%s: expected an error", tpl)
+		}
+	}
+}
+
+// gatewayServer implements SayHelloPost, bound to POST /hello.
+type gatewayServer struct {
+	server
+}
+
+func (s *gatewayServer) SayHelloPost(_ context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+func newGatewayServer(t *testing.T, opts ...ServerOption) (*Server, string) {
+	opts = append([]ServerOption{
+		Address("127.0.0.1:0"),
+		Middleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if tr, ok := transport.FromServerContext(ctx); ok {
+					tr.ReplyHeader().Set("x-operation", tr.Operation())
+				}
+				if r, ok := req.(*pb.HelloRequest); ok && r.Name == "nobody" {
+					return nil, errcode.ErrNotFound.WithReason("USER_NOT_FOUND")
+				}
+				return handler(ctx, req)
+			}
+		}),
+	}, opts...)
+	srv := NewServer(opts...)
+	pb.RegisterGreeterServer(srv, &gatewayServer{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = srv.Stop(context.Background())
+	})
+	<-srv.Ready()
+	return srv, u.Host
+}
+
+func TestServer_Transcoding(t *testing.T) {
+	_, addr := newGatewayServer(t, Transcoding())
+	base := "http://" + addr
+
+	res, err := http.Get(base + "/hello/gaia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(body), `"message":"Hello gaia"`) {
+		t.Errorf("unexpected response %d %s", res.StatusCode, body)
+	}
+	if op := res.Header.Get("x-operation"); op != "/helloworld.Greeter/SayHello" {
+		t.Errorf("expected the reply header of the middleware, got %q", op)
+	}
+
+	res, err = http.Post(base+"/hello?name=post", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(body), `"message":"Hello post"`) {
+		t.Errorf("unexpected response %d %s", res.StatusCode, body)
+	}
+
+	res, err = http.Get(base + "/hello/nobody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e errorBody
+	_ = json.NewDecoder(res.Body).Decode(&e)
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound || e.Code != errcode.ErrNotFound.Code() || e.Reason != "USER_NOT_FOUND" {
+		t.Errorf("unexpected error %d %+v", res.StatusCode, e)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, base+"/hello", nil)
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed || res.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("unexpected response %d %v", res.StatusCode, res.Header)
+	}
+
+	res, err = http.Get(base + "/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 got %d", res.StatusCode)
+	}
+
+	// gRPC clients share the listener
+	conn, err := DialInsecure(context.Background(), WithEndpoint(addr), WithGrpcOptions(grpc.WithBlock()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reply, err := pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "grpc"})
+	if err != nil || reply.Message != "Hello grpc" {
+		t.Errorf("unexpected reply %v %v", reply, err)
+	}
+}
+
+func TestServer_TranscodingBodyLimit(t *testing.T) {
+	srv, addr := newGatewayServer(t, Transcoding(), MaxRecvMsgSize(32))
+	base := "http://" + addr
+	// the test rule of POST /hello has no body
+	srv.transcoder.mu.Lock()
+	for _, rule := range srv.transcoder.rules {
+		if rule.method == http.MethodPost {
+			rule.body = "*"
+		}
+	}
+	srv.transcoder.mu.Unlock()
+
+	res, err := http.Post(base+"/hello", "application/json", strings.NewReader(`{"name":"gaia"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(body), `"message":"Hello gaia"`) {
+		t.Errorf("unexpected response %d %s", res.StatusCode, body)
+	}
+
+	res, err = http.Post(base+"/hello", "application/json", strings.NewReader(`{"name":"`+strings.Repeat("a", 64)+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e errorBody
+	_ = json.NewDecoder(res.Body).Decode(&e)
+	res.Body.Close()
+	if res.StatusCode != http.StatusRequestEntityTooLarge || e.Code != errcode.ErrLimitExceed.Code() {
+		t.Errorf("unexpected error %d %+v", res.StatusCode, e)
+	}
+}
+
+func webFrame(flag byte, data []byte) []byte {
+	frame := make([]byte, 5, 5+len(data))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+// readWebFrames returns the message and the trailers of a gRPC-Web body.
+func readWebFrames(t *testing.T, body []byte) ([]byte, string) {
+	var msg []byte
+	for len(body) >= 5 {
+		n := binary.BigEndian.Uint32(body[1:5])
+		data := body[5 : 5+n]
+		if body[0]&0x80 != 0 {
+			return msg, string(data)
+		}
+		msg = data
+		body = body[5+n:]
+	}
+	t.Fatalf("no trailer frame in %q", body)
+	return nil, ""
+}
+
+func TestServer_GrpcWeb(t *testing.T) {
+	_, addr := newGatewayServer(t, GrpcWeb())
+	url := "http://" + addr + "/helloworld.Greeter/SayHello"
+
+	for _, text := range []bool{false, true} {
+		for _, name := range []string{"web", "nobody"} {
+			data, _ := proto.Marshal(&pb.HelloRequest{Name: name})
+			body := webFrame(0, data)
+			contentType := "application/grpc-web+proto"
+			if text {
+				body = []byte(base64.StdEncoding.EncodeToString(body))
+				contentType = "application/grpc-web-text+proto"
+			}
+			res, err := http.Post(url, contentType, bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ = io.ReadAll(res.Body)
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != contentType {
+				t.Fatalf("unexpected response %d %v", res.StatusCode, res.Header)
+			}
+			if text {
+				if body, err = decodeWebText(body); err != nil {
+					t.Fatal(err)
+				}
+			}
+			msg, trailer := readWebFrames(t, body)
+			if name == "nobody" {
+				if !strings.Contains(trailer, "grpc-status: 5\r\n") || !strings.Contains(trailer, "grpc-status-details-bin: ") {
+					t.Errorf("expected a not found status, got %q", trailer)
+				}
+				continue
+			}
+			if !strings.Contains(trailer, "grpc-status: 0\r\n") {
+				t.Errorf("expected an OK status, got %q", trailer)
+			}
+			reply := &pb.HelloReply{}
+			if err = proto.Unmarshal(msg, reply); err != nil || reply.Message != "Hello web" {
+				t.Errorf("unexpected reply %v %v", reply, err)
+			}
+			if op := res.Header.Get("x-operation"); op != "/helloworld.Greeter/SayHello" {
+				t.Errorf("expected the reply header of the middleware, got %q", op)
+			}
+		}
+	}
+}
+
+// decodeWebText decodes a body of padded base64 chunks.
+func decodeWebText(body []byte) ([]byte, error) {
+	var out []byte
+	for len(body) > 0 {
+		i := bytes.IndexByte(body, '=')
+		n := len(body)
+		if i >= 0 {
+			for n = i; n < len(body) && body[n] == '='; n++ {
+			}
+		}
+		chunk, err := base64.StdEncoding.DecodeString(string(body[:n]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+		body = body[n:]
+	}
+	return out, nil
+}
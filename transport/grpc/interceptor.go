@@ -2,20 +2,32 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"io"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 
 	ic "github.com/apus-run/gaia/internal/context"
 	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
 	"github.com/apus-run/gaia/transport"
+	"github.com/apus-run/gaia/transport/grpc/balancer"
 )
 
-// wrappedStream is rewrite grpc stream's context
+// wrappedStream is rewrite grpc stream's context, it runs the middleware
+// for every message and sends the reply header of the transport with the
+// response headers.
 type wrappedStream struct {
 	grpc.ServerStream
 	ctx context.Context
+	ms  []middleware.Middleware
+
+	header metadata.MD
+	once   sync.Once
 }
 
 func NewWrappedStream(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
@@ -29,6 +41,116 @@ func (w *wrappedStream) Context() context.Context {
 	return w.ctx
 }
 
+// flushHeader sets the reply header before the response headers are sent.
+func (w *wrappedStream) flushHeader() {
+	w.once.Do(func() {
+		if len(w.header) > 0 {
+			_ = w.ServerStream.SetHeader(w.header)
+		}
+	})
+}
+
+func (w *wrappedStream) SendHeader(md metadata.MD) error {
+	w.flushHeader()
+	return w.ServerStream.SendHeader(md)
+}
+
+func (w *wrappedStream) SendMsg(m any) error {
+	h := func(_ context.Context, req any) (any, error) {
+		w.flushHeader()
+		return req, w.ServerStream.SendMsg(req)
+	}
+	if len(w.ms) > 0 {
+		h = middleware.Chain(w.ms...)(h)
+	}
+	_, err := h(middleware.NewMessageContext(w.ctx, middleware.SendMessage), m)
+	return err
+}
+
+func (w *wrappedStream) RecvMsg(m any) error {
+	if err := w.ServerStream.RecvMsg(m); err != nil || len(w.ms) == 0 {
+		return err
+	}
+	h := func(_ context.Context, req any) (any, error) {
+		return req, nil
+	}
+	_, err := middleware.Chain(w.ms...)(h)(middleware.NewMessageContext(w.ctx, middleware.RecvMessage), m)
+	return err
+}
+
+// wrappedClientStream runs the middleware for every message of a client
+// stream and fills the reply header of the transport with the response
+// headers.
+type wrappedClientStream struct {
+	grpc.ClientStream
+	ctx context.Context
+	ms  []middleware.Middleware
+
+	header headerCarrier
+	once   sync.Once
+}
+
+func (w *wrappedClientStream) Context() context.Context {
+	return w.ctx
+}
+
+// fillHeader copies the response headers to the reply header, it blocks
+// until they are received.
+func (w *wrappedClientStream) fillHeader() {
+	w.once.Do(func() {
+		md, err := w.ClientStream.Header()
+		if err != nil {
+			return
+		}
+		for k, v := range md {
+			w.header[k] = v
+		}
+	})
+}
+
+func (w *wrappedClientStream) Header() (metadata.MD, error) {
+	md, err := w.ClientStream.Header()
+	if err == nil {
+		w.fillHeader()
+	}
+	return md, err
+}
+
+func (w *wrappedClientStream) SendMsg(m any) error {
+	h := func(_ context.Context, req any) (any, error) {
+		return req, w.ClientStream.SendMsg(req)
+	}
+	if len(w.ms) > 0 {
+		h = middleware.Chain(w.ms...)(h)
+	}
+	_, err := h(middleware.NewMessageContext(w.ctx, middleware.SendMessage), m)
+	return streamError(err)
+}
+
+func (w *wrappedClientStream) RecvMsg(m any) error {
+	if err := w.ClientStream.RecvMsg(m); err != nil {
+		return streamError(err)
+	}
+	w.fillHeader()
+	if len(w.ms) == 0 {
+		return nil
+	}
+	h := func(_ context.Context, req any) (any, error) {
+		return req, nil
+	}
+	_, err := middleware.Chain(w.ms...)(h)(middleware.NewMessageContext(w.ctx, middleware.RecvMessage), m)
+	return err
+}
+
+// streamError converts the errors of a client stream but io.EOF, which ends
+// the stream.
+func streamError(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+	return errcode.FromError(err)
+}
+
 // unaryServerInterceptor is a gRPC unary server interceptor
 func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
@@ -62,32 +184,81 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 		if len(replyHeader) > 0 {
 			_ = grpc.SetHeader(ctx, replyHeader)
 		}
-		return reply, err
+		return reply, toStatusError(err)
 	}
 }
 
-// streamServerInterceptor is a gRPC stream server interceptor
+// streamServerInterceptor is a gRPC stream server interceptor. The
+// middleware matching the method run once for the stream with a
+// *middleware.StreamInfo request, then once for every message.
 func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		ctx, cancel := ic.Merge(ss.Context(), s.ctx)
 		defer cancel()
 		md, _ := metadata.FromIncomingContext(ctx)
 		replyHeader := metadata.MD{}
-		ctx = transport.NewServerContext(ctx, &Transport{
-			endpoint:    s.endpoint.String(),
+		tr := &Transport{
 			operation:   info.FullMethod,
 			reqHeader:   headerCarrier(md),
 			replyHeader: headerCarrier(replyHeader),
-		})
+		}
+		if s.endpoint != nil {
+			tr.endpoint = s.endpoint.String()
+		}
 
-		ws := NewWrappedStream(ctx, ss)
+		ctx = transport.NewServerContext(ctx, tr)
 
-		err := handler(srv, ws)
-		if len(replyHeader) > 0 {
-			_ = grpc.SetHeader(ctx, replyHeader)
+		next := s.middleware.Match(tr.Operation())
+		var ws *wrappedStream
+		h := func(ctx context.Context, _ any) (any, error) {
+			ws = &wrappedStream{
+				ServerStream: ss,
+				ctx:          ctx,
+				ms:           next,
+				header:       replyHeader,
+			}
+			return nil, handler(srv, ws)
 		}
-		return err
+		if len(next) > 0 {
+			h = middleware.Chain(next...)(h)
+		}
+
+		_, err := h(ctx, &middleware.StreamInfo{
+			ClientStream: info.IsClientStream,
+			ServerStream: info.IsServerStream,
+		})
+		if ws != nil {
+			ws.flushHeader()
+		} else if len(replyHeader) > 0 {
+			_ = ss.SetHeader(replyHeader)
+		}
+		return toStatusError(err)
+	}
+}
+
+// toStatusError sends wrapped errcode errors with their reason and metadata.
+func toStatusError(err error) error {
+	var e *errcode.Error
+	if errors.As(err, &e) {
+		return e.GRPCStatus().Err()
+	}
+	return err
+}
+
+// outgoingContext appends the request header of the client transport to
+// the outgoing metadata.
+func outgoingContext(ctx context.Context) context.Context {
+	tr, ok := transport.FromClientContext(ctx)
+	if !ok {
+		return ctx
+	}
+	header := tr.RequestHeader()
+	keys := header.Keys()
+	keyvals := make([]string, 0, len(keys))
+	for _, k := range keys {
+		keyvals = append(keyvals, k, header.Get(k))
 	}
+	return metadata.AppendToOutgoingContext(ctx, keyvals...)
 }
 
 // unaryClientInterceptor client unary interceptor
@@ -98,11 +269,14 @@ func (c *Client) unaryClientInterceptor(ms []middleware.Middleware, timeout time
 		cc *grpc.ClientConn,
 		invoker grpc.UnaryInvoker,
 		opts ...grpc.CallOption) error {
+		replyHeader := headerCarrier{}
 		ctx = transport.NewClientContext(ctx, &Transport{
-			endpoint:  cc.Target(),
-			operation: method,
-			reqHeader: headerCarrier{},
+			endpoint:    cc.Target(),
+			operation:   method,
+			reqHeader:   headerCarrier{},
+			replyHeader: replyHeader,
 		})
+		ctx = balancer.NewFilterContext(ctx, c.filters...)
 
 		if timeout > 0 {
 			var cancel context.CancelFunc
@@ -110,17 +284,20 @@ func (c *Client) unaryClientInterceptor(ms []middleware.Middleware, timeout time
 			defer cancel()
 		}
 
+		var hedged hedgedReply
 		h := func(ctx context.Context, req any) (any, error) {
-			if tr, ok := transport.FromClientContext(ctx); ok {
-				header := tr.RequestHeader()
-				keys := header.Keys()
-				keyvals := make([]string, 0, len(keys))
-				for _, k := range keys {
-					keyvals = append(keyvals, k, header.Get(k))
-				}
-				ctx = metadata.AppendToOutgoingContext(ctx, keyvals...)
+			if a, ok := middleware.FromAttemptContext(ctx); ok && a.Hedged {
+				return hedged.invoke(ctx, method, req, reply, replyHeader, cc, invoker, opts...)
 			}
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			var header metadata.MD
+			err := invoker(outgoingContext(ctx), method, req, reply, cc, append(opts, grpc.Header(&header))...)
+			for k, v := range header {
+				replyHeader[k] = v
+			}
+			if err != nil {
+				return reply, errcode.FromError(err)
+			}
+			return reply, nil
 		}
 
 		if len(ms) > 0 {
@@ -133,19 +310,89 @@ func (c *Client) unaryClientInterceptor(ms []middleware.Middleware, timeout time
 	}
 }
 
-func (c *Client) streamClientInterceptor() grpc.StreamClientInterceptor {
+// streamClientInterceptor client stream interceptor, the middleware run
+// once for the stream with a *middleware.StreamInfo request, then once for
+// every message.
+func (c *Client) streamClientInterceptor(ms []middleware.Middleware) grpc.StreamClientInterceptor {
 	return func(ctx context.Context,
 		desc *grpc.StreamDesc,
 		cc *grpc.ClientConn,
 		method string,
 		streamer grpc.Streamer,
 		opts ...grpc.CallOption) (grpc.ClientStream, error) { // nolint
+		replyHeader := headerCarrier{}
 		ctx = transport.NewClientContext(ctx, &Transport{
-			endpoint:  cc.Target(),
-			reqHeader: headerCarrier{},
-			operation: method,
+			endpoint:    cc.Target(),
+			operation:   method,
+			reqHeader:   headerCarrier{},
+			replyHeader: replyHeader,
 		})
+		ctx = balancer.NewFilterContext(ctx, c.filters...)
+
+		h := func(ctx context.Context, _ any) (any, error) {
+			cs, err := streamer(outgoingContext(ctx), desc, cc, method, opts...)
+			if err != nil {
+				return nil, errcode.FromError(err)
+			}
+			return &wrappedClientStream{
+				ClientStream: cs,
+				ctx:          ctx,
+				ms:           ms,
+				header:       replyHeader,
+			}, nil
+		}
 
-		return streamer(ctx, desc, cc, method, opts...)
+		if len(ms) > 0 {
+			h = middleware.Chain(ms...)(h)
+		}
+
+		reply, err := h(ctx, &middleware.StreamInfo{
+			ClientStream: desc.ClientStreams,
+			ServerStream: desc.ServerStreams,
+		})
+		if err != nil {
+			return nil, err
+		}
+		cs, ok := reply.(grpc.ClientStream)
+		if !ok {
+			return nil, errcode.ErrInternalServer.WithDetails("stream middleware returned no stream")
+		}
+		return cs, nil
+	}
+}
+
+// hedgedReply is the reply of the concurrent attempts of a call, each one
+// decodes a copy of the reply and the first successful one is committed
+// with its header. The replies that are not proto messages are shared.
+type hedgedReply struct {
+	mu        sync.Mutex
+	committed bool
+}
+
+func (r *hedgedReply) invoke(ctx context.Context, method string, req, reply any, replyHeader headerCarrier,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (any, error) {
+	out := reply
+	m, ok := reply.(proto.Message)
+	if ok {
+		out = m.ProtoReflect().New().Interface()
+	}
+	var header metadata.MD
+	err := invoker(outgoingContext(ctx), method, req, out, cc, append(opts, grpc.Header(&header))...)
+	if err != nil {
+		return reply, errcode.FromError(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.committed {
+		return reply, nil
+	}
+	r.committed = true
+	if ok {
+		proto.Reset(m)
+		proto.Merge(m, out.(proto.Message))
+	}
+	for k, v := range header {
+		replyHeader[k] = v
 	}
+	return reply, nil
 }
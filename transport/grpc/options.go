@@ -3,15 +3,19 @@ package grpc
 import (
 	"context"
 	"net"
+	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 
+	apphealth "github.com/apus-run/gaia/health"
 	"github.com/apus-run/gaia/internal/matcher"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/pkg/tls"
+	"github.com/apus-run/gaia/transport/http/status"
 )
 
 // Server is a gRPC server wrapper.
@@ -29,21 +33,41 @@ type Server struct {
 	unaryInterceptor  []grpc.UnaryServerInterceptor
 	streamInterceptor []grpc.StreamServerInterceptor
 	grpcOpts          []grpc.ServerOption
+	maxRecvMsgSize    int
 	health            *health.Server
 
 	customHealth bool
+	aggregator   *apphealth.Health
 	adminClean   func()
+
+	// gRPC-Web and HTTP/JSON transcoding
+	grpcWeb      bool
+	transcoding  bool
+	transcoder   *transcoder
+	converter    status.Converter
+	http         *http.Server
+	interceptors []grpc.UnaryServerInterceptor
+
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
+// defaultMaxRecvMsgSize is the default max size of received messages of
+// grpc-go.
+const defaultMaxRecvMsgSize = 4 * 1024 * 1024
+
 // defaultServer return a default config server
 func defaultServer() *Server {
 	return &Server{
-		ctx:        context.Background(),
-		network:    "tcp",
-		address:    ":0",
-		timeout:    1 * time.Second,
-		health:     health.NewServer(),
-		middleware: matcher.New(),
+		ctx:            context.Background(),
+		network:        "tcp",
+		address:        ":0",
+		timeout:        1 * time.Second,
+		maxRecvMsgSize: defaultMaxRecvMsgSize,
+		health:         health.NewServer(),
+		middleware:     matcher.New(),
+		converter:      status.DefaultConverter,
+		ready:          make(chan struct{}),
 	}
 }
 
@@ -92,6 +116,14 @@ func CustomHealth() ServerOption {
 	}
 }
 
+// Health with the health aggregator whose readiness drives the status of the
+// server and of every service in the health server.
+func Health(h *apphealth.Health) ServerOption {
+	return func(s *Server) {
+		s.aggregator = h
+	}
+}
+
 // TLSConfig with TLS config.
 func TLSConfig(c *tls.TLS) ServerOption {
 	return func(s *Server) {
@@ -126,3 +158,39 @@ func GrpcOptions(opts ...grpc.ServerOption) ServerOption {
 		s.grpcOpts = opts
 	}
 }
+
+// MaxRecvMsgSize with the max size in bytes of the messages the server can
+// receive, 4MB by default, it also limits the body of transcoded requests.
+// Use it rather than grpc.MaxRecvMsgSize in GrpcOptions, which the
+// transcoder does not know of.
+func MaxRecvMsgSize(n int) ServerOption {
+	return func(s *Server) {
+		s.maxRecvMsgSize = n
+	}
+}
+
+// GrpcWeb serves gRPC-Web requests on the listener of the server, through
+// the interceptors and the middleware of the server.
+func GrpcWeb() ServerOption {
+	return func(s *Server) {
+		s.grpcWeb = true
+	}
+}
+
+// Transcoding serves the unary methods of the registered services as
+// HTTP/JSON on the listener of the server, by the google.api.http rules of
+// their descriptors. Requests go through the unary interceptors and the
+// middleware of the server.
+func Transcoding() ServerOption {
+	return func(s *Server) {
+		s.transcoding = true
+	}
+}
+
+// StatusConverter with the converter of the gRPC codes of transcoded errors
+// into HTTP statuses.
+func StatusConverter(c status.Converter) ServerOption {
+	return func(s *Server) {
+		s.converter = c
+	}
+}
@@ -9,9 +9,12 @@ import (
 
 	"google.golang.org/grpc"
 
+	apphealth "github.com/apus-run/gaia/health"
+	"github.com/apus-run/gaia/internal/matcher"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/pkg/tls"
 	"github.com/apus-run/gaia/registry"
+	"github.com/apus-run/gaia/transport/http/status"
 )
 
 func TestNetwork(t *testing.T) {
@@ -39,13 +42,13 @@ func TestAddress(t *testing.T) {
 }
 
 func TestMiddleware(t *testing.T) {
-	o := &Server{}
+	o := &Server{middleware: matcher.New()}
 	v := []middleware.Middleware{
 		func(middleware.Handler) middleware.Handler { return nil },
 	}
 	Middleware(v...)(o)
-	if !reflect.DeepEqual(v, o.middleware) {
-		t.Errorf("expect %v, got %v", v, o.middleware)
+	if ms := o.middleware.Match("/foo"); len(ms) != len(v) {
+		t.Errorf("expect %v, got %v", v, ms)
 	}
 }
 
@@ -187,3 +190,47 @@ func (m *mockRegistry) GetService(ctx context.Context, serviceName string) ([]*r
 func (m *mockRegistry) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
 	return nil, nil
 }
+
+func TestHealth(t *testing.T) {
+	o := &Server{}
+	v := apphealth.New()
+	Health(v)(o)
+	if !reflect.DeepEqual(v, o.aggregator) {
+		t.Errorf("expect %v, got %v", v, o.aggregator)
+	}
+}
+
+func TestMaxRecvMsgSize(t *testing.T) {
+	if o := NewServer(); o.maxRecvMsgSize != defaultMaxRecvMsgSize {
+		t.Errorf("expect %d, got %d", defaultMaxRecvMsgSize, o.maxRecvMsgSize)
+	}
+	if o := NewServer(MaxRecvMsgSize(1024)); o.maxRecvMsgSize != 1024 {
+		t.Errorf("expect 1024, got %d", o.maxRecvMsgSize)
+	}
+}
+
+func TestGrpcWeb(t *testing.T) {
+	o := NewServer(GrpcWeb())
+	if !o.grpcWeb || o.transcoder != nil || o.http == nil {
+		t.Errorf("expect gRPC-Web only, got %v %v", o.grpcWeb, o.transcoder)
+	}
+}
+
+func TestTranscoding(t *testing.T) {
+	o := NewServer(Transcoding())
+	if o.transcoder == nil || o.http == nil {
+		t.Error("expect a transcoder")
+	}
+	if o := NewServer(); o.gateway() || o.http != nil {
+		t.Error("expect no HTTP server by default")
+	}
+}
+
+func TestStatusConverter(t *testing.T) {
+	o := &Server{}
+	v := status.DefaultConverter
+	StatusConverter(v)(o)
+	if !reflect.DeepEqual(v, o.converter) {
+		t.Errorf("expect %v, got %v", v, o.converter)
+	}
+}
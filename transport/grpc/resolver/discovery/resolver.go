@@ -67,8 +67,7 @@ func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
 			Attributes: parseAttributes(in.Metadata),
 			Addr:       ept,
 		}
-		addr.Attributes = addr.Attributes.WithValue("rawServiceInstance", in)
-		addrs = append(addrs, addr)
+		addrs = append(addrs, WithServiceInstance(addr, in))
 	}
 	if len(addrs) == 0 {
 		log.Warnf("[resolver] Zero endpoint found,refused to write, instances: %v", ins)
@@ -95,6 +94,23 @@ func (r *discoveryResolver) Close() {
 
 func (r *discoveryResolver) ResolveNow(_ resolver.ResolveNowOptions) {}
 
+type instanceKey struct{}
+
+// WithServiceInstance returns the address with the service instance it is
+// resolved from, for the load balancers. The instance is a balancer
+// attribute, so it doesn't recreate the connections on every update.
+func WithServiceInstance(addr resolver.Address, in *registry.ServiceInstance) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(instanceKey{}, in)
+	return addr
+}
+
+// ServiceInstance returns the service instance the address is resolved
+// from, if any.
+func ServiceInstance(addr resolver.Address) (*registry.ServiceInstance, bool) {
+	in, ok := addr.BalancerAttributes.Value(instanceKey{}).(*registry.ServiceInstance)
+	return in, ok
+}
+
 func parseAttributes(md map[string]string) *attributes.Attributes {
 	var a *attributes.Attributes
 	for k, v := range md {
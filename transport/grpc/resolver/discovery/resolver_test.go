@@ -125,3 +125,20 @@ func TestParseAttributes(t *testing.T) {
 		t.Errorf("expect nil, got %v", x.Value("notfound"))
 	}
 }
+
+func TestServiceInstance(t *testing.T) {
+	in := &registry.ServiceInstance{ID: "1", Metadata: map[string]string{"weight": "10"}}
+	addr := WithServiceInstance(resolver.Address{Addr: "127.0.0.1:9000"}, in)
+	got, ok := ServiceInstance(addr)
+	if !ok || got != in {
+		t.Errorf("expect %v, got %v", in, got)
+	}
+	if _, ok = ServiceInstance(resolver.Address{Addr: "127.0.0.1:9000"}); ok {
+		t.Error("expect no instance")
+	}
+	// the instance doesn't make the addresses of the same endpoint differ
+	other := WithServiceInstance(resolver.Address{Addr: "127.0.0.1:9000"}, &registry.ServiceInstance{ID: "1"})
+	if !addr.Attributes.Equal(other.Attributes) {
+		t.Error("expect equal attributes")
+	}
+}
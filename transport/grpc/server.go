@@ -2,7 +2,7 @@ package grpc
 
 import (
 	"context"
-	"net"
+	"net/http"
 	"net/url"
 
 	"github.com/apus-run/sea-kit/log"
@@ -11,8 +11,10 @@ import (
 	hapi "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	apphealth "github.com/apus-run/gaia/health"
 	"github.com/apus-run/gaia/internal/endpoint"
 	"github.com/apus-run/gaia/internal/host"
+	"github.com/apus-run/gaia/internal/inherit"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/transport"
 	"google.golang.org/grpc/admin"
@@ -21,6 +23,8 @@ import (
 var (
 	_ transport.Server     = (*Server)(nil)
 	_ transport.Endpointer = (*Server)(nil)
+	_ transport.Readier    = (*Server)(nil)
+	_ transport.Drainer    = (*Server)(nil)
 )
 
 // NewServer creates a gRPC server by options.
@@ -45,6 +49,7 @@ func NewServer(opts ...ServerOption) *Server {
 	grpcOpts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(unaryInterceptor...),
 		grpc.ChainStreamInterceptor(streamInterceptor...),
+		grpc.MaxRecvMsgSize(srv.maxRecvMsgSize),
 	}
 	if srv.tlsConf != nil {
 		t, err := srv.tlsConf.Config()
@@ -58,10 +63,20 @@ func NewServer(opts ...ServerOption) *Server {
 	}
 
 	srv.Server = grpc.NewServer(grpcOpts...)
+	srv.interceptors = unaryInterceptor
+	if srv.transcoding {
+		srv.transcoder = newTranscoder(srv)
+	}
+	if srv.gateway() {
+		srv.http = &http.Server{Handler: http.HandlerFunc(srv.serveGateway)}
+	}
 	// internal register
 	if !srv.customHealth {
 		hapi.RegisterHealthServer(srv.Server, srv.health)
 	}
+	if srv.aggregator != nil {
+		srv.aggregator.OnChange(srv.updateHealth)
+	}
 
 	// register reflection and the interface can be debugged through the grpcurl tool
 	// https://github.com/grpc/grpc-go/blob/master/Documentation/server-reflection-tutorial.md#enable-server-reflection
@@ -72,6 +87,30 @@ func NewServer(opts ...ServerOption) *Server {
 	return srv
 }
 
+// RegisterService registers a service and its implementation to the gRPC
+// server, and its google.api.http rules when transcoding is enabled.
+func (s *Server) RegisterService(sd *grpc.ServiceDesc, ss interface{}) {
+	s.Server.RegisterService(sd, ss)
+	if s.transcoder != nil {
+		s.transcoder.register(sd, ss)
+	}
+}
+
+// interceptor chains the unary interceptors of the server, for transcoded
+// requests.
+func (s *Server) interceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		h := handler
+		for i := len(s.interceptors) - 1; i >= 0; i-- {
+			in, next := s.interceptors[i], h
+			h = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return in(ctx, req, info, next)
+			}
+		}
+		return h(ctx, req)
+	}
+}
+
 // Use uses a service middleware with selector.
 // selector:
 //   - '/*'
@@ -100,7 +139,39 @@ func (s *Server) Start(ctx context.Context) error {
 	s.ctx = ctx
 	log.Infof("[gRPC] server listening on: %s", s.lis.Addr().String())
 	s.health.Resume()
-	return s.Serve(s.lis)
+	if s.aggregator != nil {
+		s.updateHealth()
+	}
+	// the listener is already bound, connections queue until Serve accepts them
+	s.readyOnce.Do(func() { close(s.ready) })
+	return s.serve()
+}
+
+// Ready returns a channel that is closed once the server is serving.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Drain reports NOT_SERVING for every service through the health server.
+func (s *Server) Drain() {
+	s.health.Shutdown()
+}
+
+// updateHealth reports the readiness of the health aggregator for the server
+// as a whole and for every registered service. It has no effect once the
+// server is draining.
+func (s *Server) updateHealth() {
+	s.health.SetServingStatus("", servingStatus(s.aggregator.Readiness()))
+	for name := range s.GetServiceInfo() {
+		s.health.SetServingStatus(name, servingStatus(s.aggregator.Service(name)))
+	}
+}
+
+func servingStatus(r apphealth.Report) hapi.HealthCheckResponse_ServingStatus {
+	if r.Status == apphealth.StatusUp {
+		return hapi.HealthCheckResponse_SERVING
+	}
+	return hapi.HealthCheckResponse_NOT_SERVING
 }
 
 // Stop stop the gRPC server.
@@ -111,12 +182,19 @@ func (s *Server) Stop(ctx context.Context) error {
 	s.health.Shutdown()
 	s.GracefulStop()
 	log.Info("[gRPC] server stopping")
+	if s.http != nil {
+		err := s.http.Shutdown(ctx)
+		if s.lis != nil {
+			_ = s.lis.Close()
+		}
+		return err
+	}
 	return nil
 }
 
 func (s *Server) listenAndEndpoint() error {
 	if s.lis == nil {
-		lis, err := net.Listen(s.network, s.address)
+		lis, err := inherit.Listen(s.network, s.address)
 		if err != nil {
 			s.err = err
 			return err
@@ -2,18 +2,24 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	hapi "google.golang.org/grpc/health/grpc_health_v1"
 
+	apphealth "github.com/apus-run/gaia/health"
 	"github.com/apus-run/gaia/internal/matcher"
 	pb "github.com/apus-run/gaia/internal/testdata/helloworld"
 	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/errcode"
 	"github.com/apus-run/gaia/transport"
 )
 
@@ -192,3 +198,218 @@ func TestServer_unaryServerInterceptor(t *testing.T) {
 		t.Errorf("expect %s, got %s", "hi", rv.(*testResp).Data)
 	}
 }
+
+func TestServer_Ready(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	select {
+	case <-srv.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("server is not ready")
+	}
+	_ = srv.Stop(ctx)
+}
+
+func TestServer_Health(t *testing.T) {
+	ctx := context.Background()
+	h := apphealth.New()
+	h.AddReadiness("cache", apphealth.CheckerFunc(func(context.Context) error {
+		return errors.New("down")
+	}), apphealth.Services("helloworld.Greeter"))
+
+	srv := NewServer(Health(h))
+	pb.RegisterGreeterServer(srv, &server{})
+	go func() {
+		_ = h.Start(ctx)
+	}()
+	defer h.Stop(ctx)
+	<-h.Ready()
+
+	tests := map[string]hapi.HealthCheckResponse_ServingStatus{
+		"":                      hapi.HealthCheckResponse_NOT_SERVING,
+		"helloworld.Greeter":    hapi.HealthCheckResponse_NOT_SERVING,
+		"grpc.health.v1.Health": hapi.HealthCheckResponse_SERVING,
+	}
+	for service, want := range tests {
+		res, err := srv.health.Check(ctx, &hapi.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != want {
+			t.Errorf("%q: expected %v got %v", service, want, res.Status)
+		}
+	}
+}
+
+func TestServer_errcode(t *testing.T) {
+	srv := NewServer(Address("127.0.0.1:0"))
+	// the health checks of the client are streams served by the middleware too
+	srv.Use("/helloworld.Greeter/*", func(middleware.Handler) middleware.Handler {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("wrapped: %w", errcode.ErrNotFound.
+				WithReason("USER_NOT_FOUND").
+				WithMetadata(map[string]string{"id": "1"}).
+				WithDetails("user 1"))
+		}
+	})
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() {
+		_ = srv.Stop(context.Background())
+	}()
+
+	conn, err := DialInsecure(context.Background(), WithEndpoint(u.Host), WithGrpcOptions(grpc.WithBlock()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, err = pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "gaia"})
+	var e *errcode.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected an *errcode.Error, got %T %v", err, err)
+	}
+	if !errors.Is(err, errcode.ErrNotFound.WithReason("USER_NOT_FOUND")) {
+		t.Errorf("expected the not found error, got %v", err)
+	}
+	if e.GRPCCode() != codes.NotFound || e.StatusCode() != 404 {
+		t.Errorf("expected NotFound and 404, got %s and %d", e.GRPCCode(), e.StatusCode())
+	}
+	if !reflect.DeepEqual(e.Metadata(), map[string]string{"id": "1"}) || !reflect.DeepEqual(e.Details(), []string{"user 1"}) {
+		t.Errorf("unexpected metadata %v or details %v", e.Metadata(), e.Details())
+	}
+}
+
+func TestServer_streamMiddleware(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls = make(map[string][]string)
+	)
+	record := func(side string) middleware.Middleware {
+		return func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				mu.Lock()
+				if kind, ok := middleware.FromMessageContext(ctx); ok {
+					calls[side] = append(calls[side], fmt.Sprintf("%s %T", kind, req))
+				} else if info, ok := req.(*middleware.StreamInfo); ok {
+					calls[side] = append(calls[side], fmt.Sprintf("stream %v %v", info.ClientStream, info.ServerStream))
+				}
+				mu.Unlock()
+				if _, ok := middleware.FromMessageContext(ctx); !ok {
+					if tr, ok := transport.FromServerContext(ctx); ok {
+						if tr.RequestHeader().Get("x-md-trace") != "2233" {
+							return nil, errcode.ErrInvalidParam.WithDetails("no trace header")
+						}
+						tr.ReplyHeader().Set("x-md-reply", "4455")
+					}
+					if tr, ok := transport.FromClientContext(ctx); ok {
+						tr.RequestHeader().Set("x-md-trace", "2233")
+					}
+				}
+				if in, ok := req.(*pb.HelloRequest); ok && in.Name == "invalid" && side == "server" {
+					return nil, errcode.ErrInvalidParam.WithReason("INVALID_NAME")
+				}
+				return handler(ctx, req)
+			}
+		}
+	}
+
+	srv := NewServer(Address("127.0.0.1:0"))
+	srv.Use("/helloworld.Greeter/SayHelloStream", record("server"))
+	srv.Use("/helloworld.Greeter/SayHello", func(middleware.Handler) middleware.Handler {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, errors.New("unexpected unary middleware")
+		}
+	})
+	pb.RegisterGreeterServer(srv, &server{})
+	u, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	defer func() {
+		_ = srv.Stop(context.Background())
+	}()
+
+	conn, err := DialInsecure(context.Background(),
+		WithEndpoint(u.Host),
+		WithGrpcOptions(grpc.WithBlock()),
+		WithMiddleware(record("client")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	stream, err := pb.NewGreeterClient(conn).SayHelloStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.Send(&pb.HelloRequest{Name: "cc"}); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Message != "hello cc" {
+		t.Errorf("expect %s, got %s", "hello cc", reply.Message)
+	}
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := header.Get("x-md-reply"); len(v) != 1 || v[0] != "4455" {
+		t.Errorf("expect the reply header set by the middleware, got %v", header)
+	}
+	if v := header.Get("123"); len(v) != 1 || v[0] != "123" {
+		t.Errorf("expect the reply header set by the handler, got %v", header)
+	}
+
+	if err = stream.Send(&pb.HelloRequest{Name: "invalid"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	if !errors.Is(err, errcode.ErrInvalidParam.WithReason("INVALID_NAME")) {
+		t.Errorf("expected the error of the middleware, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := map[string][]string{
+		"client": {
+			"stream true true",
+			"send *helloworld.HelloRequest",
+			"recv *helloworld.HelloReply",
+			"send *helloworld.HelloRequest",
+		},
+		"server": {
+			"stream true true",
+			"recv *helloworld.HelloRequest",
+			"send *helloworld.HelloReply",
+			"recv *helloworld.HelloRequest",
+		},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expect %v, got %v", expected, calls)
+	}
+}
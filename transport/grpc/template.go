@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// pathTemplate is the path template of a google.api.http rule, e.g.
+// /v1/{name=shelves/*/books/*}:publish. Segments are literals, "*" matching
+// one segment or "**" matching the rest of the path.
+type pathTemplate struct {
+	segments []string
+	verb     string
+	vars     []templateVar
+}
+
+// templateVar is a variable matching the segments [start, end).
+type templateVar struct {
+	field      string
+	start, end int
+}
+
+func parsePathTemplate(tpl string) (*pathTemplate, error) {
+	if !strings.HasPrefix(tpl, "/") {
+		return nil, fmt.Errorf("grpc: path template %q must start with /", tpl)
+	}
+	t := &pathTemplate{}
+	path := tpl[1:]
+	if i := strings.LastIndex(path, ":"); i > strings.LastIndex(path, "/") && i > strings.LastIndex(path, "}") {
+		t.verb, path = path[i+1:], path[:i]
+	}
+	for _, token := range splitTemplate(path) {
+		if !strings.HasPrefix(token, "{") {
+			t.segments = append(t.segments, token)
+			continue
+		}
+		if !strings.HasSuffix(token, "}") {
+			return nil, fmt.Errorf("grpc: invalid variable %q in path template %q", token, tpl)
+		}
+		field, pattern, ok := strings.Cut(token[1:len(token)-1], "=")
+		if !ok {
+			pattern = "*"
+		}
+		if field == "" {
+			return nil, fmt.Errorf("grpc: empty variable in path template %q", tpl)
+		}
+		v := templateVar{field: field, start: len(t.segments)}
+		t.segments = append(t.segments, strings.Split(pattern, "/")...)
+		v.end = len(t.segments)
+		t.vars = append(t.vars, v)
+	}
+	for i, seg := range t.segments {
+		if seg == "" || strings.ContainsAny(seg, "{}=") {
+			return nil, fmt.Errorf("grpc: invalid segment %q in path template %q", seg, tpl)
+		}
+		if seg == "**" && i != len(t.segments)-1 {
+			return nil, fmt.Errorf("grpc: ** must be the last segment of path template %q", tpl)
+		}
+	}
+	return t, nil
+}
+
+// splitTemplate splits a path template by the slashes out of variables.
+func splitTemplate(path string) []string {
+	var (
+		tokens []string
+		depth  int
+		start  int
+	)
+	for i, c := range path {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 {
+				tokens = append(tokens, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(tokens, path[start:])
+}
+
+// literals returns the number of literal segments, templates with more
+// literals take precedence.
+func (t *pathTemplate) literals() int {
+	n := 0
+	for _, seg := range t.segments {
+		if seg != "*" && seg != "**" {
+			n++
+		}
+	}
+	return n
+}
+
+// match matches an escaped path and returns the unescaped variables.
+func (t *pathTemplate) match(path string) (map[string]string, bool) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+	path = path[1:]
+	if t.verb != "" {
+		if !strings.HasSuffix(path, ":"+t.verb) {
+			return nil, false
+		}
+		path = strings.TrimSuffix(path, ":"+t.verb)
+	}
+	parts := strings.Split(path, "/")
+	for i, seg := range t.segments {
+		if seg == "**" {
+			break
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		if (seg == "*" && parts[i] == "") || (seg != "*" && seg != parts[i]) {
+			return nil, false
+		}
+		if i == len(t.segments)-1 && len(parts) != len(t.segments) {
+			return nil, false
+		}
+	}
+	vars := make(map[string]string, len(t.vars))
+	for _, v := range t.vars {
+		end := v.end
+		if t.segments[end-1] == "**" {
+			end = len(parts)
+		}
+		values := make([]string, 0, end-v.start)
+		for _, p := range parts[v.start:end] {
+			s, err := url.PathUnescape(p)
+			if err != nil {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		vars[v.field] = strings.Join(values, "/")
+	}
+	return vars, true
+}
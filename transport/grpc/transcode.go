@@ -0,0 +1,377 @@
+package grpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apus-run/sea-kit/log"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/apus-run/gaia/encoding/form"
+	ejson "github.com/apus-run/gaia/encoding/json"
+	"github.com/apus-run/gaia/pkg/errcode"
+)
+
+// httpRule is a google.api.http binding of a unary method.
+type httpRule struct {
+	method       string
+	template     *pathTemplate
+	body         string
+	responseBody string
+
+	fullMethod string
+	desc       grpc.MethodDesc
+	impl       interface{}
+	input      protoreflect.MessageDescriptor
+	output     protoreflect.MessageDescriptor
+}
+
+// transcoder serves the unary methods of the registered services as
+// HTTP/JSON by their google.api.http rules, through the unary interceptors
+// of the server.
+type transcoder struct {
+	srv   *Server
+	mu    sync.RWMutex
+	rules []*httpRule
+}
+
+func newTranscoder(srv *Server) *transcoder {
+	return &transcoder{srv: srv}
+}
+
+// register adds the google.api.http rules of the unary methods of the
+// service, its descriptor is looked up in the global registry.
+func (t *transcoder) register(sd *grpc.ServiceDesc, impl interface{}) {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(sd.ServiceName))
+	if err != nil {
+		log.Warnf("[gRPC] no descriptor of service %s to transcode: %v", sd.ServiceName, err)
+		return
+	}
+	desc, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, md := range sd.Methods {
+		m := desc.Methods().ByName(protoreflect.Name(md.MethodName))
+		if m == nil {
+			continue
+		}
+		rule, ok := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+			hr, err := newHTTPRule(r)
+			if err != nil {
+				log.Errorf("[gRPC] invalid http rule of %s/%s: %v", sd.ServiceName, md.MethodName, err)
+				continue
+			}
+			hr.fullMethod = fmt.Sprintf("/%s/%s", sd.ServiceName, md.MethodName)
+			hr.desc = md
+			hr.impl = impl
+			hr.input = m.Input()
+			hr.output = m.Output()
+			t.rules = append(t.rules, hr)
+		}
+	}
+	// more literal segments take precedence, the order of the rules is kept
+	// otherwise
+	sort.SliceStable(t.rules, func(i, j int) bool {
+		return t.rules[i].template.literals() > t.rules[j].template.literals()
+	})
+}
+
+func newHTTPRule(rule *annotations.HttpRule) (*httpRule, error) {
+	var method, path string
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		method, path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		method, path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		method, path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		method, path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		method, path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		method, path = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("no pattern")
+	}
+	tpl, err := parsePathTemplate(path)
+	if err != nil {
+		return nil, err
+	}
+	return &httpRule{
+		method:       method,
+		template:     tpl,
+		body:         rule.GetBody(),
+		responseBody: rule.GetResponseBody(),
+	}, nil
+}
+
+func (t *transcoder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.EscapedPath()
+	var (
+		rule  *httpRule
+		vars  map[string]string
+		allow []string
+	)
+	t.mu.RLock()
+	for _, hr := range t.rules {
+		v, ok := hr.template.match(path)
+		if !ok {
+			continue
+		}
+		if hr.method == r.Method {
+			rule, vars = hr, v
+			break
+		}
+		allow = append(allow, hr.method)
+	}
+	t.mu.RUnlock()
+
+	if rule == nil {
+		if len(allow) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		sort.Strings(allow)
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	t.serve(w, r, rule, vars)
+}
+
+// serve calls the method through the unary interceptors of the server, with
+// the request headers as incoming metadata. The body is limited to the max
+// size of the messages the server receives.
+func (t *transcoder) serve(w http.ResponseWriter, r *http.Request, rule *httpRule, vars map[string]string) {
+	r.Body = http.MaxBytesReader(w, r.Body, int64(t.srv.maxRecvMsgSize))
+	var tooLarge *http.MaxBytesError
+	stream := &transcodeStream{method: rule.fullMethod}
+	ctx := metadata.NewIncomingContext(r.Context(), incomingMetadata(r))
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+	dec := func(v interface{}) error {
+		m, ok := v.(proto.Message)
+		if !ok {
+			return fmt.Errorf("grpc: %T is not a proto message", v)
+		}
+		if err := decodeRequest(r, rule, vars, m); err != nil {
+			if errors.As(err, &tooLarge) {
+				return errcode.ErrLimitExceed.WithDetails(err.Error())
+			}
+			return errcode.ErrInvalidParam.WithDetails(err.Error())
+		}
+		return nil
+	}
+	reply, err := rule.desc.Handler(rule.impl, ctx, dec, t.srv.interceptor())
+
+	header := w.Header()
+	stream.mu.Lock()
+	for k, vs := range stream.header {
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+	for k, vs := range stream.trailer {
+		for _, v := range vs {
+			header.Add("Grpc-Trailer-"+k, v)
+		}
+	}
+	stream.mu.Unlock()
+
+	if err != nil && tooLarge != nil {
+		t.writeError(w, errcode.FromError(err), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		t.encodeError(w, err)
+		return
+	}
+	data, err := encodeReply(rule, reply)
+	if err != nil {
+		t.encodeError(w, err)
+		return
+	}
+	header.Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// errorBody is the body of transcoded errors, see transport/http.
+type errorBody struct {
+	Code     int               `json:"code"`
+	Reason   string            `json:"reason,omitempty"`
+	Domain   string            `json:"domain,omitempty"`
+	Msg      string            `json:"msg"`
+	Details  []string          `json:"details,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// encodeError writes the error with the HTTP status converted from its gRPC
+// code.
+func (t *transcoder) encodeError(w http.ResponseWriter, err error) {
+	e := errcode.FromError(err)
+	t.writeError(w, e, t.srv.converter.FromGRPCCode(status.Code(e)))
+}
+
+// writeError writes the error with the HTTP status code.
+func (t *transcoder) writeError(w http.ResponseWriter, e *errcode.Error, code int) {
+	data, _ := json.Marshal(errorBody{
+		Code:     e.Code(),
+		Reason:   e.Reason(),
+		Domain:   e.Domain(),
+		Msg:      e.Msg(),
+		Details:  e.Details(),
+		Metadata: e.Metadata(),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+// decodeRequest decodes the body as the body field of the rule, then the
+// query parameters when the body is not the whole request, then the path
+// variables.
+func decodeRequest(r *http.Request, rule *httpRule, vars map[string]string, m proto.Message) error {
+	if rule.body != "" {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 {
+			if err = decodeBody(rule, data, m); err != nil {
+				return err
+			}
+		}
+	}
+	if rule.body != "*" {
+		if err := form.DecodeValues(m, r.URL.Query()); err != nil {
+			return err
+		}
+	}
+	if len(vars) > 0 {
+		vs := make(url.Values, len(vars))
+		for k, v := range vars {
+			vs.Set(k, v)
+		}
+		if err := form.DecodeValues(m, vs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeBody(rule *httpRule, data []byte, m proto.Message) error {
+	if rule.body == "*" {
+		return ejson.UnmarshalOptions.Unmarshal(data, m)
+	}
+	fd := rule.input.Fields().ByName(protoreflect.Name(rule.body))
+	if fd == nil {
+		return fmt.Errorf("unknown body field %q", rule.body)
+	}
+	// the body is the value of the field in a message of the input type
+	key, err := json.Marshal(fd.JSONName())
+	if err != nil {
+		return err
+	}
+	wrapped := make([]byte, 0, len(data)+len(key)+3)
+	wrapped = append(append(append(append(wrapped, '{'), key...), ':'), data...)
+	wrapped = append(wrapped, '}')
+	tmp := m.ProtoReflect().New().Interface()
+	if err = ejson.UnmarshalOptions.Unmarshal(wrapped, tmp); err != nil {
+		return err
+	}
+	proto.Merge(m, tmp)
+	return nil
+}
+
+// encodeReply encodes the reply, or its response body field, as JSON.
+func encodeReply(rule *httpRule, reply interface{}) ([]byte, error) {
+	m, ok := reply.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("grpc: %T is not a proto message", reply)
+	}
+	data, err := ejson.MarshalOptions.Marshal(m)
+	if err != nil || rule.responseBody == "" {
+		return data, err
+	}
+	fd := rule.output.Fields().ByName(protoreflect.Name(rule.responseBody))
+	if fd == nil {
+		return nil, fmt.Errorf("grpc: unknown response body field %q", rule.responseBody)
+	}
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if v, ok := fields[fd.JSONName()]; ok {
+		return v, nil
+	}
+	return []byte("null"), nil
+}
+
+// incomingMetadata returns the request headers as gRPC metadata, without
+// the hop-by-hop headers.
+func incomingMetadata(r *http.Request) metadata.MD {
+	md := make(metadata.MD, len(r.Header)+1)
+	for k, vs := range r.Header {
+		switch k = strings.ToLower(k); k {
+		case "connection", "content-length", "keep-alive", "te", "trailer", "transfer-encoding", "upgrade":
+			continue
+		}
+		md[k] = append(md[k], vs...)
+	}
+	if r.Host != "" {
+		md.Set(":authority", r.Host)
+	}
+	return md
+}
+
+// transcodeStream records the headers and trailers set by the handler.
+type transcodeStream struct {
+	method  string
+	mu      sync.Mutex
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+var _ grpc.ServerTransportStream = (*transcodeStream)(nil)
+
+func (s *transcodeStream) Method() string {
+	return s.method
+}
+
+func (s *transcodeStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *transcodeStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *transcodeStream) SetTrailer(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
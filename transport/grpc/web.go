@@ -0,0 +1,121 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+)
+
+func isGrpcWeb(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebContentType)
+}
+
+// serveGrpcWeb serves a gRPC-Web request as a gRPC request of the server,
+// the trailers of the response are sent in a trailer frame of the body.
+func (s *Server) serveGrpcWeb(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	text := strings.HasPrefix(contentType, grpcWebTextContentType)
+	prefix := grpcWebContentType
+	if text {
+		prefix = grpcWebTextContentType
+	}
+
+	req := r.Clone(r.Context())
+	req.ProtoMajor, req.ProtoMinor, req.Proto = 2, 0, "HTTP/2.0"
+	req.Header.Set("Content-Type", "application/grpc"+strings.TrimPrefix(contentType, prefix))
+	req.Header.Del("Content-Length")
+	if text {
+		req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+	}
+
+	ww := &webResponseWriter{
+		w:           w,
+		header:      make(http.Header),
+		contentType: contentType,
+		text:        text,
+	}
+	s.Server.ServeHTTP(ww, req)
+	ww.finish()
+}
+
+// webResponseWriter writes a gRPC response as a gRPC-Web response.
+type webResponseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	sent        http.Header
+	contentType string
+	text        bool
+}
+
+func (w *webResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *webResponseWriter) WriteHeader(code int) {
+	if w.sent != nil {
+		return
+	}
+	w.sent = w.header.Clone()
+	h := w.w.Header()
+	for k, vs := range w.header {
+		if k == "Trailer" || strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		h[k] = vs
+	}
+	h.Set("Content-Type", w.contentType)
+	h.Del("Content-Length")
+	w.w.WriteHeader(code)
+}
+
+func (w *webResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.text {
+		if _, err := io.WriteString(w.w, base64.StdEncoding.EncodeToString(data)); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	return w.w.Write(data)
+}
+
+func (w *webResponseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the headers set after the response headers as a trailer
+// frame.
+func (w *webResponseWriter) finish() {
+	w.WriteHeader(http.StatusOK)
+	keys := make([]string, 0, len(w.header))
+	for k := range w.header {
+		if _, ok := w.sent[k]; !ok || strings.HasPrefix(k, http.TrailerPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var trailer bytes.Buffer
+	for _, k := range keys {
+		name := strings.ToLower(strings.TrimPrefix(k, http.TrailerPrefix))
+		for _, v := range w.header[k] {
+			trailer.WriteString(name + ": " + v + "\r\n")
+		}
+	}
+	frame := make([]byte, 5, 5+trailer.Len())
+	frame[0] = 1 << 7
+	binary.BigEndian.PutUint32(frame[1:], uint32(trailer.Len()))
+	_, _ = w.Write(append(frame, trailer.Bytes()...))
+	w.Flush()
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/apus-run/gaia/registry"
+)
+
+// ErrNoAvailable is returned when no node can serve a request.
+var ErrNoAvailable = errors.New("no available node")
+
+// Node is a service instance endpoint the client can send requests to.
+type Node struct {
+	// Address is the host and port of the endpoint.
+	Address string
+	// Instance is the discovered service instance.
+	Instance *registry.ServiceInstance
+}
+
+// DoneFunc is called when the request to a picked node is done.
+type DoneFunc func(ctx context.Context, err error)
+
+// Balancer picks the node of each request.
+type Balancer interface {
+	Pick(ctx context.Context, nodes []*Node) (node *Node, done DoneFunc, err error)
+}
+
+// RoundRobin returns a balancer that picks the nodes in turn.
+func RoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+func (b *roundRobin) Pick(_ context.Context, nodes []*Node) (*Node, DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+	n := b.next.Add(1) - 1
+	return nodes[n%uint64(len(nodes))], func(context.Context, error) {}, nil
+}
+
+// Random returns a balancer that picks a node at random.
+func Random() Balancer {
+	return random{}
+}
+
+type random struct{}
+
+func (random) Pick(_ context.Context, nodes []*Node) (*Node, DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+	return nodes[rand.Intn(len(nodes))], func(context.Context, error) {}, nil
+}
@@ -0,0 +1,52 @@
+package http
+
+import "net/http"
+
+// CallOption configures a call before it starts or extracts information
+// from it after it completes.
+type CallOption func(*callInfo)
+
+type callInfo struct {
+	contentType  string
+	operation    string
+	pathTemplate string
+	header       *http.Header
+}
+
+func defaultCallInfo(path string) callInfo {
+	return callInfo{
+		contentType:  contentTypeJSON,
+		operation:    path,
+		pathTemplate: path,
+	}
+}
+
+// ContentType with the request content type, application/json by default.
+func ContentType(contentType string) CallOption {
+	return func(c *callInfo) {
+		c.contentType = contentType
+	}
+}
+
+// Operation with the transport operation used by client middleware, the
+// request path by default.
+func Operation(operation string) CallOption {
+	return func(c *callInfo) {
+		c.operation = operation
+	}
+}
+
+// PathTemplate with the path template of the request, the request path by
+// default.
+func PathTemplate(pattern string) CallOption {
+	return func(c *callInfo) {
+		c.pathTemplate = pattern
+	}
+}
+
+// Header with a header that receives the response header.
+func Header(header *http.Header) CallOption {
+	return func(c *callInfo) {
+		c.header = header
+	}
+}
@@ -0,0 +1,367 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apus-run/gaia/internal/endpoint"
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/pkg/tls"
+	"github.com/apus-run/gaia/registry"
+	"github.com/apus-run/gaia/transport"
+)
+
+// clientOptions is HTTP client options
+type clientOptions struct {
+	endpoint     string
+	timeout      time.Duration
+	tlsConf      *tls.TLS
+	userAgent    string
+	transport    http.RoundTripper
+	discovery    registry.Discovery
+	balancer     Balancer
+	block        bool
+	ms           []middleware.Middleware
+	encoder      EncodeRequestFunc
+	decoder      DecodeResponseFunc
+	errorDecoder DecodeErrorFunc
+}
+
+// defaultClientOptions return a default config client
+func defaultClientOptions() clientOptions {
+	return clientOptions{
+		timeout:      2000 * time.Millisecond,
+		balancer:     RoundRobin(),
+		encoder:      DefaultRequestEncoder,
+		decoder:      DefaultResponseDecoder,
+		errorDecoder: DefaultErrorDecoder,
+	}
+}
+
+// ClientOption is HTTP client option.
+type ClientOption func(*clientOptions)
+
+// WithEndpoint with the client endpoint, either an address such as
+// 127.0.0.1:8000 or a discovery target such as discovery:///helloworld.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(o *clientOptions) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithTimeout with client request timeout.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.timeout = d
+	}
+}
+
+// WithTLSConfig with TLS config.
+func WithTLSConfig(conf *tls.TLS) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConf = conf
+	}
+}
+
+// WithUserAgent with client user agent.
+func WithUserAgent(ua string) ClientOption {
+	return func(o *clientOptions) {
+		o.userAgent = ua
+	}
+}
+
+// WithTransport with client transport.
+func WithTransport(trans http.RoundTripper) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = trans
+	}
+}
+
+// WithDiscovery with client discovery.
+func WithDiscovery(d registry.Discovery) ClientOption {
+	return func(o *clientOptions) {
+		o.discovery = d
+	}
+}
+
+// WithBalancer with the balancer picking the discovered node of each
+// request, round robin by default.
+func WithBalancer(b Balancer) ClientOption {
+	return func(o *clientOptions) {
+		o.balancer = b
+	}
+}
+
+// WithBlock makes NewClient wait until the service is discovered.
+func WithBlock() ClientOption {
+	return func(o *clientOptions) {
+		o.block = true
+	}
+}
+
+// WithMiddleware with client middleware.
+func WithMiddleware(ms ...middleware.Middleware) ClientOption {
+	return func(o *clientOptions) {
+		o.ms = ms
+	}
+}
+
+// WithRequestEncoder with client request encoder.
+func WithRequestEncoder(encoder EncodeRequestFunc) ClientOption {
+	return func(o *clientOptions) {
+		o.encoder = encoder
+	}
+}
+
+// WithResponseDecoder with client response decoder.
+func WithResponseDecoder(decoder DecodeResponseFunc) ClientOption {
+	return func(o *clientOptions) {
+		o.decoder = decoder
+	}
+}
+
+// WithErrorDecoder with client error decoder.
+func WithErrorDecoder(errorDecoder DecodeErrorFunc) ClientOption {
+	return func(o *clientOptions) {
+		o.errorDecoder = errorDecoder
+	}
+}
+
+// Client is an HTTP client.
+type Client struct {
+	opts     clientOptions
+	target   *Target
+	r        *resolver
+	cc       *http.Client
+	insecure bool
+}
+
+// NewClient returns an HTTP client. Endpoints with the discovery scheme
+// are resolved through the discovery, and each request goes to the node
+// picked by the balancer.
+func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
+	options := defaultClientOptions()
+	for _, o := range opts {
+		o(&options)
+	}
+
+	insecure := options.tlsConf == nil
+	if options.transport == nil {
+		trans := http.DefaultTransport.(*http.Transport).Clone()
+		if !insecure {
+			conf, err := options.tlsConf.Config()
+			if err != nil {
+				return nil, fmt.Errorf("TLS Config Error - %v", err)
+			}
+			trans.TLSClientConfig = conf
+		}
+		options.transport = trans
+	}
+	target, err := parseTarget(options.endpoint, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		opts:     options,
+		target:   target,
+		cc:       &http.Client{Transport: options.transport},
+		insecure: insecure,
+	}
+	if target.Scheme == "discovery" {
+		if options.discovery == nil {
+			return nil, fmt.Errorf("no discovery for endpoint %s", options.endpoint)
+		}
+		if c.r, err = newResolver(ctx, options.discovery, target, options.balancer, options.block, insecure); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Invoke makes a request to path, encoding args as the request body, unless
+// nil, and decoding the response body into reply. Error responses are
+// returned as errors by the error decoder.
+func (c *Client) Invoke(ctx context.Context, method, path string, args interface{}, reply interface{}, opts ...CallOption) error {
+	info := defaultCallInfo(path)
+	for _, o := range opts {
+		o(&info)
+	}
+
+	var body io.Reader
+	if args != nil {
+		data, err := c.opts.encoder(ctx, info.contentType, args)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if args != nil {
+		req.Header.Set("Content-Type", info.contentType)
+	}
+	req.Header.Set("Accept", info.contentType)
+	if c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+
+	// the concurrent attempts of a hedged call decode a single reply, the
+	// first one decoded commits the call
+	var (
+		mu        sync.Mutex
+		committed bool
+	)
+	h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		res, err := c.do(req.WithContext(ctx), true)
+		hedged := false
+		if a, ok := middleware.FromAttemptContext(ctx); ok && a.Hedged {
+			if err != nil {
+				return nil, err
+			}
+			hedged = true
+			mu.Lock()
+			defer mu.Unlock()
+			if committed {
+				res.Body.Close()
+				return reply, nil
+			}
+		}
+		if res != nil {
+			setReplyHeader(ctx, res)
+			if info.header != nil {
+				*info.header = res.Header
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if err = c.opts.decoder(ctx, res, reply); err != nil {
+			return nil, err
+		}
+		committed = hedged
+		return reply, nil
+	}
+	_, err = c.chain(h)(c.clientContext(ctx, req, info), args)
+	return err
+}
+
+// Do sends the request through the client middleware and returns the
+// response, whatever its status.
+func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, error) {
+	info := defaultCallInfo(req.URL.Path)
+	for _, o := range opts {
+		o(&info)
+	}
+
+	h := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		res, err := c.do(req.WithContext(ctx), false)
+		if err != nil {
+			return nil, err
+		}
+		setReplyHeader(ctx, res)
+		return res, nil
+	}
+	res, err := c.chain(h)(c.clientContext(req.Context(), req, info), nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*http.Response), nil
+}
+
+// Close closes the client and stops watching the discovery.
+func (c *Client) Close() error {
+	c.cc.CloseIdleConnections()
+	if c.r != nil {
+		return c.r.Close()
+	}
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	url := fmt.Sprintf("%s://%s%s", c.target.Scheme, c.target.Authority, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.userAgent != "" {
+		req.Header.Set("User-Agent", c.opts.userAgent)
+	}
+	return req, nil
+}
+
+// clientContext returns the context with the client transport of the
+// request, whose header is the request header.
+func (c *Client) clientContext(ctx context.Context, req *http.Request, info callInfo) context.Context {
+	return transport.NewClientContext(ctx, &Transport{
+		endpoint:     c.opts.endpoint,
+		operation:    info.operation,
+		reqHeader:    headerCarrier(req.Header),
+		replyHeader:  headerCarrier{},
+		request:      req,
+		pathTemplate: info.pathTemplate,
+	})
+}
+
+// setReplyHeader sets the header of the response as the reply header of the
+// client transport of ctx.
+func setReplyHeader(ctx context.Context, res *http.Response) {
+	if tr, ok := transport.FromClientContext(ctx); ok {
+		if tr, ok := tr.(*Transport); ok {
+			for k, v := range res.Header {
+				tr.replyHeader[k] = v
+			}
+		}
+	}
+}
+
+func (c *Client) chain(h middleware.Handler) middleware.Handler {
+	if len(c.opts.ms) > 0 {
+		return middleware.Chain(c.opts.ms...)(h)
+	}
+	return h
+}
+
+// do sends a copy of the request to the picked node, so that it can be
+// sent again by retrying middleware. With decodeErr, error responses are
+// returned along with the error decoded from them.
+func (c *Client) do(req *http.Request, decodeErr bool) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+
+	var done DoneFunc
+	if c.r != nil {
+		node, d, err := c.r.pick(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		done = d
+		req.URL.Scheme = endpoint.Scheme("http", !c.insecure)
+		req.URL.Host = node.Address
+		req.Host = node.Address
+	}
+	res, err := c.cc.Do(req)
+	if err == nil && decodeErr {
+		err = c.opts.errorDecoder(req.Context(), res)
+	}
+	if done != nil {
+		done(req.Context(), err)
+	}
+	return res, err
+}
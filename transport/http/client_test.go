@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/metadata"
+	"github.com/apus-run/gaia/middleware"
+	mmd "github.com/apus-run/gaia/middleware/metadata"
+	"github.com/apus-run/gaia/pkg/errcode"
+	"github.com/apus-run/gaia/registry"
+	"github.com/apus-run/gaia/transport"
+)
+
+type user struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Trace string `json:"trace"`
+}
+
+func newUserServer(name string) *httptest.Server {
+	srv := NewServer()
+	r := srv.Route("/v1")
+	r.GET("/users/{id}", func(c Context) error {
+		if c.Vars().Get("id") == "0" {
+			return errcode.ErrNotFound.WithReason("USER_NOT_FOUND").WithDetails("user 0")
+		}
+		return c.JSON(http.StatusOK, user{ID: c.Vars().Get("id"), Name: name, Trace: c.Header().Get("x-md-local-trace")})
+	})
+	r.POST("/users", func(c Context) error {
+		var u user
+		if err := c.Bind(&u); err != nil {
+			return err
+		}
+		return c.Result(http.StatusOK, u)
+	})
+	return httptest.NewServer(srv)
+}
+
+type discovery struct {
+	mu  sync.Mutex
+	ins []*registry.ServiceInstance
+	ch  chan struct{}
+}
+
+func (d *discovery) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ins, nil
+}
+
+func (d *discovery) GetServiceList(ctx context.Context) ([]*registry.ServiceInstance, error) {
+	return d.GetService(ctx, "")
+}
+
+func (d *discovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	return &watcher{d: d, ctx: ctx, first: true}, nil
+}
+
+func (d *discovery) set(ins ...*registry.ServiceInstance) {
+	d.mu.Lock()
+	d.ins = ins
+	d.mu.Unlock()
+	d.ch <- struct{}{}
+}
+
+type watcher struct {
+	d     *discovery
+	ctx   context.Context
+	first bool
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	if w.first {
+		w.first = false
+		return w.d.GetService(w.ctx, "")
+	}
+	select {
+	case <-w.d.ch:
+		return w.d.GetService(w.ctx, "")
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	}
+}
+
+func (w *watcher) Stop() error {
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	ts := newUserServer("a")
+	defer ts.Close()
+
+	ctx := metadata.AppendToClientContext(context.Background(), "x-md-local-trace", "t1")
+	client, err := NewClient(ctx, WithEndpoint(ts.Listener.Addr().String()), WithMiddleware(mmd.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var (
+		reply  user
+		header http.Header
+	)
+	if err = client.Invoke(ctx, http.MethodGet, "/v1/users/1", nil, &reply, Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != "1" || reply.Trace != "t1" {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+	if header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected the response header, got %v", header)
+	}
+
+	if err = client.Invoke(ctx, http.MethodPost, "/v1/users", &user{ID: "2"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != "2" {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+
+	err = client.Invoke(ctx, http.MethodGet, "/v1/users/0", nil, &reply)
+	var e *errcode.Error
+	if !errors.As(err, &e) || e.Code() != errcode.ErrNotFound.Code() || e.Msg() != errcode.ErrNotFound.Msg() {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if e != nil && (e.Reason() != "USER_NOT_FOUND" || e.StatusCode() != http.StatusNotFound || !reflect.DeepEqual(e.Details(), []string{"user 0"})) {
+		t.Errorf("expected the reason, status and details of the error, got %v", e)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/users/0", nil)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the raw response, got status %d", res.StatusCode)
+	}
+}
+
+func TestClient_Discovery(t *testing.T) {
+	a, b := newUserServer("a"), newUserServer("b")
+	defer a.Close()
+	defer b.Close()
+
+	d := &discovery{ch: make(chan struct{})}
+	d.ins = []*registry.ServiceInstance{
+		{ID: "a", Name: "users", Endpoints: []string{"grpc://127.0.0.1:9000", "http://" + a.Listener.Addr().String()}},
+		{ID: "b", Name: "users", Endpoints: []string{"http://" + b.Listener.Addr().String()}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client, err := NewClient(ctx, WithEndpoint("discovery:///users"), WithDiscovery(d), WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	names := map[string]int{}
+	for i := 0; i < 4; i++ {
+		var reply user
+		if err = client.Invoke(ctx, http.MethodGet, "/v1/users/1", nil, &reply); err != nil {
+			t.Fatal(err)
+		}
+		names[reply.Name]++
+	}
+	if names["a"] != 2 || names["b"] != 2 {
+		t.Errorf("expected requests to be balanced, got %v", names)
+	}
+
+	d.set(d.ins[1])
+	deadline := time.Now().Add(time.Second)
+	for {
+		var reply user
+		if err = client.Invoke(ctx, http.MethodGet, "/v1/users/1", nil, &reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Name == "b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the client to follow the discovery")
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(context.Background(), WithEndpoint("discovery:///users")); err == nil {
+		t.Error("expected an error without discovery")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	d := &discovery{ch: make(chan struct{})}
+	if _, err := NewClient(ctx, WithEndpoint("discovery:///users"), WithDiscovery(d), WithBlock()); err == nil {
+		t.Error("expected an error when nothing is discovered")
+	}
+
+	client, err := NewClient(context.Background(), WithEndpoint("discovery:///users"), WithDiscovery(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err = client.Invoke(context.Background(), http.MethodGet, "/", nil, nil); !errors.Is(err, ErrNoAvailable) {
+		t.Errorf("expected %v got %v", ErrNoAvailable, err)
+	}
+}
+
+func TestClient_HedgedDecodeError(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			_, _ = w.Write([]byte(`{"id":`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer ts.Close()
+
+	// hedged attempts sent one after the other, the first one fails to decode
+	hedge := func(h middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var err error
+			for i := 0; i < 2; i++ {
+				var reply interface{}
+				ctx := middleware.NewAttemptContext(ctx, middleware.Attempt{Index: i, Hedged: true})
+				if reply, err = h(ctx, req); err == nil {
+					return reply, nil
+				}
+			}
+			return nil, err
+		}
+	}
+	client, err := NewClient(context.Background(), WithEndpoint(ts.Listener.Addr().String()), WithMiddleware(hedge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply user
+	if err = client.Invoke(context.Background(), http.MethodGet, "/v1/users/1", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != "1" {
+		t.Errorf("expected the reply of the second attempt, got %+v", reply)
+	}
+}
+
+func TestClient_ReplyHeader(t *testing.T) {
+	ts := newUserServer("a")
+	defer ts.Close()
+
+	var contentType string
+	m := func(h middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := h(ctx, req)
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				contentType = tr.ReplyHeader().Get("Content-Type")
+			}
+			return reply, err
+		}
+	}
+	client, err := NewClient(context.Background(), WithEndpoint(ts.Listener.Addr().String()), WithMiddleware(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply user
+	if err = client.Invoke(context.Background(), http.MethodGet, "/v1/users/1", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("expected the reply header, got content type %q", contentType)
+	}
+}
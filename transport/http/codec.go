@@ -0,0 +1,240 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/apus-run/gaia/encoding"
+	"github.com/apus-run/gaia/encoding/form"
+	"github.com/apus-run/gaia/encoding/json"
+	"github.com/apus-run/gaia/pkg/errcode"
+
+	// register the codecs of the HTTP transport
+	_ "github.com/apus-run/gaia/encoding/proto"
+	_ "github.com/apus-run/gaia/encoding/xml"
+	_ "github.com/apus-run/gaia/encoding/yaml"
+)
+
+// contentTypeJSON is the default content type of requests and responses.
+const contentTypeJSON = "application/json"
+
+// DecodeRequestFunc is decode request func.
+type DecodeRequestFunc func(*http.Request, interface{}) error
+
+// EncodeResponseFunc is encode response func.
+type EncodeResponseFunc func(http.ResponseWriter, *http.Request, interface{}) error
+
+// EncodeErrorFunc is encode error func.
+type EncodeErrorFunc func(http.ResponseWriter, *http.Request, error)
+
+// EncodeRequestFunc is request encode func.
+type EncodeRequestFunc func(ctx context.Context, contentType string, in interface{}) (body []byte, err error)
+
+// DecodeResponseFunc is response decode func.
+type DecodeResponseFunc func(ctx context.Context, res *http.Response, out interface{}) error
+
+// DecodeErrorFunc is error decode func, it returns nil for successful
+// responses and otherwise consumes and closes the response body.
+type DecodeErrorFunc func(ctx context.Context, res *http.Response) error
+
+// errorBody is the body of error responses, as written by ginx.
+type errorBody struct {
+	Code     int               `json:"code" xml:"code"`
+	Reason   string            `json:"reason,omitempty" xml:"reason,omitempty"`
+	Domain   string            `json:"domain,omitempty" xml:"domain,omitempty"`
+	Msg      string            `json:"msg" xml:"msg"`
+	Details  []string          `json:"details,omitempty" xml:"details,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty" xml:"-"`
+}
+
+// DefaultRequestVars decodes the request path variables into v.
+func DefaultRequestVars(r *http.Request, v interface{}) error {
+	vars, _ := r.Context().Value(varsKey{}).(url.Values)
+	if err := form.DecodeValues(v, vars); err != nil {
+		return errcode.ErrInvalidParam.WithDetails(err.Error())
+	}
+	return nil
+}
+
+// DefaultRequestQuery decodes the request query parameters into v.
+func DefaultRequestQuery(r *http.Request, v interface{}) error {
+	if err := form.DecodeValues(v, r.URL.Query()); err != nil {
+		return errcode.ErrInvalidParam.WithDetails(err.Error())
+	}
+	return nil
+}
+
+// DefaultRequestDecoder decodes the request body into v with the codec of
+// its Content-Type.
+func DefaultRequestDecoder(r *http.Request, v interface{}) error {
+	codec, ok := CodecForRequest(r, "Content-Type")
+	if !ok {
+		return errcode.ErrInvalidParam.WithDetails(fmt.Sprintf("unregistered Content-Type: %s", r.Header.Get("Content-Type")))
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return errcode.ErrInvalidParam.WithDetails(err.Error())
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err = codec.Unmarshal(data, v); err != nil {
+		return errcode.ErrInvalidParam.WithDetails(fmt.Sprintf("body unmarshal %s", err.Error()))
+	}
+	return nil
+}
+
+// DefaultResponseEncoder encodes v with the codec accepted by the request,
+// JSON by default.
+func DefaultResponseEncoder(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	codec, _ := CodecForRequest(r, "Accept")
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType(codec.Name()))
+	_, err = w.Write(data)
+	return err
+}
+
+// DefaultErrorEncoder writes the error with the codec accepted by the
+// request, and the HTTP status of the error.
+func DefaultErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
+	e := errcode.FromError(err)
+	body := errorBody{
+		Code:     e.Code(),
+		Reason:   e.Reason(),
+		Domain:   e.Domain(),
+		Msg:      e.Msg(),
+		Details:  e.Details(),
+		Metadata: e.Metadata(),
+	}
+	codec, _ := CodecForRequest(r, "Accept")
+	data, err := codec.Marshal(body)
+	if err != nil {
+		// e.g. the proto codec
+		codec = encoding.GetCodec(json.Name)
+		if data, err = codec.Marshal(body); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentType(codec.Name()))
+	w.WriteHeader(e.StatusCode())
+	_, _ = w.Write(data)
+}
+
+// DefaultRequestEncoder encodes the request body with the codec of the
+// content type.
+func DefaultRequestEncoder(_ context.Context, contentType string, in interface{}) ([]byte, error) {
+	codec := encoding.GetCodec(ContentSubtype(contentType))
+	if codec == nil {
+		return nil, fmt.Errorf("unregistered Content-Type: %s", contentType)
+	}
+	return codec.Marshal(in)
+}
+
+// DefaultResponseDecoder decodes the response body with the codec of its
+// Content-Type, JSON by default.
+func DefaultResponseDecoder(_ context.Context, res *http.Response, out interface{}) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return codecForResponse(res).Unmarshal(data, out)
+}
+
+// DefaultErrorDecoder decodes error responses into *errcode.Error, with
+// the code, reason, message, details and metadata of the body when the
+// server wrote them.
+func DefaultErrorDecoder(_ context.Context, res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode <= 299 {
+		return nil
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	body := errorBody{}
+	if err = codecForResponse(res).Unmarshal(data, &body); err != nil || (body.Reason == "" && body.Msg == "") {
+		return errcode.Newf(res.StatusCode, "", "%s", http.StatusText(res.StatusCode)).
+			WithCause(fmt.Errorf("http status %d: %s", res.StatusCode, data))
+	}
+	e := errcode.Newf(res.StatusCode, body.Reason, "%s", body.Msg).
+		WithCode(body.Code).
+		WithDomain(body.Domain).
+		WithMetadata(body.Metadata)
+	if len(body.Details) > 0 {
+		e = e.WithDetails(body.Details...)
+	}
+	return e
+}
+
+// CodecForRequest returns the codec of the first registered content type
+// of the request header, e.g. Content-Type or Accept, or the JSON codec
+// and false.
+func CodecForRequest(r *http.Request, name string) (encoding.Codec, bool) {
+	for _, value := range r.Header.Values(name) {
+		for _, accept := range strings.Split(value, ",") {
+			if codec := encoding.GetCodec(ContentSubtype(accept)); codec != nil {
+				return codec, true
+			}
+		}
+	}
+	return encoding.GetCodec(json.Name), false
+}
+
+func codecForResponse(res *http.Response) encoding.Codec {
+	if codec := encoding.GetCodec(ContentSubtype(res.Header.Get("Content-Type"))); codec != nil {
+		return codec
+	}
+	return encoding.GetCodec(json.Name)
+}
+
+// subtypes are aliases of the codec names.
+var subtypes = map[string]string{
+	"x-protobuf": "proto",
+	"protobuf":   "proto",
+	"x-yaml":     "yaml",
+}
+
+// ContentSubtype returns the codec name of a content type, e.g. json for
+// application/json; charset=utf-8, proto for application/x-protobuf.
+func ContentSubtype(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(contentType))
+	if err != nil {
+		return ""
+	}
+	_, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok {
+		return ""
+	}
+	// structured syntax suffix, e.g. application/problem+json
+	if _, suffix, ok := strings.Cut(subtype, "+"); ok {
+		subtype = suffix
+	}
+	if alias, ok := subtypes[subtype]; ok {
+		return alias
+	}
+	return subtype
+}
+
+// contentType returns the content type of a codec name.
+func contentType(subtype string) string {
+	if subtype == "proto" {
+		return "application/x-protobuf"
+	}
+	return "application/" + subtype
+}
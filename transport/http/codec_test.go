@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apus-run/gaia/encoding"
+	pb "github.com/apus-run/gaia/internal/testdata/encoding"
+	"github.com/apus-run/gaia/pkg/errcode"
+)
+
+func TestContentSubtype(t *testing.T) {
+	tests := map[string]string{
+		"application/json":                  "json",
+		"application/json; charset=utf-8":   "json",
+		"application/problem+json":          "json",
+		"application/x-protobuf":            "proto",
+		"application/x-www-form-urlencoded": "x-www-form-urlencoded",
+		"text/xml":                          "xml",
+		"application/x-yaml":                "yaml",
+		"json":                              "",
+		"":                                  "",
+	}
+	for ct, expected := range tests {
+		if got := ContentSubtype(ct); got != expected {
+			t.Errorf("%q: expected %q got %q", ct, expected, got)
+		}
+	}
+}
+
+func newModelServer(opts ...ServerOption) *Server {
+	srv := NewServer(opts...)
+	srv.Handle(http.MethodPost, "/models/{id}", func(c Context) error {
+		in := &pb.TestModel{}
+		if err := c.Bind(in); err != nil {
+			return err
+		}
+		if err := c.BindQuery(in); err != nil {
+			return err
+		}
+		if err := c.BindVars(in); err != nil {
+			return err
+		}
+		return c.Result(http.StatusCreated, in)
+	})
+	return srv
+}
+
+func TestServer_Codec(t *testing.T) {
+	srv := newModelServer()
+	tests := []struct {
+		contentType string
+		body        string
+		accept      string
+		code        int
+		reply       string
+	}{
+		{"application/json", `{"name":"gaia"}`, "", http.StatusCreated, `"name":"gaia"`},
+		{"application/json", `{"name":"gaia"}`, "application/x-yaml", http.StatusCreated, "name: gaia"},
+		{"application/x-www-form-urlencoded", `name=gaia&hobby=go`, "text/html, application/x-yaml", http.StatusCreated, "- go"},
+		{"application/json", `{"name":`, "", http.StatusBadRequest, `"code":10001`},
+		{"application/json", `{"name":`, "application/xml", http.StatusBadRequest, "<code>10001</code>"},
+		{"text/csv", `name`, "", http.StatusBadRequest, `"code":10001`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/models/7?hobby=a&hobby=b", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, req)
+		if res.Code != tt.code || !strings.Contains(res.Body.String(), tt.reply) {
+			t.Errorf("%s %s: unexpected response %d %s", tt.contentType, tt.body, res.Code, res.Body.String())
+		}
+	}
+
+	// path variables and query parameters
+	req := httptest.NewRequest(http.MethodPost, "/models/7?hobby=a&hobby=b", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/x-protobuf")
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, req)
+	m := &pb.TestModel{}
+	if err := encoding.GetCodec("proto").Unmarshal(res.Body.Bytes(), m); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (&pb.TestModel{Id: 7, Hobby: []string{"a", "b"}}); !proto.Equal(m, expected) {
+		t.Errorf("expected %v got %v", expected, m)
+	}
+	if ct := res.Header().Get("Content-Type"); ct != "application/x-protobuf" {
+		t.Errorf("expected content type application/x-protobuf got %q", ct)
+	}
+}
+
+func TestServer_CustomCodec(t *testing.T) {
+	decoded := errors.New("decoded")
+	srv := newModelServer(
+		RequestDecoder(func(*http.Request, interface{}) error { return decoded }),
+		ErrorEncoder(func(w http.ResponseWriter, _ *http.Request, err error) {
+			if errors.Is(err, decoded) {
+				w.WriteHeader(http.StatusTeapot)
+			}
+		}),
+	)
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/models/1", nil))
+	if res.Code != http.StatusTeapot {
+		t.Errorf("expected status %d got %d", http.StatusTeapot, res.Code)
+	}
+}
+
+func TestClient_Codec(t *testing.T) {
+	ts := httptest.NewServer(newModelServer())
+	defer ts.Close()
+	client, err := NewClient(context.Background(), WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for _, ct := range []string{"application/json", "application/x-protobuf", "application/x-www-form-urlencoded", "application/x-yaml"} {
+		reply := &pb.TestModel{}
+		if err = client.Invoke(context.Background(), http.MethodPost, "/models/3", &pb.TestModel{Name: "gaia"}, reply, ContentType(ct)); err != nil {
+			t.Fatalf("%s: %v", ct, err)
+		}
+		if reply.Id != 3 || reply.Name != "gaia" {
+			t.Errorf("%s: unexpected reply %v", ct, reply)
+		}
+	}
+
+	err = client.Invoke(context.Background(), http.MethodPost, "/models/x", &pb.TestModel{}, nil, ContentType("application/x-yaml"))
+	if !errors.Is(err, errcode.ErrInvalidParam) {
+		t.Errorf("expected an invalid param error, got %v", err)
+	}
+}
+
+func TestDefaultErrorDecoder(t *testing.T) {
+	tests := []struct {
+		body   string
+		reason string
+		domain string
+	}{
+		{`{"code":0,"reason":"USER_NOT_FOUND","domain":"user","msg":"user not found"}`, "USER_NOT_FOUND", "user"},
+		{`{"code":10001,"msg":"invalid param"}`, "", ""},
+		{`{}`, "", ""},
+		{`not found`, "", ""},
+	}
+	for _, tt := range tests {
+		res := &http.Response{
+			StatusCode: http.StatusNotFound,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(tt.body)),
+		}
+		e := errcode.FromError(DefaultErrorDecoder(context.Background(), res))
+		if e.StatusCode() != http.StatusNotFound || e.Reason() != tt.reason || e.Domain() != tt.domain {
+			t.Errorf("%s: unexpected error %v", tt.body, e)
+		}
+	}
+}
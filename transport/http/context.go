@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/apus-run/gaia/encoding/form"
+	"github.com/apus-run/gaia/pkg/errcode"
+)
+
+var _ Context = (*wrapper)(nil)
+
+// Context is an HTTP request context.
+type Context interface {
+	context.Context
+	// Vars returns the path template variables.
+	Vars() url.Values
+	Query() url.Values
+	Form() url.Values
+	Header() http.Header
+	Request() *http.Request
+	Response() http.ResponseWriter
+	// Bind decodes the request body with the codec of its Content-Type.
+	Bind(v interface{}) error
+	BindVars(v interface{}) error
+	BindQuery(v interface{}) error
+	BindForm(v interface{}) error
+	// Result encodes v with the codec accepted by the request.
+	Result(code int, v interface{}) error
+	// Returns encodes v, or returns err to the error encoder.
+	Returns(v interface{}, err error) error
+	JSON(code int, v interface{}) error
+	String(code int, text string) error
+	Blob(code int, contentType string, data []byte) error
+	Stream(code int, contentType string, rd io.Reader) error
+}
+
+type wrapper struct {
+	srv  *Server
+	req  *http.Request
+	res  http.ResponseWriter
+	vars url.Values
+}
+
+func (c *wrapper) Vars() url.Values {
+	return c.vars
+}
+
+func (c *wrapper) Query() url.Values {
+	return c.req.URL.Query()
+}
+
+func (c *wrapper) Form() url.Values {
+	if err := c.req.ParseForm(); err != nil {
+		return url.Values{}
+	}
+	return c.req.Form
+}
+
+func (c *wrapper) Header() http.Header {
+	return c.req.Header
+}
+
+func (c *wrapper) Request() *http.Request {
+	return c.req
+}
+
+func (c *wrapper) Response() http.ResponseWriter {
+	return c.res
+}
+
+func (c *wrapper) Bind(v interface{}) error {
+	return c.srv.decBody(c.req, v)
+}
+
+func (c *wrapper) BindVars(v interface{}) error {
+	return c.srv.decVars(c.req, v)
+}
+
+func (c *wrapper) BindQuery(v interface{}) error {
+	return c.srv.decQuery(c.req, v)
+}
+
+func (c *wrapper) BindForm(v interface{}) error {
+	if err := c.req.ParseForm(); err != nil {
+		return errcode.ErrInvalidParam.WithDetails(err.Error())
+	}
+	if err := form.DecodeValues(v, c.req.Form); err != nil {
+		return errcode.ErrInvalidParam.WithDetails(err.Error())
+	}
+	return nil
+}
+
+func (c *wrapper) Result(code int, v interface{}) error {
+	w := &statusWriter{ResponseWriter: c.res, code: code}
+	if err := c.srv.enc(w, c.req, v); err != nil {
+		return err
+	}
+	w.WriteHeader(code)
+	return nil
+}
+
+func (c *wrapper) Returns(v interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	return c.srv.enc(c.res, c.req, v)
+}
+
+func (c *wrapper) JSON(code int, v interface{}) error {
+	c.res.Header().Set("Content-Type", "application/json")
+	c.res.WriteHeader(code)
+	return json.NewEncoder(c.res).Encode(v)
+}
+
+func (c *wrapper) String(code int, text string) error {
+	c.res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.res.WriteHeader(code)
+	_, err := io.WriteString(c.res, text)
+	return err
+}
+
+func (c *wrapper) Blob(code int, contentType string, data []byte) error {
+	c.res.Header().Set("Content-Type", contentType)
+	c.res.WriteHeader(code)
+	_, err := c.res.Write(data)
+	return err
+}
+
+func (c *wrapper) Stream(code int, contentType string, rd io.Reader) error {
+	c.res.Header().Set("Content-Type", contentType)
+	c.res.WriteHeader(code)
+	_, err := io.Copy(c.res, rd)
+	return err
+}
+
+func (c *wrapper) Deadline() (time.Time, bool) {
+	return c.req.Context().Deadline()
+}
+
+func (c *wrapper) Done() <-chan struct{} {
+	return c.req.Context().Done()
+}
+
+func (c *wrapper) Err() error {
+	return c.req.Context().Err()
+}
+
+func (c *wrapper) Value(key interface{}) interface{} {
+	return c.req.Context().Value(key)
+}
+
+// statusWriter writes the status code before the body, so that encoders can
+// set headers first.
+type statusWriter struct {
+	http.ResponseWriter
+	code  int
+	wrote bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.wrote = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *statusWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(w.code)
+	return w.ResponseWriter.Write(data)
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users?page=2", strings.NewReader("name=gaia"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res := httptest.NewRecorder()
+	c := &wrapper{req: req, res: res, vars: url.Values{"id": {"1"}}}
+
+	if c.Vars().Get("id") != "1" {
+		t.Errorf("expected var id 1 got %q", c.Vars().Get("id"))
+	}
+	if c.Query().Get("page") != "2" {
+		t.Errorf("expected query page 2 got %q", c.Query().Get("page"))
+	}
+	if c.Form().Get("name") != "gaia" {
+		t.Errorf("expected form name gaia got %q", c.Form().Get("name"))
+	}
+	if err := c.JSON(http.StatusCreated, map[string]string{"name": "gaia"}); err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != http.StatusCreated || res.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response %d %q", res.Code, res.Header().Get("Content-Type"))
+	}
+	if body := strings.TrimSpace(res.Body.String()); body != `{"name":"gaia"}` {
+		t.Errorf("unexpected body %q", body)
+	}
+}
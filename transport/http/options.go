@@ -4,8 +4,11 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/apus-run/gaia/health"
 	"github.com/apus-run/gaia/internal/matcher"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/pkg/tls"
@@ -24,8 +27,28 @@ type Server struct {
 
 	filters    []FilterFunc
 	middleware matcher.Matcher
+	handler    http.Handler
+
+	routes   *node
+	routesMu sync.RWMutex
+
+	decVars  DecodeRequestFunc
+	decQuery DecodeRequestFunc
+	decBody  DecodeRequestFunc
+	enc      EncodeResponseFunc
+	ene      EncodeErrorFunc
 
 	err error
+
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	livenessPath  string
+	readinessPath string
+	metricsPath   string
+	metrics       http.Handler
+	draining      atomic.Bool
+	health        *health.Health
 }
 
 // defaultServer return a default config server
@@ -36,6 +59,13 @@ func defaultServer() *Server {
 		readTimeout:  1 * time.Second,
 		writeTimeout: 1 * time.Second,
 		middleware:   matcher.New(),
+		routes:       newNode(),
+		decVars:      DefaultRequestVars,
+		decQuery:     DefaultRequestQuery,
+		decBody:      DefaultRequestDecoder,
+		enc:          DefaultResponseEncoder,
+		ene:          DefaultErrorEncoder,
+		ready:        make(chan struct{}),
 	}
 }
 
@@ -84,6 +114,42 @@ func Filter(filters ...FilterFunc) ServerOption {
 	}
 }
 
+// RequestVarsDecoder with request path variables decoder.
+func RequestVarsDecoder(dec DecodeRequestFunc) ServerOption {
+	return func(o *Server) {
+		o.decVars = dec
+	}
+}
+
+// RequestQueryDecoder with request query decoder.
+func RequestQueryDecoder(dec DecodeRequestFunc) ServerOption {
+	return func(o *Server) {
+		o.decQuery = dec
+	}
+}
+
+// RequestDecoder with request body decoder.
+func RequestDecoder(dec DecodeRequestFunc) ServerOption {
+	return func(o *Server) {
+		o.decBody = dec
+	}
+}
+
+// ResponseEncoder with response encoder.
+func ResponseEncoder(en EncodeResponseFunc) ServerOption {
+	return func(o *Server) {
+		o.enc = en
+	}
+}
+
+// ErrorEncoder with the encoder of the errors returned by route handlers and
+// service middleware.
+func ErrorEncoder(en EncodeErrorFunc) ServerOption {
+	return func(o *Server) {
+		o.ene = en
+	}
+}
+
 // Listener with server lis
 func Listener(lis net.Listener) ServerOption {
 	return func(s *Server) {
@@ -91,6 +157,41 @@ func Listener(lis net.Listener) ServerOption {
 	}
 }
 
+// LivenessPath with the path of the liveness probe, e.g. "/healthz". The
+// probe is disabled by default so that it never shadows a route.
+func LivenessPath(path string) ServerOption {
+	return func(s *Server) {
+		s.livenessPath = path
+	}
+}
+
+// ReadinessPath with the path of the readiness probe, which fails while the
+// server is draining, e.g. "/readyz". The probe is disabled by default so
+// that it never shadows a route.
+func ReadinessPath(path string) ServerOption {
+	return func(s *Server) {
+		s.readinessPath = path
+	}
+}
+
+// Metrics with the handler of the metrics served on the path, outside the
+// routes and their filters, e.g. prometheus.Handler(nil) of
+// pkg/metrics/prometheus.
+func Metrics(path string, h http.Handler) ServerOption {
+	return func(s *Server) {
+		s.metricsPath = path
+		s.metrics = h
+	}
+}
+
+// Health with the health aggregator reported by the liveness and readiness
+// probes.
+func Health(h *health.Health) ServerOption {
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
 // TLSConfig with TLS config.
 func TLSConfig(c *tls.TLS) ServerOption {
 	return func(o *Server) {
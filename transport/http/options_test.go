@@ -2,9 +2,12 @@ package http
 
 import (
 	"net"
+	"net/http"
 	"reflect"
 	"testing"
 
+	"github.com/apus-run/gaia/health"
+	"github.com/apus-run/gaia/internal/matcher"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/pkg/tls"
 )
@@ -28,14 +31,13 @@ func TestAddress(t *testing.T) {
 }
 
 func TestMiddleware(t *testing.T) {
-	o := &Server{}
+	o := &Server{middleware: matcher.New()}
 	v := []middleware.Middleware{
 		func(middleware.Handler) middleware.Handler { return nil },
 	}
-	o.middleware.Use(v...)
-
-	if !reflect.DeepEqual(v, o) {
-		t.Errorf("expected %v got %v", v, o)
+	Middleware(v...)(o)
+	if ms := o.middleware.Match("/foo"); len(ms) != len(v) {
+		t.Errorf("expected %v got %v", v, ms)
 	}
 }
 
@@ -64,3 +66,40 @@ func TestListener(t *testing.T) {
 		t.Errorf("expected not empty")
 	}
 }
+
+func TestReadinessPath(t *testing.T) {
+	o := &Server{}
+	v := "/ready"
+	ReadinessPath(v)(o)
+	if !reflect.DeepEqual(v, o.readinessPath) {
+		t.Errorf("expected %v got %v", v, o.readinessPath)
+	}
+}
+
+func TestLivenessPath(t *testing.T) {
+	o := &Server{}
+	v := "/live"
+	LivenessPath(v)(o)
+	if !reflect.DeepEqual(v, o.livenessPath) {
+		t.Errorf("expected %v got %v", v, o.livenessPath)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	o := &Server{}
+	v := health.New()
+	Health(v)(o)
+	if !reflect.DeepEqual(v, o.health) {
+		t.Errorf("expected %v got %v", v, o.health)
+	}
+}
+
+func TestErrorEncoder(t *testing.T) {
+	o := &Server{}
+	var called bool
+	ErrorEncoder(func(http.ResponseWriter, *http.Request, error) { called = true })(o)
+	o.ene(nil, nil, nil)
+	if !called {
+		t.Error("expected the error encoder to be set")
+	}
+}
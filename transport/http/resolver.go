@@ -0,0 +1,158 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apus-run/sea-kit/log"
+
+	"github.com/apus-run/gaia/internal/endpoint"
+	"github.com/apus-run/gaia/registry"
+)
+
+// Target is resolver target
+type Target struct {
+	Scheme    string
+	Authority string
+	Endpoint  string
+}
+
+// parseTarget parses an endpoint such as discovery:///helloworld,
+// http://127.0.0.1:8000 or 127.0.0.1:8000.
+func parseTarget(ep string, insecure bool) (*Target, error) {
+	if !strings.Contains(ep, "://") {
+		ep = endpoint.Scheme("http", !insecure) + "://" + ep
+	}
+	u, err := url.Parse(ep)
+	if err != nil {
+		return nil, err
+	}
+	target := &Target{Scheme: u.Scheme, Authority: u.Host}
+	if len(u.Path) > 1 {
+		target.Endpoint = u.Path[1:]
+	}
+	return target, nil
+}
+
+// resolver keeps the nodes of a discovered service.
+type resolver struct {
+	balancer Balancer
+	target   *Target
+	watcher  registry.Watcher
+	insecure bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.RWMutex
+	nodes []*Node
+}
+
+func newResolver(ctx context.Context, discovery registry.Discovery, target *Target, balancer Balancer, block, insecure bool) (*resolver, error) {
+	wctx, cancel := context.WithCancel(context.Background())
+	watcher, err := discovery.Watch(wctx, target.Endpoint)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	r := &resolver{
+		balancer: balancer,
+		target:   target,
+		watcher:  watcher,
+		insecure: insecure,
+		ctx:      wctx,
+		cancel:   cancel,
+	}
+	if block {
+		done := make(chan error, 1)
+		go func() {
+			for {
+				ins, err := watcher.Next()
+				if err != nil {
+					done <- err
+					return
+				}
+				if r.update(ins) {
+					done <- nil
+					return
+				}
+			}
+		}()
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("failed to resolve %s: %w", target.Endpoint, err)
+		}
+	}
+	go r.watch()
+	return r, nil
+}
+
+func (r *resolver) watch() {
+	for {
+		ins, err := r.watcher.Next()
+		if err != nil {
+			if errors.Is(err, context.Canceled) || r.ctx.Err() != nil {
+				return
+			}
+			log.Errorf("[HTTP] Failed to watch discovery endpoint: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		r.update(ins)
+	}
+}
+
+// update replaces the nodes, unless no instance has an endpoint.
+func (r *resolver) update(ins []*registry.ServiceInstance) bool {
+	var (
+		nodes = make([]*Node, 0, len(ins))
+		seen  = make(map[string]struct{}, len(ins))
+	)
+	for _, in := range ins {
+		ept, err := endpoint.ParseEndpoint(in.Endpoints, endpoint.Scheme("http", !r.insecure))
+		if err != nil {
+			log.Errorf("[HTTP] Failed to parse discovery endpoint: %v", err)
+			continue
+		}
+		if ept == "" {
+			continue
+		}
+		// filter redundant endpoints
+		if _, ok := seen[ept]; ok {
+			continue
+		}
+		seen[ept] = struct{}{}
+		nodes = append(nodes, &Node{Address: ept, Instance: in})
+	}
+	if len(nodes) == 0 {
+		log.Warnf("[HTTP] Zero endpoint found, refused to write, instances: %v", ins)
+		return false
+	}
+	r.mu.Lock()
+	r.nodes = nodes
+	r.mu.Unlock()
+	return true
+}
+
+// pick picks a node with the balancer.
+func (r *resolver) pick(ctx context.Context) (*Node, DoneFunc, error) {
+	r.mu.RLock()
+	nodes := r.nodes
+	r.mu.RUnlock()
+	return r.balancer.Pick(ctx, nodes)
+}
+
+func (r *resolver) Close() error {
+	r.cancel()
+	return r.watcher.Stop()
+}
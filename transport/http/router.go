@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/transport"
+)
+
+// HandlerFunc defines a function to serve HTTP requests.
+type HandlerFunc func(Context) error
+
+type varsKey struct{}
+
+// route is a handler registered for a method and a path template.
+type route struct {
+	template string
+	segments []segment
+	handler  http.Handler
+}
+
+// Router is an HTTP router, routes are registered relative to its prefix
+// and wrapped by its filters.
+//
+//	r := srv.Route("/v1", auth)
+//	r.GET("/users/{id}", getUser)
+//	r.Group("/admin", admin).DELETE("/users/{id}", deleteUser)
+type Router struct {
+	prefix  string
+	srv     *Server
+	filters []FilterFunc
+}
+
+func newRouter(prefix string, srv *Server, filters ...FilterFunc) *Router {
+	return &Router{
+		prefix:  prefix,
+		srv:     srv,
+		filters: filters,
+	}
+}
+
+// Group returns a new router group with the prefix appended to the router
+// prefix, its filters run after the router filters.
+func (r *Router) Group(prefix string, filters ...FilterFunc) *Router {
+	fs := make([]FilterFunc, 0, len(r.filters)+len(filters))
+	fs = append(fs, r.filters...)
+	fs = append(fs, filters...)
+	return newRouter(joinPath(r.prefix, prefix), r.srv, fs...)
+}
+
+// Handle registers a handler for the method and the path template relative
+// to the router prefix. Path templates have literal and variable segments,
+// e.g. /users/{id}, and may end with a variable matching the rest of the
+// path, e.g. /files/{path...}. Handle panics when the template is invalid
+// or already registered for the method.
+func (r *Router) Handle(method, relativePath string, h HandlerFunc, filters ...FilterFunc) {
+	fs := make([]FilterFunc, 0, len(r.filters)+len(filters))
+	fs = append(fs, r.filters...)
+	fs = append(fs, filters...)
+	r.srv.handle(method, joinPath(r.prefix, relativePath), h, fs...)
+}
+
+// GET registers a new GET route for a path with matching handler in the router.
+func (r *Router) GET(path string, h HandlerFunc, m ...FilterFunc) {
+	r.Handle(http.MethodGet, path, h, m...)
+}
+
+// HEAD registers a new HEAD route for a path with matching handler in the router.
+func (r *Router) HEAD(path string, h HandlerFunc, m ...FilterFunc) {
+	r.Handle(http.MethodHead, path, h, m...)
+}
+
+// POST registers a new POST route for a path with matching handler in the router.
+func (r *Router) POST(path string, h HandlerFunc, m ...FilterFunc) {
+	r.Handle(http.MethodPost, path, h, m...)
+}
+
+// PUT registers a new PUT route for a path with matching handler in the router.
+func (r *Router) PUT(path string, h HandlerFunc, m ...FilterFunc) {
+	r.Handle(http.MethodPut, path, h, m...)
+}
+
+// PATCH registers a new PATCH route for a path with matching handler in the router.
+func (r *Router) PATCH(path string, h HandlerFunc, m ...FilterFunc) {
+	r.Handle(http.MethodPatch, path, h, m...)
+}
+
+// DELETE registers a new DELETE route for a path with matching handler in the router.
+func (r *Router) DELETE(path string, h HandlerFunc, m ...FilterFunc) {
+	r.Handle(http.MethodDelete, path, h, m...)
+}
+
+// OPTIONS registers a new OPTIONS route for a path with matching handler in the router.
+func (r *Router) OPTIONS(path string, h HandlerFunc, m ...FilterFunc) {
+	r.Handle(http.MethodOptions, path, h, m...)
+}
+
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if prefix != "" && path == "/" {
+		return prefix
+	}
+	return prefix + path
+}
+
+// handle registers the route in the routing tree.
+func (s *Server) handle(method, template string, h HandlerFunc, filters ...FilterFunc) {
+	segs, err := parseTemplate(template)
+	if err != nil {
+		panic(err)
+	}
+	r := &route{template: template, segments: segs}
+	r.handler = FilterChain(filters...)(s.serveRoute(r, h))
+
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	if err = s.routes.add(segs, method, r); err != nil {
+		panic(err)
+	}
+}
+
+// serveRoute runs the service middleware matching the transport operation
+// around the route handler, and encodes its error.
+func (s *Server) serveRoute(r *route, h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		vars, _ := req.Context().Value(varsKey{}).(url.Values)
+		next := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, h(&wrapper{srv: s, req: req.WithContext(ctx), res: res, vars: vars})
+		}
+		operation := r.template
+		if tr, ok := transport.FromServerContext(req.Context()); ok {
+			operation = tr.Operation()
+		}
+		if ms := s.middleware.Match(operation); len(ms) > 0 {
+			next = middleware.Chain(ms...)(next)
+		}
+		if _, err := next(req.Context(), req); err != nil {
+			s.ene(res, req, err)
+		}
+	})
+}
+
+// dispatch finds the route of the request and serves it with an HTTP
+// transport in the server context.
+func (s *Server) dispatch(res http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.EscapedPath(), "/"), "/")
+
+	s.routesMu.RLock()
+	n, values := s.routes.match(parts, nil, req.Method)
+	if n == nil && req.Method == http.MethodHead {
+		n, values = s.routes.match(parts, nil, http.MethodGet)
+	}
+	var (
+		r     *route
+		allow []string
+	)
+	if n != nil {
+		if r = n.routes[req.Method]; r == nil {
+			r = n.routes[http.MethodGet]
+		}
+	} else if m, _ := s.routes.match(parts, nil, ""); m != nil {
+		for method := range m.routes {
+			allow = append(allow, method)
+		}
+	}
+	s.routesMu.RUnlock()
+
+	if r == nil {
+		if len(allow) == 0 {
+			http.NotFound(res, req)
+			return
+		}
+		sort.Strings(allow)
+		res.Header().Set("Allow", strings.Join(allow, ", "))
+		http.Error(res, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	tr := &Transport{
+		operation:    r.template,
+		pathTemplate: r.template,
+		reqHeader:    headerCarrier(req.Header),
+		replyHeader:  headerCarrier(res.Header()),
+		request:      req,
+	}
+	if s.endpoint != nil {
+		tr.endpoint = s.endpoint.String()
+	}
+	ctx := transport.NewServerContext(req.Context(), tr)
+	ctx = context.WithValue(ctx, varsKey{}, r.vars(values))
+	req = req.WithContext(ctx)
+	tr.request = req
+	r.handler.ServeHTTP(res, req)
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/transport"
+)
+
+func serve(srv *Server, method, path string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+	return res
+}
+
+func TestRouter(t *testing.T) {
+	srv := NewServer()
+	reply := func(name string) HandlerFunc {
+		return func(c Context) error {
+			return c.String(http.StatusOK, name+" "+c.Vars().Encode())
+		}
+	}
+	r := srv.Route("/v1")
+	r.GET("/users", reply("list"))
+	r.GET("/users/{id}", reply("get"))
+	r.POST("/users/{id}", reply("update"))
+	r.GET("/users/me", reply("me"))
+	r.GET("/users/{id}/posts/{post}", reply("post"))
+	r.Group("/files").GET("/{path...}", reply("file"))
+	srv.Handle(http.MethodGet, "/", reply("root"))
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "root "},
+		{http.MethodGet, "/v1/users", http.StatusOK, "list "},
+		{http.MethodGet, "/v1/users/1", http.StatusOK, "get id=1"},
+		{http.MethodPost, "/v1/users/1", http.StatusOK, "update id=1"},
+		{http.MethodGet, "/v1/users/me", http.StatusOK, "me "},
+		{http.MethodPost, "/v1/users/me", http.StatusOK, "update id=me"},
+		{http.MethodGet, "/v1/users/a%2Fb", http.StatusOK, "get id=a%2Fb"},
+		{http.MethodGet, "/v1/users/1/posts/2", http.StatusOK, "post id=1&post=2"},
+		{http.MethodGet, "/v1/files/a/b.txt", http.StatusOK, "file path=a%2Fb.txt"},
+		{http.MethodGet, "/v1/files/", http.StatusOK, "file path="},
+		{http.MethodHead, "/v1/users/1", http.StatusOK, ""},
+		{http.MethodDelete, "/v1/users/1", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/v1/users/1/comments", http.StatusNotFound, ""},
+		{http.MethodGet, "/v2/users", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		res := serve(srv, tt.method, tt.path)
+		if res.Code != tt.code {
+			t.Errorf("%s %s: expected status %d got %d", tt.method, tt.path, tt.code, res.Code)
+			continue
+		}
+		if tt.code == http.StatusOK && tt.method != http.MethodHead && res.Body.String() != tt.body {
+			t.Errorf("%s %s: expected %q got %q", tt.method, tt.path, tt.body, res.Body.String())
+		}
+	}
+	if allow := serve(srv, http.MethodDelete, "/v1/users/1").Header().Get("Allow"); allow != "GET, POST" {
+		t.Errorf("expected Allow %q got %q", "GET, POST", allow)
+	}
+}
+
+func TestRouter_Conflict(t *testing.T) {
+	srv := NewServer()
+	h := func(Context) error { return nil }
+	srv.Handle(http.MethodGet, "/users/{id}", h)
+	for _, tmpl := range []string{"/users/{name}", "users", "/users/{id", "/{a}/{a}", "/{path...}/x", "/{}"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected %q to panic", tmpl)
+				}
+			}()
+			srv.Handle(http.MethodGet, tmpl, h)
+		}()
+	}
+}
+
+func TestRouter_Transport(t *testing.T) {
+	var (
+		operation string
+		template  string
+	)
+	srv := NewServer()
+	srv.Route("/v1").GET("/users/{id}", func(c Context) error {
+		if tr, ok := transport.FromServerContext(c); ok {
+			operation = tr.Operation()
+			template = tr.(Transporter).PathTemplate()
+		}
+		if req, ok := RequestFromServerContext(c); !ok || req.URL.Path != "/v1/users/1" {
+			t.Errorf("expected the request in the server context, got %v", req)
+		}
+		return nil
+	})
+	serve(srv, http.MethodGet, "/v1/users/1")
+	if operation != "/v1/users/{id}" || template != "/v1/users/{id}" {
+		t.Errorf("expected operation and template /v1/users/{id}, got %q and %q", operation, template)
+	}
+}
+
+func TestRouter_Middleware(t *testing.T) {
+	var calls []string
+	record := func(name string) middleware.Middleware {
+		return func(next middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls = append(calls, name)
+				return next(ctx, req)
+			}
+		}
+	}
+	filter := func(name string) FilterFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	srv := NewServer(Middleware(record("default")), Filter(filter("server")))
+	srv.Use("/v1/users/*", record("users"))
+	r := srv.Route("/v1", filter("router"))
+	r.Group("/users", filter("group")).GET("/{id}", func(c Context) error {
+		calls = append(calls, "handler")
+		return nil
+	}, filter("route"))
+	r.GET("/posts", func(Context) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	serve(srv, http.MethodGet, "/v1/users/1")
+	expected := []string{"server", "router", "group", "route", "default", "users", "handler"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected %v got %v", expected, calls)
+	}
+	calls = nil
+	serve(srv, http.MethodGet, "/v1/posts")
+	expected = []string{"server", "router", "default", "handler"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected %v got %v", expected, calls)
+	}
+}
+
+func TestRouter_Error(t *testing.T) {
+	denied := errors.New("denied")
+	srv := NewServer(Middleware(func(middleware.Handler) middleware.Handler {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, denied
+		}
+	}))
+	srv.Handle(http.MethodGet, "/", func(Context) error { return nil })
+	res := serve(srv, http.MethodGet, "/")
+	if res.Code != http.StatusInternalServerError || !strings.Contains(res.Body.String(), "denied") {
+		t.Errorf("expected status 500 with the error, got %d %q", res.Code, res.Body.String())
+	}
+
+	srv = NewServer(ErrorEncoder(func(w http.ResponseWriter, _ *http.Request, err error) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	srv.Handle(http.MethodGet, "/", func(Context) error { return denied })
+	if res = serve(srv, http.MethodGet, "/"); res.Code != http.StatusForbidden {
+		t.Errorf("expected status %d got %d", http.StatusForbidden, res.Code)
+	}
+}
+
+func TestRouter_Probes(t *testing.T) {
+	srv := NewServer(ReadinessPath("/readyz"), Filter(func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}))
+	if code := serve(srv, http.MethodGet, "/readyz").Code; code != http.StatusOK {
+		t.Errorf("expected probes to bypass filters, got %d", code)
+	}
+	if code := serve(srv, http.MethodGet, "/v1").Code; code != http.StatusUnauthorized {
+		t.Errorf("expected filters to run, got %d", code)
+	}
+
+	// disabled by default, the routes are served
+	srv = NewServer()
+	for _, path := range []string{"/healthz", "/readyz"} {
+		srv.Handle(http.MethodGet, path, func(c Context) error {
+			return c.Result(http.StatusAccepted, nil)
+		})
+		if code := serve(srv, http.MethodGet, path).Code; code != http.StatusAccepted {
+			t.Errorf("%s: expected the route to be served, got %d", path, code)
+		}
+	}
+}
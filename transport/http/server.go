@@ -9,8 +9,10 @@ import (
 
 	"github.com/apus-run/sea-kit/log"
 
+	"github.com/apus-run/gaia/health"
 	"github.com/apus-run/gaia/internal/endpoint"
 	"github.com/apus-run/gaia/internal/host"
+	"github.com/apus-run/gaia/internal/inherit"
 	"github.com/apus-run/gaia/middleware"
 	"github.com/apus-run/gaia/transport"
 )
@@ -18,6 +20,8 @@ import (
 var (
 	_ transport.Server     = (*Server)(nil)
 	_ transport.Endpointer = (*Server)(nil)
+	_ transport.Readier    = (*Server)(nil)
+	_ transport.Drainer    = (*Server)(nil)
 	_ http.Handler         = (*Server)(nil)
 )
 
@@ -50,6 +54,7 @@ func NewServer(opts ...ServerOption) *Server {
 		ReadTimeout:  srv.readTimeout,
 		WriteTimeout: srv.writeTimeout,
 	}
+	srv.handler = FilterChain(srv.filters...)(http.HandlerFunc(srv.dispatch))
 
 	return srv
 }
@@ -63,8 +68,57 @@ func (s *Server) Use(selector string, m ...middleware.Middleware) {
 	s.middleware.Add(selector, m...)
 }
 
+// Route returns a router with the path prefix, its filters wrap every route
+// registered through it.
+func (s *Server) Route(prefix string, filters ...FilterFunc) *Router {
+	return newRouter(prefix, s, filters...)
+}
+
+// Handle registers a handler for the method and the path template, see
+// Router.Handle.
+func (s *Server) Handle(method, pathTemplate string, h HandlerFunc, filters ...FilterFunc) {
+	s.handle(method, pathTemplate, h, filters...)
+}
+
+// ServeHTTP answers the probes and the metrics, then serves the request with the matching
+// route, wrapped by the server filters.
 func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	s.Handler.ServeHTTP(res, req)
+	if s.livenessPath != "" && req.URL.Path == s.livenessPath {
+		s.serveLiveness(res)
+		return
+	}
+	if s.readinessPath != "" && req.URL.Path == s.readinessPath {
+		s.serveReadiness(res)
+		return
+	}
+	if s.metrics != nil && req.URL.Path == s.metricsPath {
+		s.metrics.ServeHTTP(res, req)
+		return
+	}
+	s.handler.ServeHTTP(res, req)
+}
+
+// serveLiveness answers the liveness probe with the liveness of the health
+// aggregator, if any.
+func (s *Server) serveLiveness(res http.ResponseWriter) {
+	if s.health != nil {
+		health.WriteReport(res, s.health.Liveness())
+		return
+	}
+	res.WriteHeader(http.StatusOK)
+}
+
+// serveReadiness answers the readiness probe, failing it while draining.
+func (s *Server) serveReadiness(res http.ResponseWriter) {
+	if s.draining.Load() {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if s.health != nil {
+		health.WriteReport(res, s.health.Readiness())
+		return
+	}
+	res.WriteHeader(http.StatusOK)
 }
 
 // Endpoint return a real address to registry endpoint.
@@ -84,7 +138,9 @@ func (s *Server) Start(ctx context.Context) error {
 	if err := s.listenAndEndpoint(); err != nil {
 		return err
 	}
+	// BaseContext is called by Serve right before it starts accepting
 	s.BaseContext = func(net.Listener) context.Context {
+		s.readyOnce.Do(func() { close(s.ready) })
 		return ctx
 	}
 
@@ -102,33 +158,41 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// Drain fails the readiness probe and disables keep-alives so that clients
+// reconnect elsewhere, in-flight requests are still served.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+	s.SetKeepAlivesEnabled(false)
+}
+
 // Stop stop the HTTP server.
 func (s *Server) Stop(ctx context.Context) error {
 	log.Infof("[HTTP] server is stopping")
 	return s.Shutdown(ctx)
 }
 
-// Health 心跳检测
-func (s *Server) Health() bool {
-	if s.lis == nil {
-		return false
-	}
+// Ready returns a channel that is closed once the server is serving.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
 
-	conn, err := s.lis.Accept()
-	if err != nil {
+// Health reports whether the server is serving, not draining and,
+// with a health aggregator, alive.
+func (s *Server) Health() bool {
+	select {
+	case <-s.ready:
+	default:
 		return false
 	}
-
-	er := conn.Close()
-	if er != nil {
+	if s.draining.Load() {
 		return false
 	}
-	return true
+	return s.health == nil || s.health.Liveness().Status == health.StatusUp
 }
 
 func (s *Server) listenAndEndpoint() error {
 	if s.lis == nil {
-		lis, err := net.Listen(s.network, s.address)
+		lis, err := inherit.Listen(s.network, s.address)
 		if err != nil {
 			s.err = err
 			return err
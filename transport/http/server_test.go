@@ -5,9 +5,12 @@ import (
 	"errors"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/apus-run/gaia/health"
 )
 
 func TestServeHTTP(t *testing.T) {
@@ -53,3 +56,91 @@ func TestServer(t *testing.T) {
 		t.Errorf("expected nil got %v", srv.Stop(ctx))
 	}
 }
+
+func TestServer_Ready(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	select {
+	case <-srv.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("server is not ready")
+	}
+	if err := srv.Stop(ctx); err != nil {
+		t.Errorf("expected nil got %v", err)
+	}
+}
+
+func TestServer_Drain(t *testing.T) {
+	srv := NewServer(ReadinessPath("/readyz"))
+	probe := func() int {
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return res.Code
+	}
+	if code := probe(); code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	srv.Drain()
+	if code := probe(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d got %d", http.StatusServiceUnavailable, code)
+	}
+}
+
+func TestServer_Health(t *testing.T) {
+	ctx := context.Background()
+	h := health.New()
+	h.AddLiveness("live", health.CheckerFunc(func(context.Context) error { return nil }))
+	h.AddReadiness("db", health.CheckerFunc(func(context.Context) error { return errors.New("down") }))
+	go func() {
+		_ = h.Start(ctx)
+	}()
+	defer h.Stop(ctx)
+	<-h.Ready()
+
+	srv := NewServer(Health(h), LivenessPath("/healthz"), ReadinessPath("/readyz"))
+	probe := func(path string) int {
+		res := httptest.NewRecorder()
+		srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res.Code
+	}
+	if code := probe("/healthz"); code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if code := probe("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d got %d", http.StatusServiceUnavailable, code)
+	}
+
+	if srv.Health() {
+		t.Error("expected unhealthy before serving")
+	}
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	<-srv.Ready()
+	if !srv.Health() {
+		t.Error("expected healthy while serving")
+	}
+	srv.Drain()
+	if srv.Health() {
+		t.Error("expected unhealthy while draining")
+	}
+	_ = srv.Stop(ctx)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	srv := NewServer(Metrics("/metrics", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("gaia_server_requests_total 1\n"))
+	})))
+	res := httptest.NewRecorder()
+	srv.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "gaia_server_requests_total") {
+		t.Errorf("expected the metrics got %d %q", res.Code, res.Body.String())
+	}
+}
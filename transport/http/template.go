@@ -0,0 +1,162 @@
+package http
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// segment is a parsed path template segment, either a literal, a variable
+// matching one segment, e.g. {id}, or a trailing variable matching the rest
+// of the path, e.g. {path...}.
+type segment struct {
+	literal string
+	name    string
+	rest    bool
+}
+
+// parseTemplate parses a path template such as /v1/users/{id}.
+func parseTemplate(tmpl string) ([]segment, error) {
+	if !strings.HasPrefix(tmpl, "/") {
+		return nil, fmt.Errorf("path template %q must start with /", tmpl)
+	}
+	var (
+		segs  []segment
+		names = make(map[string]bool)
+		parts = strings.Split(strings.TrimPrefix(tmpl, "/"), "/")
+	)
+	for i, p := range parts {
+		if !strings.HasPrefix(p, "{") {
+			if strings.ContainsAny(p, "{}") {
+				return nil, fmt.Errorf("path template %q: invalid segment %q", tmpl, p)
+			}
+			segs = append(segs, segment{literal: p})
+			continue
+		}
+		if !strings.HasSuffix(p, "}") {
+			return nil, fmt.Errorf("path template %q: invalid segment %q", tmpl, p)
+		}
+		seg := segment{name: p[1 : len(p)-1]}
+		if strings.HasSuffix(seg.name, "...") {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("path template %q: %s must be the last segment", tmpl, p)
+			}
+			seg.name, seg.rest = strings.TrimSuffix(seg.name, "..."), true
+		}
+		if seg.name == "" || strings.ContainsAny(seg.name, "{}") {
+			return nil, fmt.Errorf("path template %q: invalid variable %q", tmpl, p)
+		}
+		if names[seg.name] {
+			return nil, fmt.Errorf("path template %q: duplicate variable %q", tmpl, seg.name)
+		}
+		names[seg.name] = true
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+// node is a node of the routing tree, one per path segment. Literal
+// children take precedence over variables, which take precedence over
+// trailing variables.
+type node struct {
+	literals map[string]*node
+	param    *node
+	rest     *node
+	routes   map[string]*route
+}
+
+func newNode() *node {
+	return &node{
+		literals: make(map[string]*node),
+		routes:   make(map[string]*route),
+	}
+}
+
+// add adds the route for its method, following the template segments.
+func (n *node) add(segs []segment, method string, r *route) error {
+	for _, seg := range segs {
+		var next **node
+		switch {
+		case seg.rest:
+			next = &n.rest
+		case seg.name != "":
+			next = &n.param
+		default:
+			child, ok := n.literals[seg.literal]
+			if !ok {
+				child = newNode()
+				n.literals[seg.literal] = child
+			}
+			n = child
+			continue
+		}
+		if *next == nil {
+			*next = newNode()
+		}
+		n = *next
+	}
+	if prev, ok := n.routes[method]; ok {
+		return fmt.Errorf("route %s %s conflicts with %s", method, r.template, prev.template)
+	}
+	n.routes[method] = r
+	return nil
+}
+
+// match returns the node matching the path segments that has a route for
+// method, any route if method is empty, with the values of the variables in
+// template order.
+func (n *node) match(parts []string, values []string, method string) (*node, []string) {
+	if len(parts) == 0 {
+		if n.has(method) {
+			return n, values
+		}
+		if n.rest != nil && n.rest.has(method) {
+			return n.rest, append(values, "")
+		}
+		return nil, nil
+	}
+	if child, ok := n.literals[parts[0]]; ok {
+		if m, vs := child.match(parts[1:], values, method); m != nil {
+			return m, vs
+		}
+	}
+	if n.param != nil && parts[0] != "" {
+		if m, vs := n.param.match(parts[1:], append(values, parts[0]), method); m != nil {
+			return m, vs
+		}
+	}
+	if n.rest != nil && n.rest.has(method) {
+		return n.rest, append(values, strings.Join(parts, "/"))
+	}
+	return nil, nil
+}
+
+// has reports whether the node has a route for method, any route if method
+// is empty.
+func (n *node) has(method string) bool {
+	if method == "" {
+		return len(n.routes) > 0
+	}
+	_, ok := n.routes[method]
+	return ok
+}
+
+// vars returns the variables of the route from the matched values.
+func (r *route) vars(values []string) url.Values {
+	vars := make(url.Values, len(values))
+	i := 0
+	for _, seg := range r.segments {
+		if seg.name == "" {
+			continue
+		}
+		if i < len(values) {
+			if v, err := url.PathUnescape(values[i]); err == nil {
+				vars.Set(seg.name, v)
+			} else {
+				vars.Set(seg.name, values[i])
+			}
+		}
+		i++
+	}
+	return vars
+}
@@ -0,0 +1,241 @@
+package job
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule describes when a job runs.
+type Schedule interface {
+	// Next returns the next activation time after t, or the zero time when
+	// the schedule never fires again.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a schedule firing every d, it never fires unless d is
+// positive.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	if e <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(e))
+}
+
+// cron is a cron expression, every field is a bit set of the allowed values.
+type cron struct {
+	second, minute, hour, dom, month, dow uint64
+	loc                                   *time.Location
+}
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	seconds = bounds{0, 59, nil}
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	doms    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 0 and 7 are both Sunday
+	dows = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCron parses a cron expression in loc, nil means time.Local.
+//
+// The expression has five fields, minute, hour, day of month, month and day
+// of week, or six with a leading second field. A field is *, a value, a
+// range a-b, a step */n or a-b/n, or a comma separated list of those. Months
+// and days of week accept three letter names. The descriptors @yearly,
+// @monthly, @weekly, @daily, @hourly and "@every <duration>" are supported.
+func ParseCron(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		dur, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("job: invalid cron %q: %w", spec, err)
+		}
+		return Every(dur), nil
+	}
+	if s, ok := descriptors[spec]; ok {
+		spec = s
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("job: invalid cron %q: expected 5 or 6 fields, found %d", spec, len(fields))
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	c := &cron{loc: loc}
+	for i, f := range []struct {
+		bits *uint64
+		b    bounds
+	}{
+		{&c.second, seconds},
+		{&c.minute, minutes},
+		{&c.hour, hours},
+		{&c.dom, doms},
+		{&c.month, months},
+		{&c.dow, dows},
+	} {
+		v, err := parseField(fields[i], f.b)
+		if err != nil {
+			return nil, fmt.Errorf("job: invalid cron %q: %w", spec, err)
+		}
+		*f.bits = v
+	}
+	if has(c.dow, 7) {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	return c, nil
+}
+
+// parseField parses a comma separated list of ranges into a bit set.
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, expr := range strings.Split(field, ",") {
+		rng, step, hasStep := strings.Cut(expr, "/")
+		lo, hi := b.min, b.max
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(from, b); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(to, b); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// a/n means a to max every n
+				hi = b.max
+			}
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("range %q is empty", expr)
+		}
+		n := uint64(1)
+		if hasStep {
+			var err error
+			if n, err = strconv.ParseUint(step, 10, 8); err != nil || n == 0 {
+				return 0, fmt.Errorf("invalid step %q", expr)
+			}
+		}
+		for v := lo; v <= hi; v += uint(n) {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if uint(v) < b.min || uint(v) > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, b.min, b.max)
+	}
+	return uint(v), nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// all reports whether set holds every value from min to max.
+func all(set uint64, min, max uint) bool {
+	return bits.OnesCount64(set) == int(max-min+1)
+}
+
+// Next returns the next time after t matching the expression. It walks from
+// the largest field to the smallest, resetting the smaller ones whenever a
+// larger one moves.
+func (c *cron) Next(t time.Time) time.Time {
+	orig := t.Location()
+	t = t.In(c.loc).Add(time.Second - time.Duration(t.Nanosecond()))
+	// give up after five years, e.g. for February 30th
+	limit := t.AddDate(5, 0, 0)
+
+wrap:
+	for t.Before(limit) {
+		for !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			if t.Month() == time.January {
+				continue wrap
+			}
+		}
+		for !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			if t.Day() == 1 {
+				continue wrap
+			}
+		}
+		for !has(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			if t.Hour() == 0 {
+				continue wrap
+			}
+		}
+		for !has(c.minute, t.Minute()) {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			if t.Minute() == 0 {
+				continue wrap
+			}
+		}
+		for !has(c.second, t.Second()) {
+			t = t.Truncate(time.Second).Add(time.Second)
+			if t.Second() == 0 {
+				continue wrap
+			}
+		}
+		return t.In(orig)
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron: when both day of month and day of week are
+// restricted, either one matching is enough.
+func (c *cron) dayMatches(t time.Time) bool {
+	domMatch := has(c.dom, t.Day())
+	dowMatch := has(c.dow, int(t.Weekday()))
+	if all(c.dom, doms.min, doms.max) || all(c.dow, 0, 6) {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,91 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 15, 30, 500, time.UTC)
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		{"* * * * *", []time.Time{
+			time.Date(2024, time.January, 31, 10, 16, 0, 0, time.UTC),
+			time.Date(2024, time.January, 31, 10, 17, 0, 0, time.UTC),
+		}},
+		{"*/20 * * * * *", []time.Time{
+			time.Date(2024, time.January, 31, 10, 15, 40, 0, time.UTC),
+			time.Date(2024, time.January, 31, 10, 16, 0, 0, time.UTC),
+		}},
+		{"0 3 * * *", []time.Time{
+			time.Date(2024, time.February, 1, 3, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 2, 3, 0, 0, 0, time.UTC),
+		}},
+		{"30 9 * * mon-fri", []time.Time{
+			time.Date(2024, time.February, 1, 9, 30, 0, 0, time.UTC),
+			time.Date(2024, time.February, 2, 9, 30, 0, 0, time.UTC),
+			time.Date(2024, time.February, 5, 9, 30, 0, 0, time.UTC),
+		}},
+		{"0 0 29 feb *", []time.Time{
+			time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 1,15 * 7", []time.Time{
+			time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 11, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@monthly", []time.Time{
+			time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@every 90s", []time.Time{
+			base.Add(90 * time.Second),
+			base.Add(180 * time.Second),
+		}},
+	}
+	for _, tt := range tests {
+		sched, err := ParseCron(tt.spec, time.UTC)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		next := base
+		for _, want := range tt.want {
+			if next = sched.Next(next); !next.Equal(want) {
+				t.Errorf("%q: expected %v got %v", tt.spec, want, next)
+				break
+			}
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* * * * * * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@every soon",
+	} {
+		if _, err := ParseCron(spec, nil); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestParseCron_Never(t *testing.T) {
+	sched, err := ParseCron("0 0 30 2 *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := sched.Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected never, got %v", next)
+	}
+}
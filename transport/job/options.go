@@ -0,0 +1,42 @@
+package job
+
+import (
+	"time"
+
+	"github.com/apus-run/gaia/middleware"
+)
+
+// ServerOption is a job server option.
+type ServerOption func(*Server)
+
+// Middleware with the middleware run around every job.
+func Middleware(m ...middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware.Use(m...)
+	}
+}
+
+// Location with the time zone of cron expressions, time.Local by default.
+func Location(loc *time.Location) ServerOption {
+	return func(s *Server) {
+		s.location = loc
+	}
+}
+
+// JobOption is a job option.
+type JobOption func(*job)
+
+// AllowOverlap lets a run start while the previous one is still running, by
+// default the run is skipped.
+func AllowOverlap() JobOption {
+	return func(j *job) {
+		j.overlap = true
+	}
+}
+
+// Timeout with the timeout of every run of the job, zero means no timeout.
+func Timeout(d time.Duration) JobOption {
+	return func(j *job) {
+		j.timeout = d
+	}
+}
@@ -0,0 +1,41 @@
+package job
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/middleware"
+)
+
+func TestLocation(t *testing.T) {
+	o := &Server{}
+	v := time.UTC
+	Location(v)(o)
+	if !reflect.DeepEqual(v, o.location) {
+		t.Errorf("expected %v got %v", v, o.location)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	o := NewServer()
+	v := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			return "chained", nil
+		}
+	}
+	Middleware(v)(o)
+	if ms := o.middleware.Match("any"); len(ms) != 1 {
+		t.Errorf("expected 1 middleware got %d", len(ms))
+	}
+}
+
+func TestJobOptions(t *testing.T) {
+	j := &job{}
+	AllowOverlap()(j)
+	Timeout(time.Second)(j)
+	if !j.overlap || j.timeout != time.Second {
+		t.Errorf("unexpected job options %+v", j)
+	}
+}
@@ -0,0 +1,253 @@
+// Package job is a transport server running scheduled jobs, on cron
+// expressions or fixed intervals, within the application lifecycle.
+//
+//	srv := job.NewServer(job.Middleware(recovery.Recovery()))
+//	err := srv.AddCron("cleanup", "0 3 * * *", cleanup)
+//	err = srv.AddInterval("sync", time.Minute, sync, job.AllowOverlap())
+//	app := gaia.New(gaia.WithServer(httpSrv, srv))
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apus-run/sea-kit/log"
+
+	"github.com/apus-run/gaia/internal/matcher"
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/transport"
+)
+
+var (
+	_ transport.Server  = (*Server)(nil)
+	_ transport.Readier = (*Server)(nil)
+)
+
+// ErrStarted is returned when a job is added to a started server.
+var ErrStarted = errors.New("job: server already started")
+
+// Func is the work of a job.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       Func
+	overlap  bool
+	timeout  time.Duration
+	running  atomic.Bool
+}
+
+// Server is a job server.
+type Server struct {
+	middleware matcher.Matcher
+	location   *time.Location
+	jobs       map[string]*job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+	wg      sync.WaitGroup
+
+	stop      chan struct{}
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// NewServer creates a job server by options.
+func NewServer(opts ...ServerOption) *Server {
+	srv := &Server{
+		middleware: matcher.New(),
+		location:   time.Local,
+		jobs:       make(map[string]*job),
+		stop:       make(chan struct{}),
+		ready:      make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	return srv
+}
+
+// Use uses a middleware with selector, the job name.
+// selector:
+//   - '*'
+//   - 'report.*'
+//   - 'cleanup'
+func (s *Server) Use(selector string, m ...middleware.Middleware) {
+	s.middleware.Add(selector, m...)
+}
+
+// AddCron adds a job running on a cron expression, see ParseCron.
+func (s *Server) AddCron(name, spec string, fn Func, opts ...JobOption) error {
+	sched, err := ParseCron(spec, s.location)
+	if err != nil {
+		return err
+	}
+	return s.Add(name, sched, fn, opts...)
+}
+
+// AddInterval adds a job running every d.
+func (s *Server) AddInterval(name string, d time.Duration, fn Func, opts ...JobOption) error {
+	return s.Add(name, Every(d), fn, opts...)
+}
+
+// Add adds a job running on sched. Jobs must be added before Start.
+func (s *Server) Add(name string, sched Schedule, fn Func, opts ...JobOption) error {
+	j := &job{name: name, schedule: sched, fn: fn}
+	for _, o := range opts {
+		o(j)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrStarted
+	}
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job: duplicate job %q", name)
+	}
+	s.jobs[name] = j
+	return nil
+}
+
+// Start start the job server, it blocks until the server is stopped.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.started = true
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	log.Infof("[Job] server is scheduling %d jobs", len(jobs))
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		j := j
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.schedule(j)
+		}()
+	}
+	s.readyOnce.Do(func() { close(s.ready) })
+	wg.Wait()
+	return nil
+}
+
+// Stop stops scheduling and waits for the running jobs until ctx is done,
+// then cancels their context.
+func (s *Server) Stop(ctx context.Context) error {
+	log.Info("[Job] server is stopping")
+	// dispatch adds to s.wg under s.mu unless stopped, so no job is added
+	// once the wait below starts.
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if cancel != nil {
+			cancel()
+		}
+		return ctx.Err()
+	}
+}
+
+// Ready returns a channel that is closed once the jobs are scheduled.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// schedule dispatches j at every activation until the server stops.
+func (s *Server) schedule(j *job) {
+	for {
+		now := time.Now()
+		next := j.schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C:
+			s.dispatch(j)
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// dispatch runs j in the background, unless it is still running and does
+// not allow overlap.
+func (s *Server) dispatch(j *job) {
+	if !j.overlap && !j.running.CompareAndSwap(false, true) {
+		log.Warnf("[Job] %s is still running, skipped", j.name)
+		return
+	}
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		j.running.Store(false)
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		if !j.overlap {
+			defer j.running.Store(false)
+		}
+		if err := s.run(j); err != nil {
+			log.Errorf("[Job] %s failed: %v", j.name, err)
+		}
+	}()
+}
+
+// run runs j once through the middleware.
+func (s *Server) run(j *job) error {
+	ctx := s.ctx
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+	ctx = transport.NewServerContext(ctx, &Transport{
+		endpoint:    "job://" + j.name,
+		operation:   j.name,
+		reqHeader:   headerCarrier(http.Header{}),
+		replyHeader: headerCarrier(http.Header{}),
+	})
+	h := func(ctx context.Context, _ any) (any, error) {
+		return nil, j.fn(ctx)
+	}
+	if next := s.middleware.Match(j.name); len(next) > 0 {
+		h = middleware.Chain(next...)(h)
+	}
+	_, err := h(ctx, nil)
+	return err
+}
@@ -0,0 +1,182 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apus-run/gaia/middleware"
+	"github.com/apus-run/gaia/transport"
+)
+
+func start(t *testing.T, srv *Server) {
+	t.Helper()
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+	select {
+	case <-srv.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("server is not ready")
+	}
+}
+
+func TestServer(t *testing.T) {
+	var (
+		runs      atomic.Int32
+		operation atomic.Value
+		chained   atomic.Bool
+	)
+	srv := NewServer(Middleware(func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			chained.Store(true)
+			return next(ctx, req)
+		}
+	}))
+	err := srv.AddInterval("tick", 10*time.Millisecond, func(ctx context.Context) error {
+		if tr, ok := transport.FromServerContext(ctx); ok && tr.Kind() == transport.KindJob {
+			operation.Store(tr.Operation())
+		}
+		runs.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.AddInterval("tick", time.Second, nil); err == nil {
+		t.Error("expected duplicate job error")
+	}
+	start(t, srv)
+	if err = srv.AddInterval("late", time.Second, nil); !errors.Is(err, ErrStarted) {
+		t.Errorf("expected %v got %v", ErrStarted, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if err = srv.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	n := runs.Load()
+	if n < 2 {
+		t.Errorf("expected several runs, got %d", n)
+	}
+	if op, _ := operation.Load().(string); op != "tick" {
+		t.Errorf("expected operation tick got %q", op)
+	}
+	if !chained.Load() {
+		t.Error("expected middleware to run")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != n {
+		t.Error("expected no runs after stop")
+	}
+}
+
+func TestServer_Overlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []JobOption
+		overlap bool
+	}{
+		{"skip", nil, false},
+		{"overlap", []JobOption{AllowOverlap()}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak atomic.Int32
+			srv := NewServer()
+			_ = srv.AddInterval("slow", 5*time.Millisecond, func(ctx context.Context) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(40 * time.Millisecond)
+				return nil
+			}, tt.opts...)
+			start(t, srv)
+			time.Sleep(100 * time.Millisecond)
+			_ = srv.Stop(context.Background())
+			if got := peak.Load() > 1; got != tt.overlap {
+				t.Errorf("expected overlap %v, peak %d", tt.overlap, peak.Load())
+			}
+		})
+	}
+}
+
+func TestServer_StopTimeout(t *testing.T) {
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	srv := NewServer()
+	_ = srv.AddInterval("stuck", 5*time.Millisecond, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+	start(t, srv)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v got %v", context.DeadlineExceeded, err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the running job to be canceled")
+	}
+}
+
+func TestServer_Timeout(t *testing.T) {
+	errc := make(chan error, 1)
+	srv := NewServer()
+	_ = srv.AddInterval("slow", 5*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		select {
+		case errc <- ctx.Err():
+		default:
+		}
+		return ctx.Err()
+	}, Timeout(10*time.Millisecond))
+	start(t, srv)
+	defer srv.Stop(context.Background())
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the job to time out")
+	}
+}
+
+func TestServer_StopWhileStarting(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		srv := NewServer()
+		_ = srv.AddInterval("tick", time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, AllowOverlap())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = srv.Start(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i)*time.Millisecond)
+		_ = srv.Stop(ctx)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected Start to return once stopped")
+		}
+	}
+}
@@ -16,6 +16,20 @@ type Endpointer interface {
 	Endpoint() (*url.URL, error)
 }
 
+// Readier is implemented by servers that can report when they are
+// accepting traffic.
+type Readier interface {
+	// Ready returns a channel that is closed once the server is serving.
+	Ready() <-chan struct{}
+}
+
+// Drainer is implemented by servers that can stop advertising themselves as
+// ready while still serving in-flight requests.
+type Drainer interface {
+	// Drain marks the server as not ready, e.g. by failing health checks.
+	Drain()
+}
+
 // Header is the storage medium used by a Header.
 type Header interface {
 	Get(key string) string
@@ -59,6 +73,7 @@ func (k Kind) String() string { return string(k) }
 const (
 	KindGRPC Kind = "grpc"
 	KindHTTP Kind = "http"
+	KindJob  Kind = "job"
 )
 
 type (